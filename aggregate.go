@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Properties of the summary events of WithErrorAggregation
+const (
+	ErrorCountProperty      = "ErrorCount"
+	FirstOccurrenceProperty = "FirstOccurrence"
+	LastOccurrenceProperty  = "LastOccurrence"
+	SampleStackProperty     = "SampleStack"
+)
+
+// errorAggregator holds back repeats of identical Error events for a window after
+// the first, so an error storm reaches SEQ as the first event and one summary
+type errorAggregator struct {
+	window time.Duration
+	mu     sync.Mutex
+	groups map[string]*errorGroup
+}
+
+// errorGroup counts the repeats of an error within its window
+type errorGroup struct {
+	template    string
+	fields      map[string]interface{} // of the first event
+	count       int                    // repeats held back
+	first, last time.Time              // of the repeats
+	stack       string                 // where the first repeat was logged
+	timer       *time.Timer
+}
+
+// WithErrorAggregation holds back Error events identical to one logged less than
+// window ago, same template and Error property, and logs a summary when the window
+// ends: the first event's template and properties with the number of repeats as
+// ErrorCount, their FirstOccurrence and LastOccurrence and, as SampleStack, the stack
+// of the first repeat. Other levels, Fatal included, are never held back.
+func WithErrorAggregation(window time.Duration) Option {
+	return func(l *SEQLogger) {
+		l.errorAggregator = &errorAggregator{window: window, groups: make(map[string]*errorGroup)}
+	}
+}
+
+// holdBack reports whether an Error event repeats one logged within the window,
+// counting it if so; fields are the event's merged context and call-site fields
+func (l *SEQLogger) holdBack(template string, fields map[string]interface{}) bool {
+	a := l.errorAggregator
+	key := template
+	if err, ok := fields[ErrorProperty]; ok {
+		key += "\x00" + fmt.Sprint(err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	group, ok := a.groups[key]
+	if !ok {
+		group = &errorGroup{template: template, fields: fields}
+		group.timer = time.AfterFunc(a.window, func() { l.summarizeErrors(key) })
+		a.groups[key] = group
+		return false
+	}
+
+	now := time.Now()
+	if group.count == 0 {
+		group.first, group.stack = now, string(debug.Stack())
+	}
+	group.count++
+	group.last = now
+	return true
+}
+
+// summarizeErrors ends the window of the group key and logs its summary if it held
+// back any event
+func (l *SEQLogger) summarizeErrors(key string) {
+	a := l.errorAggregator
+	a.mu.Lock()
+	group, ok := a.groups[key]
+	delete(a.groups, key)
+	a.mu.Unlock()
+	if ok {
+		l.logErrorSummary(group)
+	}
+}
+
+// flushErrorSummaries ends every window early, as the logger is closing
+func (l *SEQLogger) flushErrorSummaries() {
+	a := l.errorAggregator
+	a.mu.Lock()
+	groups := a.groups
+	a.groups = make(map[string]*errorGroup)
+	a.mu.Unlock()
+	for _, group := range groups {
+		group.timer.Stop()
+		l.logErrorSummary(group)
+	}
+}
+
+// logErrorSummary queues the summary of a group that held back events. The summary
+// bypasses the filters, which the events it stands for already passed.
+func (l *SEQLogger) logErrorSummary(group *errorGroup) {
+	if group.count == 0 {
+		return
+	}
+	fields := copyFields(group.fields)
+	if fields == nil {
+		fields = make(map[string]interface{}, 4)
+	}
+	fields[ErrorCountProperty] = group.count
+	fields[FirstOccurrenceProperty] = group.first.UTC().Format(time.RFC3339Nano)
+	fields[LastOccurrenceProperty] = group.last.UTC().Format(time.RFC3339Nano)
+	fields[SampleStackProperty] = group.stack
+
+	template := group.template + " ({" + ErrorCountProperty + "} more between {" + FirstOccurrenceProperty + "} and {" + LastOccurrenceProperty + "})"
+	logMessage := l.newLogMessage(LevelError, template, fields)
+	// Nothing is queued once the logger is closed, when the summary has nowhere to go
+	l.enqueue(&logMessage)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestErrorAggregationSummarizesRepeats(t *testing.T) {
+	logger := newQueueLogger(10, WithErrorAggregation(50*time.Millisecond))
+	for i := 0; i < 5; i++ {
+		logger.Log(LevelError, "Payment failed for {Order}", map[string]interface{}{"Order": i, ErrorProperty: errors.New("card declined")})
+	}
+	logger.Log(LevelError, "Payment failed for {Order}", map[string]interface{}{"Order": 9, ErrorProperty: errors.New("timeout")})
+	logger.Log(LevelFatal, "Out of memory", nil)
+	logger.Log(LevelFatal, "Out of memory", nil)
+
+	for _, want := range []string{"Payment failed for {Order}", "Payment failed for {Order}", "Out of memory", "Out of memory"} {
+		if got := (<-logger.logChan).MessageTemplate; got != want {
+			t.Errorf("Expected %q to pass, got %q", want, got)
+		}
+	}
+	if len(logger.logChan) != 0 {
+		t.Fatalf("Expected the repeats to be held back, got %d more events", len(logger.logChan))
+	}
+
+	var summary LogMessage
+	select {
+	case summary = <-logger.logChan:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a summary once the window ended")
+	}
+	if summary.Level != LevelError || !strings.HasPrefix(summary.MessageTemplate, "Payment failed for {Order} ({ErrorCount} more") {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+	if summary.Fields[ErrorCountProperty] != 4 || summary.Fields["Order"] != 0 || summary.Fields[ErrorProperty] != "card declined" {
+		t.Errorf("Unexpected summary properties %v", summary.Fields)
+	}
+	if stack, _ := summary.Fields[SampleStackProperty].(string); !strings.Contains(stack, "TestErrorAggregationSummarizesRepeats") {
+		t.Errorf("Expected the stack of a repeat, got %q", stack)
+	}
+	if summary.Fields[FirstOccurrenceProperty] == nil || summary.Fields[LastOccurrenceProperty] == nil {
+		t.Errorf("Expected the first and last occurrences, got %v", summary.Fields)
+	}
+
+	// A new window starts with the next occurrence
+	logger.Log(LevelError, "Payment failed for {Order}", map[string]interface{}{"Order": 10, ErrorProperty: errors.New("card declined")})
+	if got := (<-logger.logChan).Fields["Order"]; got != 10 {
+		t.Errorf("Expected the error to pass after the window, got %v", got)
+	}
+}
+
+func TestErrorAggregationFlushesOnClose(t *testing.T) {
+	server := newSeqRecorder(t)
+	logger := newTestLogger(t, server.URL+EndpointRaw, 10, WithErrorAggregation(time.Hour))
+	logger.Log(LevelError, "Disk full", nil)
+	logger.Log(LevelError, "Disk full", nil)
+	logger.Close()
+
+	if received := server.received(); !strings.Contains(received, "more between") {
+		t.Errorf("Expected the summary to be sent on close, got %s", received)
+	}
+}
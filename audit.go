@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// defaultAuditDir is where audit events wait on disk until Seq confirms them
+var defaultAuditDir = filepath.Join(os.TempDir(), "seqlogger-audit")
+
+// AuditLogger sends compliance-critical events one at a time and reports whether Seq
+// accepted them. Each event is persisted to disk before it is sent and removed only
+// once delivery is confirmed, so an event that cannot be delivered is never lost.
+type AuditLogger struct {
+	logger        *SEQLogger
+	contextFields map[string]interface{}
+	dir           string
+	client        *http.Client
+}
+
+// Audit returns a guaranteed-delivery variant of the logger sharing its server,
+// encoder, enrichers and global fields. Audit events bypass level filtering and sampling.
+func (l *SEQLogger) Audit() *AuditLogger {
+	root := l.pipeline()
+	dir := root.auditDir
+	if dir == "" {
+		dir = defaultAuditDir
+	}
+	return &AuditLogger{logger: root, contextFields: l.contextFields, dir: dir, client: root.transport.client}
+}
+
+// Log sends an event synchronously, retrying transient failures with backoff until Seq
+// accepts it or ctx is done. When an error is returned the event stays in the audit
+// directory and can be resent with Replay.
+func (a *AuditLogger) Log(ctx context.Context, level, message string, fields map[string]interface{}) error {
+	if a.logger.closed.Load() {
+		return ErrClosed
+	}
+
+	logMessage := a.logger.newLogMessage(level, message, mergeFields(a.contextFields, fields))
+	// Audit events are resent until confirmed, so every one carries a token
+	logMessage.Fields = withEventToken(logMessage.Fields)
+	if err := validateLogMessage(&logMessage); err != nil {
+		return fmt.Errorf("Validation failed for audit message: %v", err)
+	}
+
+	buf := getEncodeBuffer()
+	defer putEncodeBuffer(buf)
+	if err := a.logger.encoder.Encode(buf, []LogMessage{logMessage}); err != nil {
+		return fmt.Errorf("Failed to marshal audit message: %v", err)
+	}
+
+	path, err := a.persist(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	return a.send(ctx, path, buf.Bytes())
+}
+
+// Replay resends the events left in the audit directory by earlier failed deliveries,
+// oldest first, and stops at the first event that still cannot be delivered
+func (a *AuditLogger) Replay(ctx context.Context) error {
+	paths, err := filepath.Glob(filepath.Join(a.dir, "audit-*.event"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("Failed to read audit event: %v", err)
+		}
+		if err := a.send(ctx, path, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// persist durably writes an encoded event to the audit directory; the file name sorts
+// by creation time so Replay preserves the original order
+func (a *AuditLogger) persist(data []byte) (string, error) {
+	if err := os.MkdirAll(a.dir, 0o700); err != nil {
+		return "", fmt.Errorf("Failed to create audit directory: %v", err)
+	}
+
+	file, err := os.CreateTemp(a.dir, fmt.Sprintf("audit-%020d-*.event", time.Now().UnixNano()))
+	if err != nil {
+		return "", fmt.Errorf("Failed to persist audit event: %v", err)
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("Failed to persist audit event: %v", err)
+	}
+	return file.Name(), nil
+}
+
+// send posts a persisted event until Seq accepts it, removing the file on success.
+// Unlike batches, audit events are retried without an attempt limit.
+func (a *AuditLogger) send(ctx context.Context, path string, data []byte) error {
+	body, release := a.logger.compressBody(data)
+	defer release()
+
+	retry := a.logger.retry
+	backoff := retry.initialBackoff
+	for {
+		err := a.logger.post(ctx, a.client, body)
+		if err == nil {
+			os.Remove(path)
+			return nil
+		}
+		if !err.retryable {
+			return fmt.Errorf("Audit event kept at %s: %v", path, err)
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("Audit event kept at %s: %v (last error: %v)", path, ctx.Err(), err)
+		case <-timer.C:
+		}
+		backoff = retry.next(backoff)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newAuditLogger creates an AuditLogger posting to seqURL and persisting to a temporary directory
+func newAuditLogger(t *testing.T, seqURL string) *AuditLogger {
+	logger := newQueueLogger(1, WithEncoder(RawEncoder{}), WithAuditDir(t.TempDir()),
+		WithRetry(1, time.Millisecond, 5*time.Millisecond))
+	logger.seqURL = seqURL
+	return logger.Audit()
+}
+
+// pendingAuditEvents lists the events waiting in the audit directory
+func pendingAuditEvents(t *testing.T, audit *AuditLogger) []string {
+	paths, err := filepath.Glob(filepath.Join(audit.dir, "audit-*.event"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return paths
+}
+
+func TestAuditRetriesUntilDelivered(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	audit := newAuditLogger(t, server.URL)
+	if err := audit.Log(context.Background(), LevelInformation, "User {UserId} deleted", map[string]interface{}{"UserId": 7}); err != nil {
+		t.Fatalf("Expected delivery, got %v", err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("Expected 3 attempts, got %d", n)
+	}
+	if pending := pendingAuditEvents(t, audit); len(pending) != 0 {
+		t.Errorf("Expected delivered event to be removed, found %v", pending)
+	}
+}
+
+func TestAuditKeepsUndeliveredEvent(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	audit := newAuditLogger(t, server.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := audit.Log(ctx, LevelWarning, "Permissions changed", nil); err == nil {
+		t.Fatal("Expected an error when delivery cannot be confirmed")
+	}
+	if pending := pendingAuditEvents(t, audit); len(pending) != 1 {
+		t.Fatalf("Expected the undelivered event to stay on disk, found %v", pending)
+	}
+
+	down.Store(false)
+	if err := audit.Replay(context.Background()); err != nil {
+		t.Fatalf("Expected replay to succeed, got %v", err)
+	}
+	if pending := pendingAuditEvents(t, audit); len(pending) != 0 {
+		t.Errorf("Expected replayed event to be removed, found %v", pending)
+	}
+}
+
+func TestAuditFailsFastOnRejectedEvent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	audit := newAuditLogger(t, server.URL)
+	if err := audit.Log(context.Background(), LevelError, "Export failed", nil); err == nil {
+		t.Error("Expected an error for a rejected event")
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import "sync/atomic"
+
+// watermark calls fn once each time the queue fills past fraction of its capacity;
+// it is re-armed when the queue drains back below the mark
+type watermark struct {
+	fraction float64
+	fn       func(depth, capacity int)
+	crossed  atomic.Bool
+}
+
+// QueueDepth returns the number of events waiting to be sent, in the express lane and the
+// sub-queues of WithFairQueueing too
+func (l *SEQLogger) QueueDepth() int {
+	root := l.pipeline()
+	depth := len(root.currentQueue()) + len(root.express)
+	if root.fair != nil {
+		depth += root.fair.len()
+	}
+	return depth
+}
+
+// QueueCapacity returns how many events can wait before Log blocks
+func (l *SEQLogger) QueueCapacity() int {
+	return cap(l.pipeline().currentQueue())
+}
+
+// checkWatermarks fires the watermarks the queue has just crossed and re-arms those it has fallen below
+func (l *SEQLogger) checkWatermarks() {
+	depth, capacity := len(l.logChan), cap(l.logChan)
+	for _, w := range l.watermarks {
+		if float64(depth) < w.fraction*float64(capacity) {
+			if w.crossed.Load() {
+				w.crossed.Store(false)
+			}
+			continue
+		}
+		if !w.crossed.Load() && w.crossed.CompareAndSwap(false, true) {
+			w.fn(depth, capacity)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestQueueIntrospection(t *testing.T) {
+	logger := newQueueLogger(10)
+	logger.Log(LevelInformation, "Order placed", nil)
+	logger.Log(LevelInformation, "Order placed", nil)
+
+	if depth := logger.QueueDepth(); depth != 2 {
+		t.Errorf("Expected depth 2, got %d", depth)
+	}
+	if capacity := logger.QueueCapacity(); capacity != 10 {
+		t.Errorf("Expected capacity 10, got %d", capacity)
+	}
+}
+
+func TestWatermarkFiresOncePerCrossing(t *testing.T) {
+	var fired []int
+	logger := newQueueLogger(10, WithWatermark(0.5, func(depth, capacity int) {
+		fired = append(fired, depth)
+	}))
+
+	for i := 0; i < 8; i++ {
+		logger.Log(LevelInformation, "Order placed", nil)
+	}
+	if len(fired) != 1 || fired[0] != 5 {
+		t.Fatalf("Expected a single callback at depth 5, got %v", fired)
+	}
+
+	// Draining below the mark re-arms the watermark
+	drainQueue(logger)
+	for i := 0; i < 7; i++ {
+		logger.Log(LevelInformation, "Order placed", nil)
+	}
+	if len(fired) != 2 {
+		t.Errorf("Expected the watermark to fire again after draining, got %v", fired)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import "time"
+
+// Builder configures a SEQLogger fluently, as an alternative to passing options to
+// NewSEQLogger, mirroring Serilog's LoggerConfiguration:
+//
+//	logger, err := New().Server(url).APIKey(key).MinLevel(LevelWarning).Batch(100, 2*time.Second).Build()
+type Builder struct {
+	seqURL     string
+	apiKey     string
+	bufferSize int
+	opts       []Option
+}
+
+// New starts building a SEQLogger
+func New() *Builder {
+	return &Builder{bufferSize: defaultBufferSize}
+}
+
+// Server sets the SEQ ingestion endpoint
+func (b *Builder) Server(seqURL string) *Builder {
+	b.seqURL = seqURL
+	return b
+}
+
+// APIKey sets the SEQ API key
+func (b *Builder) APIKey(apiKey string) *Builder {
+	b.apiKey = apiKey
+	return b
+}
+
+// BufferSize sets the number of events queued before Log blocks
+func (b *Builder) BufferSize(size int) *Builder {
+	b.bufferSize = size
+	return b
+}
+
+// MinLevel drops events less severe than level
+func (b *Builder) MinLevel(level string) *Builder {
+	return b.With(WithMinLevel(level))
+}
+
+// Override sets a separate minimum level for events whose SourceContext starts with prefix
+func (b *Builder) Override(prefix, level string) *Builder {
+	return b.With(WithLevelOverride(prefix, level))
+}
+
+// Sample keeps only the given fraction of events per level
+func (b *Builder) Sample(rates map[string]float64) *Builder {
+	return b.With(WithSampling(rates))
+}
+
+// Batch sets the maximum number of events per request and how long to wait for a batch to fill up
+func (b *Builder) Batch(size int, interval time.Duration) *Builder {
+	return b.With(WithBatching(size, interval))
+}
+
+// Retry resends failed batches with exponential backoff
+func (b *Builder) Retry(maxAttempts int, initialBackoff, maxBackoff time.Duration) *Builder {
+	return b.With(WithRetry(maxAttempts, initialBackoff, maxBackoff))
+}
+
+// Encoder sets the Encoder used to serialize batches
+func (b *Builder) Encoder(encoder Encoder) *Builder {
+	return b.With(WithEncoder(encoder))
+}
+
+// Enrich adds enrichers run for every event
+func (b *Builder) Enrich(enrichers ...Enricher) *Builder {
+	for _, enricher := range enrichers {
+		b.With(WithEnricher(enricher))
+	}
+	return b
+}
+
+// WriteTo routes events at or above minLevel to sink in addition to the SEQ server
+func (b *Builder) WriteTo(sink Sink, minLevel string) *Builder {
+	return b.With(WithSink(sink, minLevel))
+}
+
+// SeqMinLevel sends only events at or above level to the SEQ server
+func (b *Builder) SeqMinLevel(level string) *Builder {
+	return b.With(WithSeqMinLevel(level))
+}
+
+// GlobalFields adds properties to every event
+func (b *Builder) GlobalFields(fields map[string]interface{}) *Builder {
+	return b.With(WithGlobalFields(fields))
+}
+
+// Destructure sets the depth and breadth limits used when destructuring field values
+func (b *Builder) Destructure(maxDepth, maxProperties int) *Builder {
+	return b.With(WithMaxDepth(maxDepth), WithMaxProperties(maxProperties))
+}
+
+// Transport shares an HTTP client and request limit with other loggers
+func (b *Builder) Transport(transport *Transport) *Builder {
+	return b.With(WithTransport(transport))
+}
+
+// WAL keeps a write-ahead log in dir for at-least-once delivery across crashes
+func (b *Builder) WAL(dir string) *Builder {
+	return b.With(WithWAL(dir))
+}
+
+// Endpoint picks the ingestion endpoint appended to a base server URL instead of negotiating it
+func (b *Builder) Endpoint(endpoint string) *Builder {
+	return b.With(WithEndpoint(endpoint))
+}
+
+// With applies options that have no dedicated builder method
+func (b *Builder) With(opts ...Option) *Builder {
+	b.opts = append(b.opts, opts...)
+	return b
+}
+
+// Build creates the SEQLogger and starts sending its events; it returns NewSEQLogger's
+// *ConfigError for settings the logger can't work with
+func (b *Builder) Build() (*SEQLogger, error) {
+	return NewSEQLogger(b.seqURL, b.apiKey, b.bufferSize, b.opts...)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuilderMatchesOptions(t *testing.T) {
+	logger, err := New().
+		Server("http://localhost:5341/api/events/raw").
+		APIKey("key").
+		BufferSize(20).
+		MinLevel(LevelWarning).
+		Batch(50, 2*time.Second).
+		Retry(3, time.Second, 10*time.Second).
+		Encoder(CLEFEncoder{}).
+		Enrich(RuntimeEnricher(), ProcessEnricher()).
+		GlobalFields(map[string]interface{}{"Application": "billing"}).
+		Destructure(3, 10).
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build: %v", err)
+	}
+
+	if logger.seqURL != "http://localhost:5341/api/events/raw" || logger.apiKey != "key" || cap(logger.logChan) != 20 {
+		t.Errorf("Unexpected connection settings %q %q %d", logger.seqURL, logger.apiKey, cap(logger.logChan))
+	}
+	if logger.minLevel.Load() != int32(levelRank(LevelWarning)) {
+		t.Errorf("Expected min level Warning")
+	}
+	if logger.batchSize != 50 || logger.batchInterval != 2*time.Second || logger.retry.maxAttempts != 3 {
+		t.Errorf("Unexpected batching or retry settings")
+	}
+	if _, ok := logger.encoder.(CLEFEncoder); !ok || len(logger.enrichers) != 2 {
+		t.Errorf("Unexpected encoder %T or %d enrichers", logger.encoder, len(logger.enrichers))
+	}
+	if logger.globalFields.load()["Application"] != "billing" || logger.normalizer.maxDepth != 3 || logger.normalizer.maxProperties != 10 {
+		t.Errorf("Unexpected global fields or destructuring limits")
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+// ForContext returns a child logger adding fields to every event it logs. Children
+// send through their root logger's queue, batching and delivery, so events from all
+// of them reach Seq in consolidated batches, and they follow the root's minimum level,
+// overrides and sampling. Fields passed to Log take precedence over the child's fields,
+// which take precedence over global fields. A CorrelationId the logger already has
+// is inherited and can't be replaced by fields.
+func (l *SEQLogger) ForContext(fields map[string]interface{}) *SEQLogger {
+	contextFields := mergeFields(copyFields(l.contextFields), copyFields(fields))
+	keepCorrelationID(l.contextFields, contextFields)
+	return &SEQLogger{
+		root:          l.pipeline(),
+		contextFields: contextFields,
+	}
+}
+
+// pipeline returns the logger owning the queue and delivery that l sends through
+func (l *SEQLogger) pipeline() *SEQLogger {
+	if l.root != nil {
+		return l.root
+	}
+	return l
+}
+
+// Named returns a child logger stamping a SourceContext property on its events, so they
+// can be filtered per component in Seq and given their own level with WithLevelOverride.
+// Naming a named logger appends to its name: Named("payments").Named("worker")
+// logs with SourceContext "payments.worker".
+func (l *SEQLogger) Named(name string) *SEQLogger {
+	if parent, ok := l.contextFields[SourceContextProperty].(string); ok && parent != "" {
+		name = parent + "." + name
+	}
+	return l.ForContext(map[string]interface{}{SourceContextProperty: name})
+}
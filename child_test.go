@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestChildLoggersShareOneBatch(t *testing.T) {
+	logger := newQueueLogger(10, WithGlobalFields(map[string]interface{}{"Application": "shop"}))
+	orders := logger.ForContext(map[string]interface{}{"Component": "orders", "Region": "eu"})
+	refunds := orders.ForContext(map[string]interface{}{"Component": "refunds"})
+
+	orders.Log(LevelInformation, "Order placed", nil)
+	refunds.Log(LevelInformation, "Refund issued", map[string]interface{}{"Region": "us"})
+
+	if depth := logger.QueueDepth(); depth != 2 {
+		t.Fatalf("Expected both events in the root queue, got %d", depth)
+	}
+	placed, issued := <-logger.logChan, <-logger.logChan
+	if placed.Fields["Application"] != "shop" || placed.Fields["Component"] != "orders" || placed.Fields["Region"] != "eu" {
+		t.Errorf("Unexpected child fields %v", placed.Fields)
+	}
+	if issued.Fields["Component"] != "refunds" || issued.Fields["Region"] != "us" {
+		t.Errorf("Expected nested and call-site fields to win, got %v", issued.Fields)
+	}
+}
+
+func TestChildFollowsRootLevel(t *testing.T) {
+	logger := newQueueLogger(10, WithMinLevel(LevelWarning))
+	child := logger.ForContext(map[string]interface{}{"Component": "orders"})
+
+	child.Log(LevelInformation, "Order placed", nil)
+	logger.minLevel.Store(int32(levelRank(LevelInformation)))
+	child.Log(LevelInformation, "Order shipped", nil)
+
+	if depth := logger.QueueDepth(); depth != 1 {
+		t.Errorf("Expected the child to follow the root's minimum level, got %d events", depth)
+	}
+}
+
+func TestChildFlushesThroughRoot(t *testing.T) {
+	seq := newSeqRecorder(t)
+	logger := newTestLogger(t, seq.URL+EndpointRaw, 10)
+	child := logger.ForContext(map[string]interface{}{"Component": "orders"})
+
+	child.Log(LevelInformation, "Order placed", nil)
+	if err := child.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	child.Close()
+
+	if !strings.Contains(seq.received(), `"Component":"orders"`) {
+		t.Errorf("Expected the child's event to be delivered, got %s", seq.received())
+	}
+}
+
+func TestNamedLoggerOverrides(t *testing.T) {
+	logger := newQueueLogger(10,
+		WithMinLevel(LevelInformation),
+		WithLevelOverride("payments.worker", LevelDebug),
+	)
+	worker := logger.Named("payments").Named("worker")
+	access := logger.Named("http.access")
+
+	worker.Log(LevelDebug, "Retrying charge", nil)
+	access.Log(LevelDebug, "GET /", nil)
+
+	if depth := logger.QueueDepth(); depth != 1 {
+		t.Fatalf("Expected only the overridden source to log at Debug, got %d events", depth)
+	}
+	if source := (<-logger.logChan).Fields[SourceContextProperty]; source != "payments.worker" {
+		t.Errorf("Expected SourceContext payments.worker, got %v", source)
+	}
+}
+
+func TestChildInheritsCorrelationID(t *testing.T) {
+	logger := newQueueLogger(10)
+	if id := logger.CorrelationID(); id != "" {
+		t.Errorf("Expected no CorrelationId on the root, got %q", id)
+	}
+
+	request := logger.ForContext(map[string]interface{}{CorrelationIDProperty: "req-1"})
+	worker := request.Named("worker").ForContext(map[string]interface{}{CorrelationIDProperty: "req-2", "Job": 3})
+	scope := worker.BeginScope("Import", nil)
+	<-logger.logChan
+
+	for _, l := range []*SEQLogger{request, worker, scope.Logger()} {
+		if id := l.CorrelationID(); id != "req-1" {
+			t.Errorf("Expected the inherited CorrelationId, got %q", id)
+		}
+	}
+	if worker.contextFields["Job"] != 3 {
+		t.Errorf("Expected the other fields to be added, got %v", worker.contextFields)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestEventTypeIDIsStablePerTemplate(t *testing.T) {
+	a := eventTypeID("User {UserId} logged in")
+	if a != eventTypeID("User {UserId} logged in") {
+		t.Errorf("Expected the same template to hash to the same id")
+	}
+	if a == eventTypeID("User {UserId} logged out") {
+		t.Errorf("Expected different templates to hash to different ids")
+	}
+	if got := string(appendEventTypeID(nil, 0x0a1b2c3d)); got != "0a1b2c3d" {
+		t.Errorf("Unexpected event id rendering %q", got)
+	}
+}
+
+func TestCLEFEncoderWritesOneLinePerEvent(t *testing.T) {
+	batch := []LogMessage{
+		{
+			Timestamp:       "2024-01-02T03:04:05Z",
+			Level:           "Information",
+			MessageTemplate: "Application started",
+			EventID:         eventTypeID("Application started"),
+		},
+		{
+			Timestamp:       "2024-01-02T03:04:06Z",
+			Level:           "Error",
+			MessageTemplate: "Failed {Count}",
+			Fields:          sanitizePropertyNames(map[string]interface{}{"Count": 3, "@mt": "user value"}),
+			EventID:         eventTypeID("Failed {Count}"),
+		},
+	}
+
+	var out bytes.Buffer
+	if err := (CLEFEncoder{}).Encode(&out, batch); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []map[string]interface{}
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var event map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Line is not valid JSON: %v\n%s", err, scanner.Text())
+		}
+		events = append(events, event)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(events))
+	}
+
+	if events[0]["@mt"] != "Application started" || events[0]["@l"] != "Information" {
+		t.Errorf("Unexpected first event %#v", events[0])
+	}
+	if events[0]["@i"] != string(appendEventTypeID(nil, batch[0].EventID)) {
+		t.Errorf("Expected @i to carry the event type id, got %#v", events[0]["@i"])
+	}
+	if events[1]["Count"] != float64(3) || events[1]["@@mt"] != "user value" || events[1]["@mt"] != "Failed {Count}" {
+		t.Errorf("Unexpected second event %#v", events[1])
+	}
+}
+
+func TestRawEncoderWritesEventTypeIDProperty(t *testing.T) {
+	fields := map[string]interface{}{"Count": 3}
+	batch := []LogMessage{
+		{Level: "Information", MessageTemplate: "Application started", EventID: eventTypeID("Application started")},
+		{Level: "Error", MessageTemplate: "Failed {Count}", Fields: fields, EventID: eventTypeID("Failed {Count}")},
+		{Level: "Error", MessageTemplate: "Failed", Fields: map[string]interface{}{EventTypeIDProperty: "custom"}, EventID: eventTypeID("Failed")},
+	}
+
+	var out bytes.Buffer
+	if err := (RawEncoder{}).Encode(&out, batch); err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		Events []struct{ Properties map[string]interface{} }
+	}
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("Encoded batch is not valid JSON: %v\n%s", err, out.String())
+	}
+	for i, event := range payload.Events[:2] {
+		if got := event.Properties[EventTypeIDProperty]; got != string(appendEventTypeID(nil, batch[i].EventID)) {
+			t.Errorf("Expected event %d to carry its type id, got %#v", i, event.Properties)
+		}
+	}
+	if payload.Events[1].Properties["Count"] != float64(3) || len(fields) != 1 {
+		t.Errorf("Expected the fields kept and left alone, got %#v and %#v", payload.Events[1].Properties, fields)
+	}
+	if got := payload.Events[2].Properties[EventTypeIDProperty]; got != "custom" {
+		t.Errorf("Expected a field of the same name to win, got %#v", got)
+	}
+}
@@ -0,0 +1,583 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// cliUsage lists the seqlog subcommands
+const cliUsage = `Usage: seqlog <command> [flags]
+
+Commands:
+  ingest      read CLEF or raw JSON events from stdin, one per line, and send them
+  tail        print new events matching a filter as they arrive
+  search      print the most recent events matching a filter
+  pipe        forward newline-delimited JSON of any schema, or plain text, from stdin
+  forward     receive Fluentd forward protocol records, e.g. from Docker's fluentd driver
+  syslog      receive RFC 3164 and RFC 5424 syslog messages over UDP and TCP
+  gelf        receive GELF messages over UDP and TCP
+  proxy       serve the SEQ ingestion API locally and forward events upstream
+  files       ship the lines appended to the files matching glob patterns
+  journal     ship systemd journal entries, optionally only those matching journalctl matches
+  eventlog    ship the records of Windows Event Log channels, Application and System by default
+  kubernetes  ship the logs of the containers on a Kubernetes node, run as a DaemonSet
+
+Long running commands reopen their files and reload -config on SIGHUP.
+Run 'seqlog <command> -h' for the flags of a command.
+`
+
+// cliOptions are the flags shared by every subcommand
+type cliOptions struct {
+	server     string
+	apiKey     string
+	configPath string
+}
+
+// register adds the shared flags to fs; the server and API key default to $SEQ_SERVER_URL and $SEQ_API_KEY
+func (o *cliOptions) register(fs *flag.FlagSet) {
+	server := os.Getenv("SEQ_SERVER_URL")
+	if server == "" {
+		server = "http://localhost:5341"
+	}
+	fs.StringVar(&o.server, "server", server, "SEQ server URL")
+	fs.StringVar(&o.apiKey, "apikey", os.Getenv("SEQ_API_KEY"), "SEQ API key")
+	fs.StringVar(&o.configPath, "config", "", "configuration file overriding -server and -apikey")
+}
+
+// logger creates the SEQLogger ingesting events, from the configuration file if one was given
+func (o *cliOptions) logger() (*SEQLogger, error) {
+	if o.configPath != "" {
+		return NewFromConfigFile(o.configPath)
+	}
+	return NewSEQLogger(o.server, o.apiKey, defaultBufferSize)
+}
+
+// daemonLogger creates the logger of a long running subcommand, which reopens its files
+// and reloads the configuration file on SIGHUP until the returned function closes it
+func (o *cliOptions) daemonLogger(ctx context.Context) (*SEQLogger, func(), error) {
+	logger, err := o.logger()
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		logger.ReloadOnHangup(ctx, o.configPath)
+	}()
+	return logger, func() {
+		cancel()
+		<-done
+		logger.Close()
+	}, nil
+}
+
+// queryClient creates the QueryClient of tail and search
+func (o *cliOptions) queryClient() (*QueryClient, error) {
+	if o.configPath == "" {
+		return NewQueryClient(o.server, o.apiKey, nil), nil
+	}
+	config, err := LoadConfigFile(o.configPath)
+	if err != nil {
+		return nil, err
+	}
+	apiKey, err := config.resolveAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	var opts []Option
+	if config.BasicAuth.Username != "" {
+		password, err := resolveSecret(config.BasicAuth.Password, "basic auth password")
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithBasicAuth(config.BasicAuth.Username, password))
+	}
+	return NewQueryClient(config.ServerURL, apiKey, nil, opts...), nil
+}
+
+// runCLI runs the seqlog subcommand named by args[0] and returns the process exit code
+func runCLI(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, cliUsage)
+		return 2
+	}
+
+	var options cliOptions
+	fs := flag.NewFlagSet("seqlog "+args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	options.register(fs)
+
+	var run func() error
+	switch args[0] {
+	case "ingest":
+		run = func() error { return runIngest(ctx, &options, stdin, stderr) }
+	case "tail":
+		filter := fs.String("filter", "", "SEQ filter expression")
+		interval := fs.Duration("interval", time.Second, "how often the server is polled")
+		run = func() error { return runTail(ctx, &options, *filter, *interval, stdout) }
+	case "pipe":
+		var mapping pipeMapping
+		mapping.register(fs)
+		run = func() error { return runPipe(ctx, &options, &mapping, stdin) }
+	case "forward":
+		var mapping pipeMapping
+		mapping.register(fs)
+		listen := fs.String("listen", ":24224", "TCP address to listen on")
+		run = func() error {
+			return serveWithLogger(ctx, &options, "tcp", *listen, func(logger *SEQLogger, ln net.Listener) error {
+				return logger.serveForward(ctx, ln, &mapping)
+			})
+		}
+	case "syslog":
+		udp := fs.String("udp", ":514", "UDP address to listen on, empty to disable")
+		tcp := fs.String("tcp", ":514", "TCP address to listen on, empty to disable")
+		run = func() error {
+			return runReceiver(ctx, &options, *udp, *tcp, (*SEQLogger).ServeSyslogPacket, (*SEQLogger).ServeSyslog)
+		}
+	case "gelf":
+		udp := fs.String("udp", ":12201", "UDP address to listen on, empty to disable")
+		tcp := fs.String("tcp", ":12201", "TCP address to listen on, empty to disable")
+		run = func() error {
+			return runReceiver(ctx, &options, *udp, *tcp, (*SEQLogger).ServeGELFPacket, (*SEQLogger).ServeGELF)
+		}
+	case "proxy":
+		listen := fs.String("listen", "localhost:5341", "HTTP address to listen on")
+		run = func() error { return runProxy(ctx, &options, *listen) }
+	case "files":
+		checkpoint := fs.String("checkpoint", "seqlog.offsets", "file keeping the offsets reached, empty to start over on every run")
+		interval := fs.Duration("interval", defaultTailInterval, "how often files are checked for new lines")
+		run = func() error { return runFiles(ctx, &options, *checkpoint, *interval, fs.Args()) }
+	case "journal":
+		cursor := fs.String("cursor", "seqlog.cursor", "file keeping the journal cursor reached, empty to ship new entries only")
+		run = func() error {
+			logger, closeLogger, err := options.daemonLogger(ctx)
+			if err != nil {
+				return err
+			}
+			defer closeLogger()
+			return NewJournalReader(logger, *cursor, fs.Args()...).Run(ctx)
+		}
+	case "eventlog":
+		checkpoint := fs.String("checkpoint", "seqlog.eventlog", "file keeping the record ids reached, empty to ship new records only")
+		interval := fs.Duration("interval", defaultEventLogInterval, "how often channels are queried for new records")
+		run = func() error { return runEventLog(ctx, &options, *checkpoint, *interval, fs.Args()) }
+	case "kubernetes":
+		checkpoint := fs.String("checkpoint", "seqlog.offsets", "file keeping the offsets reached, empty to start over on every run")
+		interval := fs.Duration("interval", defaultTailInterval, "how often log files are checked for new lines")
+		kubelet := fs.String("kubelet", defaultKubeletURL(), "kubelet URL the pods' labels are read from, empty for none")
+		insecure := fs.Bool("kubelet-insecure", false, "skip verifying the kubelet's certificate")
+		run = func() error {
+			return runKubernetes(ctx, &options, *checkpoint, *interval, *kubelet, *insecure, fs.Args())
+		}
+	case "search":
+		filter := fs.String("filter", "", "SEQ filter expression")
+		count := fs.Int("count", 100, "maximum number of events printed")
+		run = func() error { return runSearch(ctx, &options, *filter, *count, stdout) }
+	default:
+		fmt.Fprintf(stderr, "Unknown command %q\n\n%s", args[0], cliUsage)
+		return 2
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if err := run(); err != nil {
+		fmt.Fprintf(stderr, "seqlog %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// runIngest sends every event read from stdin, then flushes; lines that aren't events are reported and skipped
+func runIngest(ctx context.Context, options *cliOptions, stdin io.Reader, stderr io.Writer) error {
+	logger, err := options.logger()
+	if err != nil {
+		return err
+	}
+	defer logger.Close()
+
+	err = scanLines(stdin, func(line int, text []byte) {
+		event, err := parseIngestLine(text)
+		if err != nil {
+			fmt.Fprintf(stderr, "Skipping line %d: %v\n", line, err)
+			return
+		}
+		logger.LogAt(event.timestamp, event.level, event.template, event.fields)
+	})
+	if err != nil {
+		return err
+	}
+	return logger.Flush(ctx)
+}
+
+// serveWithLogger listens on address and runs serve with a new logger until ctx is
+// done, then closes the logger, sending everything received
+func serveWithLogger(ctx context.Context, options *cliOptions, network, address string, serve func(*SEQLogger, net.Listener) error) error {
+	logger, closeLogger, err := options.daemonLogger(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeLogger()
+
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return err
+	}
+	return serve(logger, ln)
+}
+
+// runReceiver serves a receiver on the UDP and TCP addresses that aren't empty until ctx is done
+func runReceiver(ctx context.Context, options *cliOptions, udp, tcp string,
+	servePacket func(*SEQLogger, context.Context, net.PacketConn) error,
+	serve func(*SEQLogger, context.Context, net.Listener) error,
+) error {
+	if udp == "" && tcp == "" {
+		return errors.New("no address to listen on")
+	}
+	logger, closeLogger, err := options.daemonLogger(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeLogger()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make(chan error, 2)
+	servers := 0
+	if udp != "" {
+		conn, err := net.ListenPacket("udp", udp)
+		if err != nil {
+			return err
+		}
+		servers++
+		go func() { errs <- servePacket(logger, ctx, conn) }()
+	}
+	if tcp != "" {
+		ln, err := net.Listen("tcp", tcp)
+		if err != nil {
+			return err
+		}
+		servers++
+		go func() { errs <- serve(logger, ctx, ln) }()
+	}
+
+	// Either server failing stops the other
+	var first error
+	for i := 0; i < servers; i++ {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+			cancel()
+		}
+	}
+	return first
+}
+
+// runFiles tails the files matching patterns until ctx is done
+func runFiles(ctx context.Context, options *cliOptions, checkpoint string, interval time.Duration, patterns []string) error {
+	if len(patterns) == 0 {
+		return errors.New("no file patterns given")
+	}
+	logger, closeLogger, err := options.daemonLogger(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeLogger()
+
+	tailer := NewFileTailer(logger, checkpoint, patterns...)
+	tailer.interval = interval
+	return tailer.Run(ctx)
+}
+
+// runEventLog ships the records of the event log channels until ctx is done
+func runEventLog(ctx context.Context, options *cliOptions, checkpoint string, interval time.Duration, channels []string) error {
+	if len(channels) == 0 {
+		channels = []string{"Application", "System"}
+	}
+	logger, closeLogger, err := options.daemonLogger(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeLogger()
+
+	reader := NewEventLogReader(logger, checkpoint, channels...)
+	reader.interval = interval
+	return reader.Run(ctx)
+}
+
+// defaultKubeletURL is the kubelet of the node named by $NODE_NAME, as set in a DaemonSet
+// from the downward API, if it is set
+func defaultKubeletURL() string {
+	if node := os.Getenv("NODE_NAME"); node != "" {
+		return "https://" + net.JoinHostPort(node, "10250")
+	}
+	return ""
+}
+
+// runKubernetes ships the container logs matching patterns until ctx is done
+func runKubernetes(ctx context.Context, options *cliOptions, checkpoint string, interval time.Duration, kubelet string, insecure bool, patterns []string) error {
+	logger, closeLogger, err := options.daemonLogger(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeLogger()
+
+	collector := NewKubernetesCollector(logger, checkpoint, patterns...)
+	collector.tailer.interval = interval
+	if kubelet != "" {
+		if err := collector.UseKubelet(kubelet, insecure); err != nil {
+			return err
+		}
+	}
+	return collector.Run(ctx)
+}
+
+// runProxy serves the ingestion API on listen until ctx is done, then sends what it received
+func runProxy(ctx context.Context, options *cliOptions, listen string) error {
+	logger, closeLogger, err := options.daemonLogger(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeLogger()
+	return serveHTTP(ctx, listen, logger.IngestionHandler())
+}
+
+// serveHTTP serves handler on address until ctx is done, then shuts the server down gracefully
+func serveHTTP(ctx context.Context, address string, handler http.Handler) error {
+	ln, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	stop := context.AfterFunc(ctx, func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	})
+	defer stop()
+
+	if err := server.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// scanLines calls fn with every non-blank line of r and its 1-based line number
+func scanLines(r io.Reader, fn func(line int, text []byte)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) > 0 {
+			fn(line, scanner.Bytes())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("Failed to read lines: %w", err)
+	}
+	return nil
+}
+
+// ingestEvent is an event read by seqlog ingest
+type ingestEvent struct {
+	timestamp time.Time
+	level     string
+	template  string
+	fields    map[string]interface{}
+}
+
+// parseIngestLine reads a CLEF event, or an event of SEQ's raw format with Timestamp,
+// Level, MessageTemplate and Properties. Missing levels are Information and missing
+// timestamps the time of ingestion.
+func parseIngestLine(line []byte) (ingestEvent, error) {
+	var object map[string]interface{}
+	if err := json.Unmarshal(line, &object); err != nil {
+		return ingestEvent{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return parseIngestObject(object)
+}
+
+// parseIngestObject reads an event decoded from a CLEF line or a raw format event
+func parseIngestObject(object map[string]interface{}) (ingestEvent, error) {
+	event := ingestEvent{level: LevelInformation, fields: make(map[string]interface{}, len(object))}
+	var timestamp string
+	if _, ok := object["MessageTemplate"]; ok {
+		timestamp, _ = object["Timestamp"].(string)
+		event.template, _ = object["MessageTemplate"].(string)
+		if level, ok := object["Level"].(string); ok && level != "" {
+			event.level = level
+		}
+		if properties, ok := object["Properties"].(map[string]interface{}); ok {
+			event.fields = properties
+		}
+	} else {
+		for key, value := range object {
+			switch key {
+			case "@t":
+				timestamp, _ = value.(string)
+			case "@l":
+				if level, ok := value.(string); ok && level != "" {
+					event.level = level
+				}
+			case "@mt":
+				event.template, _ = value.(string)
+			case "@m":
+				if message, ok := value.(string); ok && event.template == "" {
+					event.template = escapeTemplate(message)
+				}
+			case "@x":
+				event.fields["Exception"] = value
+			case "@i", "@r":
+				// Derived from the template again when the event is logged
+			default:
+				// A leading @@ escapes a property whose name starts with @
+				event.fields[strings.TrimPrefix(key, "@")] = value
+			}
+		}
+	}
+
+	if event.template == "" {
+		return ingestEvent{}, errors.New("no message template")
+	}
+	if timestamp != "" {
+		t, err := time.Parse(time.RFC3339Nano, timestamp)
+		if err != nil {
+			return ingestEvent{}, fmt.Errorf("invalid timestamp %q", timestamp)
+		}
+		event.timestamp = t
+	}
+	return event, nil
+}
+
+// escapeTemplate doubles the braces of a rendered message so it is logged verbatim as a template
+func escapeTemplate(message string) string {
+	return strings.NewReplacer("{", "{{", "}", "}}").Replace(message)
+}
+
+// runSearch prints the most recent events matching filter, oldest first
+func runSearch(ctx context.Context, options *cliOptions, filter string, count int, stdout io.Writer) error {
+	client, err := options.queryClient()
+	if err != nil {
+		return err
+	}
+	events, err := client.Events(ctx, filter, count)
+	if err != nil {
+		return err
+	}
+	for i := len(events) - 1; i >= 0; i-- {
+		printEvent(stdout, &events[i])
+	}
+	return nil
+}
+
+// tailPageSize is how many events tail fetches per request, and tailMaxPages how many
+// pages it reads in one poll to catch up with the events that arrived since the last
+const (
+	tailPageSize = 100
+	tailMaxPages = 10
+)
+
+// runTail prints events matching filter as they arrive until ctx is done. Each poll
+// pages back from the newest event to the newest one the previous poll returned.
+func runTail(ctx context.Context, options *cliOptions, filter string, interval time.Duration, stdout io.Writer) error {
+	client, err := options.queryClient()
+	if err != nil {
+		return err
+	}
+
+	// last is the Id of the newest event seen; the first poll only looks for it
+	var last string
+	started := false
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		var events []Event
+		var caughtUp bool
+		if started {
+			events, caughtUp, err = newEvents(ctx, client, filter, last)
+		} else {
+			events, err = client.Events(ctx, filter, 1)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			selfLogf("Failed to poll for new events: %v", err)
+		} else {
+			if started {
+				if !caughtUp {
+					selfLogf("More than %d events arrived since the last poll, skipping the older ones", tailPageSize*tailMaxPages)
+				}
+				for i := len(events) - 1; i >= 0; i-- {
+					printEvent(stdout, &events[i])
+				}
+			}
+			if len(events) > 0 {
+				last = events[0].ID
+			}
+			started = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// newEvents returns the events matching filter newer than the one with Id last, newest
+// first, paging back from the newest event. It reports whether it caught up with last
+// or the oldest event within tailMaxPages pages.
+func newEvents(ctx context.Context, client *QueryClient, filter, last string) ([]Event, bool, error) {
+	var events []Event
+	afterID := ""
+	for page := 0; page < tailMaxPages; page++ {
+		polled, err := client.eventsAfter(ctx, filter, tailPageSize, afterID)
+		if err != nil {
+			return nil, false, err
+		}
+		for i := range polled {
+			if polled[i].ID == last {
+				return append(events, polled[:i]...), true, nil
+			}
+		}
+		events = append(events, polled...)
+		if len(polled) < tailPageSize {
+			return events, true, nil
+		}
+		afterID = polled[len(polled)-1].ID
+	}
+	return events, false, nil
+}
+
+// printEvent writes an event as a single line, followed by its exception if it has one
+func printEvent(w io.Writer, e *Event) {
+	message := e.RenderedMessage
+	if message == "" {
+		message = e.MessageTemplate()
+	}
+	fmt.Fprintf(w, "%s [%s] %s\n", e.Timestamp, e.Level, message)
+	if e.Exception != "" {
+		fmt.Fprintln(w, e.Exception)
+	}
+}
+
+// runCommand runs the seqlog subcommand of the process arguments until SIGINT or SIGTERM
+func runCommand(args []string) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return runCLI(ctx, args, os.Stdin, os.Stdout, os.Stderr)
+}
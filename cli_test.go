@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseIngestLine(t *testing.T) {
+	clef, err := parseIngestLine([]byte(`{"@t": "2024-01-02T03:04:05.5Z", "@mt": "Order {OrderId} placed", "@l": "Warning", "OrderId": 7, "@@tag": "a", "@i": "abc"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !clef.timestamp.Equal(time.Date(2024, 1, 2, 3, 4, 5, 5e8, time.UTC)) || clef.level != "Warning" || clef.template != "Order {OrderId} placed" {
+		t.Errorf("Unexpected CLEF event %+v", clef)
+	}
+	if clef.fields["OrderId"] != float64(7) || clef.fields["@tag"] != "a" || len(clef.fields) != 2 {
+		t.Errorf("Unexpected CLEF properties %v", clef.fields)
+	}
+
+	rendered, err := parseIngestLine([]byte(`{"@m": "Saw {braces}"}`))
+	if err != nil || rendered.template != "Saw {{braces}}" || rendered.level != LevelInformation || !rendered.timestamp.IsZero() {
+		t.Errorf("Unexpected rendered CLEF event %+v, %v", rendered, err)
+	}
+
+	raw, err := parseIngestLine([]byte(`{"Timestamp": "2024-01-02T03:04:05Z", "Level": "Error", "MessageTemplate": "Failed", "Properties": {"Count": 3}}`))
+	if err != nil || raw.level != LevelError || raw.template != "Failed" || raw.fields["Count"] != float64(3) {
+		t.Errorf("Unexpected raw event %+v, %v", raw, err)
+	}
+
+	for _, line := range []string{`not json`, `{"OrderId": 7}`, `{"@t": "yesterday", "@mt": "x"}`} {
+		if _, err := parseIngestLine([]byte(line)); err == nil {
+			t.Errorf("Expected %s to be rejected", line)
+		}
+	}
+}
+
+func TestCLIIngest(t *testing.T) {
+	seq := newSeqRecorder(t)
+	stdin := strings.NewReader(`{"@t": "2024-01-02T03:04:05Z", "@mt": "Order {OrderId} placed", "OrderId": 7}
+garbage
+
+{"@mt": "Application started"}
+`)
+	var stderr bytes.Buffer
+
+	code := runCLI(context.Background(), []string{"ingest", "-server", seq.URL + EndpointRaw}, stdin, &bytes.Buffer{}, &stderr)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	received := seq.received()
+	if !strings.Contains(received, "Order {OrderId} placed") || !strings.Contains(received, "2024-01-02T03:04:05Z") || !strings.Contains(received, "Application started") {
+		t.Errorf("Expected both events to be ingested, got %s", received)
+	}
+	if !strings.Contains(stderr.String(), "Skipping line 2") {
+		t.Errorf("Expected the invalid line to be reported, got %q", stderr.String())
+	}
+}
+
+func TestCLISearch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("filter") != "@Level = 'Error'" {
+			t.Errorf("Unexpected filter %q", r.URL.Query().Get("filter"))
+		}
+		w.Write([]byte(`[{"Id": "2", "Timestamp": "T2", "Level": "Error", "RenderedMessage": "second"},
+			{"Id": "1", "Timestamp": "T1", "Level": "Error", "RenderedMessage": "first", "Exception": "boom"}]`))
+	}))
+	defer server.Close()
+
+	var stdout bytes.Buffer
+	args := []string{"search", "-server", server.URL, "-filter", "@Level = 'Error'"}
+	if code := runCLI(context.Background(), args, nil, &stdout, &bytes.Buffer{}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	if want := "T1 [Error] first\nboom\nT2 [Error] second\n"; stdout.String() != want {
+		t.Errorf("Expected output %q, got %q", want, stdout.String())
+	}
+}
+
+func TestCLITailPrintsNewEvents(t *testing.T) {
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if polls.Add(1) == 1 {
+			w.Write([]byte(`[{"Id": "1", "Timestamp": "T1", "Level": "Information", "RenderedMessage": "old"}]`))
+			return
+		}
+		w.Write([]byte(`[{"Id": "2", "Timestamp": "T2", "Level": "Information", "RenderedMessage": "new"},
+			{"Id": "1", "Timestamp": "T1", "Level": "Information", "RenderedMessage": "old"}]`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var stdout bytes.Buffer
+	done := make(chan int)
+	go func() {
+		done <- runCLI(ctx, []string{"tail", "-server", server.URL, "-interval", "10ms"}, nil, &stdout, &bytes.Buffer{})
+	}()
+	for polls.Load() < 3 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	if code := <-done; code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	if want := "T2 [Information] new\n"; stdout.String() != want {
+		t.Errorf("Expected only the new event, got %q", stdout.String())
+	}
+}
+
+func TestCLITailPagesThroughBursts(t *testing.T) {
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Event n has Id n; 250 more arrive after the first poll, served newest first
+		newest := 0
+		if polls.Add(1) > 1 {
+			newest = 250
+		}
+		from := newest
+		if afterID := r.URL.Query().Get("afterId"); afterID != "" {
+			from, _ = strconv.Atoi(afterID)
+			from--
+		}
+		count, _ := strconv.Atoi(r.URL.Query().Get("count"))
+		events := make([]string, 0, count)
+		for n := from; n >= 0 && len(events) < count; n-- {
+			events = append(events, fmt.Sprintf(`{"Id": "%d", "Timestamp": "T%d", "Level": "Information", "RenderedMessage": "event %d"}`, n, n, n))
+		}
+		w.Write([]byte("[" + strings.Join(events, ",") + "]"))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var stdout bytes.Buffer
+	done := make(chan int)
+	go func() {
+		done <- runCLI(ctx, []string{"tail", "-server", server.URL, "-interval", "10ms"}, nil, &stdout, &bytes.Buffer{})
+	}()
+	for polls.Load() < 6 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	if len(lines) != 250 || lines[0] != "T1 [Information] event 1" || lines[249] != "T250 [Information] event 250" {
+		t.Errorf("Expected the 250 new events oldest first, got %d lines: %q ... %q", len(lines), lines[0], lines[len(lines)-1])
+	}
+}
+
+func TestCLIRejectsUnknownCommand(t *testing.T) {
+	var stderr bytes.Buffer
+	if code := runCLI(context.Background(), []string{"frobnicate"}, nil, &bytes.Buffer{}, &stderr); code != 2 {
+		t.Errorf("Expected exit code 2, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "Usage: seqlog") {
+		t.Errorf("Expected the usage to be printed, got %q", stderr.String())
+	}
+}
+
+func TestCLIQueryClientUsesConfiguredBasicAuth(t *testing.T) {
+	path := writeConfigFile(t, "seqlogger.yaml", "serverUrl: http://localhost:5341\nbasicAuth:\n  username: proxy\n  password: secret\n")
+	client, err := (&cliOptions{configPath: path}).queryClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth := client.settings.basicAuth; auth == nil || auth.username != "proxy" || auth.password != "secret" {
+		t.Errorf("Expected the configured basic auth, got %+v", auth)
+	}
+}
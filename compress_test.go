@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressedRecorder is a test SEQ server recording the Content-Encoding and decoded body of each request
+type compressedRecorder struct {
+	*httptest.Server
+	mu        sync.Mutex
+	encodings []string
+	bodies    []string
+}
+
+func newCompressedRecorder(t *testing.T) *compressedRecorder {
+	recorder := &compressedRecorder{}
+	recorder.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		encoding := r.Header.Get("Content-Encoding")
+		if decode, ok := decoders[encoding]; ok {
+			decoded, err := decode(r.Body)
+			if err != nil {
+				t.Errorf("Failed to decode %s body: %v", encoding, err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			body = decoded
+		}
+		data, _ := io.ReadAll(body)
+
+		recorder.mu.Lock()
+		recorder.encodings = append(recorder.encodings, encoding)
+		recorder.bodies = append(recorder.bodies, string(data))
+		recorder.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(recorder.Close)
+	return recorder
+}
+
+// decoders decode the request bodies of each supported Content-Encoding
+var decoders = map[string]func(io.Reader) (io.Reader, error){
+	"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+	"zstd": func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+}
+
+func TestCompressionThreshold(t *testing.T) {
+	seq := newCompressedRecorder(t)
+	logger := newQueueLogger(1, WithEncoder(RawEncoder{}), WithCompression("gzip", 2048))
+	logger.seqURL = seq.URL
+	logger.retry = defaultRetryPolicy
+
+	small := benchmarkBatch(1)
+	large := benchmarkBatch(50)
+	for _, batch := range [][]LogMessage{small, large} {
+		if err := logger.deliver(seq.Client(), batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if seq.encodings[0] != "" || seq.encodings[1] != "gzip" {
+		t.Errorf("Expected only the large batch to be compressed, got encodings %q", seq.encodings)
+	}
+	if n := strings.Count(seq.bodies[1], "Processed order"); n != 50 {
+		t.Errorf("Expected 50 events in the decoded body, got %d", n)
+	}
+}
+
+func TestGzipCompressorRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"@mt":"Processed order {OrderId}"}`), 100)
+	var compressed bytes.Buffer
+	if err := (gzipCompressor{}).compress(&compressed, data); err != nil {
+		t.Fatal(err)
+	}
+	if compressed.Len() >= len(data) {
+		t.Errorf("Expected compression to shrink %d bytes, got %d", len(data), compressed.Len())
+	}
+	reader, err := gzip.NewReader(&compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded, _ := io.ReadAll(reader); !bytes.Equal(decoded, data) {
+		t.Error("Decompressed body does not match the original")
+	}
+}
+
+func TestZstdCompression(t *testing.T) {
+	seq := newCompressedRecorder(t)
+	logger := newQueueLogger(1, WithEncoder(RawEncoder{}), WithCompression("zstd", 0))
+	logger.seqURL = seq.URL
+	logger.retry = defaultRetryPolicy
+
+	if err := logger.deliver(seq.Client(), benchmarkBatch(50)); err != nil {
+		t.Fatal(err)
+	}
+	if seq.encodings[0] != "zstd" {
+		t.Errorf("Expected a zstd body, got encoding %q", seq.encodings[0])
+	}
+	if n := strings.Count(seq.bodies[0], "Processed order"); n != 50 {
+		t.Errorf("Expected 50 events in the decoded body, got %d", n)
+	}
+}
@@ -0,0 +1,488 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config describes a SEQLogger in a YAML or JSON configuration file, so its behavior
+// can change without recompiling. Zero values keep the logger's defaults.
+type Config struct {
+	// ServerURL is the SEQ ingestion endpoint, e.g. http://localhost:5341/api/events/raw,
+	// or the server's base URL, whose endpoint is then negotiated
+	ServerURL string `yaml:"serverUrl" json:"serverUrl"`
+	// Endpoint picks the endpoint appended to a base ServerURL: "auto" (the default),
+	// "clef" or "raw"
+	Endpoint string `yaml:"endpoint" json:"endpoint"`
+	// APIKey is the SEQ API key. It is read from an environment variable when written
+	// as "env:NAME" and from a file, such as a mounted secret, when written as "file:PATH".
+	APIKey string `yaml:"apiKey" json:"apiKey"`
+	// BasicAuth is sent in addition to the API key, for a proxy in front of SEQ. The
+	// password can refer to an environment variable or file like APIKey.
+	BasicAuth struct {
+		Username string `yaml:"username" json:"username"`
+		Password string `yaml:"password" json:"password"`
+	} `yaml:"basicAuth" json:"basicAuth"`
+	// BufferSize is the number of events queued before Log blocks
+	BufferSize int `yaml:"bufferSize" json:"bufferSize"`
+	// MinLevel drops events less severe than this level
+	MinLevel string `yaml:"minLevel" json:"minLevel"`
+	// Overrides maps SourceContext prefixes to their own minimum level, e.g. http.access: Warning
+	Overrides map[string]string `yaml:"overrides" json:"overrides"`
+	// Sampling maps levels to the fraction of their events that is kept, e.g. Debug: 0.1
+	Sampling map[string]float64 `yaml:"sampling" json:"sampling"`
+	// Format selects the payload format: "auto" (the default), detected by probing the
+	// server, "clef" or "raw"
+	Format string `yaml:"format" json:"format"`
+
+	Batch struct {
+		Size     int      `yaml:"size" json:"size"`
+		Interval Duration `yaml:"interval" json:"interval"`
+	} `yaml:"batch" json:"batch"`
+
+	Retry struct {
+		MaxAttempts    int      `yaml:"maxAttempts" json:"maxAttempts"`
+		InitialBackoff Duration `yaml:"initialBackoff" json:"initialBackoff"`
+		MaxBackoff     Duration `yaml:"maxBackoff" json:"maxBackoff"`
+	} `yaml:"retry" json:"retry"`
+
+	// Compression compresses request bodies of at least Threshold bytes with Algorithm
+	Compression struct {
+		Algorithm string `yaml:"algorithm" json:"algorithm"`
+		Threshold int    `yaml:"threshold" json:"threshold"`
+	} `yaml:"compression" json:"compression"`
+
+	// MaxDepth and MaxProperties limit how field values are destructured
+	MaxDepth      int `yaml:"maxDepth" json:"maxDepth"`
+	MaxProperties int `yaml:"maxProperties" json:"maxProperties"`
+	// MaxStringLength caps string properties at this many bytes, see WithMaxStringLength
+	MaxStringLength int `yaml:"maxStringLength" json:"maxStringLength"`
+	// MaxBinaryLength caps binary values encoded into events, see WithMaxBinaryLength
+	MaxBinaryLength int `yaml:"maxBinaryLength" json:"maxBinaryLength"`
+
+	// Properties are global fields added to every event
+	Properties map[string]interface{} `yaml:"properties" json:"properties"`
+	// Enrichers names built-in enrichers: hostname, process, runtime and goroutines
+	Enrichers []string `yaml:"enrichers" json:"enrichers"`
+
+	// Fallback receives the batches SEQ did not accept instead of the local log
+	Fallback *FallbackConfig `yaml:"fallback" json:"fallback"`
+
+	// WAL is the directory of the write-ahead log giving at-least-once delivery, if set
+	WAL string `yaml:"wal" json:"wal"`
+
+	// Sinks routes events to destinations besides SEQ, each with its own minimum level
+	Sinks []SinkConfig `yaml:"sinks" json:"sinks"`
+}
+
+// SinkConfig describes one sink of a configuration file. Type is "seq", which sets the
+// minimum level of events sent to the SEQ server, "file", "webhook" or "stderr".
+type SinkConfig struct {
+	Type     string `yaml:"type" json:"type"`
+	MinLevel string `yaml:"minLevel" json:"minLevel"`
+	// Format is "clef", "raw" or "hoisted", for webhooks to an IngestionHandler, see
+	// ProxyHoistingEncoder; files and stderr default to CLEF, webhooks to raw events
+	Format  string            `yaml:"format" json:"format"`
+	Path    string            `yaml:"path" json:"path"`
+	URL     string            `yaml:"url" json:"url"`
+	Headers map[string]string `yaml:"headers" json:"headers"`
+}
+
+// Duration is a time.Duration written as a string such as "2s" in configuration files
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string such as "500ms"
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"2s\": %w", err)
+	}
+	return d.parse(s)
+}
+
+// UnmarshalYAML parses a duration string such as "500ms"
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	return d.parse(value.Value)
+}
+
+// parse sets d from a time.ParseDuration string
+func (d *Duration) parse(s string) error {
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// NewFromConfigFile creates a SEQLogger from a YAML or JSON configuration file. The
+// files it opens for file sinks and the fallback are closed by Close.
+func NewFromConfigFile(path string) (*SEQLogger, error) {
+	config, err := LoadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, err := config.resolveAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	opts, files, err := config.options()
+	if err != nil {
+		return nil, err
+	}
+
+	bufferSize := config.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+
+	logger, err := NewSEQLogger(config.ServerURL, apiKey, bufferSize, opts...)
+	if err != nil {
+		closeFiles(files)
+		return nil, err
+	}
+	logger.files = files
+	logger.configMinLevel = config.MinLevel
+	if len(config.Sampling) > 0 {
+		logger.configSampling = logger.sampling.Load()
+	}
+	logger.configFields = copyFields(config.Properties)
+	logger.configOverrides = copyOverrides(config.Overrides)
+	return logger, nil
+}
+
+// defaultBufferSize is the queue size used when a configuration file doesn't set one
+const defaultBufferSize = 100
+
+// LoadConfigFile reads a configuration file; ".json" files are parsed as JSON, anything else as YAML
+func LoadConfigFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	config := &Config{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, config)
+	} else {
+		err = yaml.Unmarshal(data, config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if config.ServerURL == "" {
+		return nil, fmt.Errorf("config file %s: serverUrl is required", path)
+	}
+	return config, nil
+}
+
+// validateOverrides checks that every level override names a known level
+func validateOverrides(overrides map[string]string) error {
+	for prefix, level := range overrides {
+		if _, ok := knownLevelRank(level); !ok {
+			return fmt.Errorf("unknown level %q for override %q", level, prefix)
+		}
+	}
+	return nil
+}
+
+// validateSampling checks that sampling names known levels and uses rates between 0 and 1
+func validateSampling(sampling map[string]float64) error {
+	for level, rate := range sampling {
+		if _, ok := knownLevelRank(level); !ok {
+			return fmt.Errorf("unknown sampling level %q", level)
+		}
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("sampling rate for %s must be between 0 and 1, got %v", level, rate)
+		}
+	}
+	return nil
+}
+
+// resolveAPIKey returns the API key, following "env:" and "file:" references
+func (c *Config) resolveAPIKey() (string, error) {
+	return resolveSecret(c.APIKey, "API key")
+}
+
+// resolveSecret returns value, or the environment variable or file contents it refers
+// to when written as "env:NAME" or "file:PATH"; what names the secret in errors
+func resolveSecret(value, what string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "env:"):
+		return os.Getenv(strings.TrimPrefix(value, "env:")), nil
+	case strings.HasPrefix(value, "file:"):
+		data, err := os.ReadFile(strings.TrimPrefix(value, "file:"))
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s file: %w", what, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return value, nil
+}
+
+// Options converts the configuration into the equivalent SEQLogger options
+func (c *Config) Options() ([]Option, error) {
+	opts, _, err := c.options()
+	return opts, err
+}
+
+// options is Options, also returning the files opened by file sinks and the fallback,
+// for the caller to close with the logger, or at once if it can't be created; on error
+// they are already closed
+func (c *Config) options() (opts []Option, files []io.Closer, err error) {
+	defer func() {
+		if err != nil {
+			closeFiles(files)
+			opts, files = nil, nil
+		}
+	}()
+
+	if c.MinLevel != "" {
+		if _, ok := knownLevelRank(c.MinLevel); !ok {
+			return nil, nil, fmt.Errorf("unknown minLevel %q", c.MinLevel)
+		}
+		opts = append(opts, WithMinLevel(c.MinLevel))
+	}
+
+	if err := validateOverrides(c.Overrides); err != nil {
+		return nil, nil, err
+	}
+	for prefix, level := range c.Overrides {
+		opts = append(opts, WithLevelOverride(prefix, level))
+	}
+
+	if len(c.Sampling) > 0 {
+		if err := validateSampling(c.Sampling); err != nil {
+			return nil, nil, err
+		}
+		opts = append(opts, WithSampling(c.Sampling))
+	}
+
+	switch strings.ToLower(c.Format) {
+	case "", "auto":
+	case "clef":
+		opts = append(opts, WithFormat(FormatCLEF))
+	case "raw":
+		opts = append(opts, WithFormat(FormatRaw))
+	default:
+		return nil, nil, fmt.Errorf("unknown format %q", c.Format)
+	}
+
+	switch strings.ToLower(c.Endpoint) {
+	case "", "auto":
+	case "clef":
+		opts = append(opts, WithEndpoint(EndpointCLEF))
+	case "raw":
+		opts = append(opts, WithEndpoint(EndpointRaw))
+	default:
+		return nil, nil, fmt.Errorf("unknown endpoint %q", c.Endpoint)
+	}
+
+	if c.Batch.Size > 0 || c.Batch.Interval > 0 {
+		size := c.Batch.Size
+		if size <= 0 {
+			size = defaultBatchSize
+		}
+		opts = append(opts, WithBatching(size, time.Duration(c.Batch.Interval)))
+	}
+
+	if c.Retry.MaxAttempts > 0 {
+		initialBackoff, maxBackoff := defaultRetryPolicy.initialBackoff, defaultRetryPolicy.maxBackoff
+		if c.Retry.InitialBackoff > 0 {
+			initialBackoff = time.Duration(c.Retry.InitialBackoff)
+		}
+		if c.Retry.MaxBackoff > 0 {
+			maxBackoff = time.Duration(c.Retry.MaxBackoff)
+		}
+		opts = append(opts, WithRetry(c.Retry.MaxAttempts, initialBackoff, maxBackoff))
+	}
+
+	if c.BasicAuth.Username != "" {
+		password, err := resolveSecret(c.BasicAuth.Password, "basic auth password")
+		if err != nil {
+			return nil, nil, err
+		}
+		opts = append(opts, WithBasicAuth(c.BasicAuth.Username, password))
+	}
+
+	if c.Compression.Algorithm != "" {
+		if _, err := newCompression(c.Compression.Algorithm, c.Compression.Threshold); err != nil {
+			return nil, nil, err
+		}
+		opts = append(opts, WithCompression(c.Compression.Algorithm, c.Compression.Threshold))
+	}
+
+	if c.MaxDepth > 0 {
+		opts = append(opts, WithMaxDepth(c.MaxDepth))
+	}
+	if c.MaxProperties > 0 {
+		opts = append(opts, WithMaxProperties(c.MaxProperties))
+	}
+	if c.MaxStringLength > 0 {
+		opts = append(opts, WithMaxStringLength(c.MaxStringLength))
+	}
+	if c.MaxBinaryLength > 0 {
+		opts = append(opts, WithMaxBinaryLength(c.MaxBinaryLength))
+	}
+
+	if len(c.Properties) > 0 {
+		opts = append(opts, WithGlobalFields(c.Properties))
+	}
+
+	enrichers, err := configEnrichers(c.Enrichers)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, enricher := range enrichers {
+		opts = append(opts, WithEnricher(enricher))
+	}
+
+	for _, sinkConfig := range c.Sinks {
+		opt, file, err := sinkConfig.option()
+		if file != nil {
+			files = append(files, file)
+		}
+		if err != nil {
+			return opts, files, err
+		}
+		opts = append(opts, opt)
+	}
+
+	if c.Fallback != nil {
+		opt, file, err := c.Fallback.option()
+		if file != nil {
+			files = append(files, file)
+		}
+		if err != nil {
+			return opts, files, err
+		}
+		opts = append(opts, opt)
+	}
+
+	if c.WAL != "" {
+		opts = append(opts, WithWAL(c.WAL))
+	}
+
+	return opts, files, nil
+}
+
+// closeFiles closes the files opened for a logger's sinks
+func closeFiles(files []io.Closer) {
+	for _, file := range files {
+		file.Close()
+	}
+}
+
+// configEnrichers creates the built-in enrichers named by names
+func configEnrichers(names []string) ([]Enricher, error) {
+	enrichers := make([]Enricher, 0, len(names))
+	for _, name := range names {
+		newEnricher, ok := namedEnrichers[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown enricher %q", name)
+		}
+		enrichers = append(enrichers, configEnricher{newEnricher()})
+	}
+	return enrichers, nil
+}
+
+// FallbackConfig describes where undelivered batches go: "stderr", or a "file" rotated
+// once it reaches MaxSize bytes with at most MaxFiles older files kept
+type FallbackConfig struct {
+	Type string `yaml:"type" json:"type"`
+	// Format is "clef" (the default) or "raw"
+	Format   string `yaml:"format" json:"format"`
+	Path     string `yaml:"path" json:"path"`
+	MaxSize  int64  `yaml:"maxSize" json:"maxSize"`
+	MaxFiles int    `yaml:"maxFiles" json:"maxFiles"`
+}
+
+// option creates the fallback sink described by the configuration, and returns the
+// file it opens, if any
+func (c FallbackConfig) option() (Option, io.Closer, error) {
+	encoder, err := sinkEncoder(c.Format, "fallback")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch strings.ToLower(c.Type) {
+	case "stderr":
+		return WithFallback(NewWriterSink(os.Stderr, encoder)), nil, nil
+	case "file":
+		if c.Path == "" {
+			return nil, nil, fmt.Errorf("file fallback requires a path")
+		}
+		sink, err := NewRotatingFileSink(c.Path, c.MaxSize, c.MaxFiles, encoder)
+		if err != nil {
+			return nil, nil, err
+		}
+		return WithFallback(sink), sink, nil
+	}
+	return nil, nil, fmt.Errorf("unknown fallback type %q", c.Type)
+}
+
+// sinkEncoder returns the encoder named by format, nil for the destination's default
+func sinkEncoder(format, destination string) (Encoder, error) {
+	switch strings.ToLower(format) {
+	case "":
+		return nil, nil
+	case "clef":
+		return CLEFEncoder{}, nil
+	case "raw":
+		return RawEncoder{}, nil
+	case "hoisted":
+		return ProxyHoistingEncoder{}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q for %s", format, destination)
+}
+
+// option creates the sink described by the configuration and routes events to it, and
+// returns the file it opens, if any
+func (c SinkConfig) option() (Option, io.Closer, error) {
+	if c.MinLevel != "" {
+		if _, ok := knownLevelRank(c.MinLevel); !ok {
+			return nil, nil, fmt.Errorf("unknown minLevel %q for %s sink", c.MinLevel, c.Type)
+		}
+	}
+
+	encoder, err := sinkEncoder(c.Format, c.Type+" sink")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch strings.ToLower(c.Type) {
+	case "seq":
+		return WithSeqMinLevel(c.MinLevel), nil, nil
+	case "file":
+		if c.Path == "" {
+			return nil, nil, fmt.Errorf("file sink requires a path")
+		}
+		sink, err := NewFileSink(c.Path, encoder)
+		if err != nil {
+			return nil, nil, err
+		}
+		return WithSink(sink, c.MinLevel), sink, nil
+	case "webhook":
+		if c.URL == "" {
+			return nil, nil, fmt.Errorf("webhook sink requires a url")
+		}
+		headers := make(http.Header, len(c.Headers))
+		for key, value := range c.Headers {
+			headers.Set(key, value)
+		}
+		return WithSink(NewWebhookSink(c.URL, encoder, headers), c.MinLevel), nil, nil
+	case "stderr":
+		return WithSink(NewWriterSink(os.Stderr, encoder), c.MinLevel), nil, nil
+	}
+	return nil, nil, fmt.Errorf("unknown sink type %q", c.Type)
+}
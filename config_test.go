@@ -0,0 +1,200 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfigFile writes content to a file named name in a temporary directory
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewFromConfigFileYAML(t *testing.T) {
+	t.Setenv("TEST_SEQ_API_KEY", "secret-key")
+	t.Setenv("TEST_PROXY_PASSWORD", "proxy-secret")
+	path := writeConfigFile(t, "seqlogger.yaml", `
+serverUrl: http://seq.example:5341/api/events/raw
+apiKey: env:TEST_SEQ_API_KEY
+basicAuth:
+  username: shipper
+  password: env:TEST_PROXY_PASSWORD
+bufferSize: 500
+minLevel: Warning
+format: clef
+batch:
+  size: 50
+  interval: 2s
+retry:
+  maxAttempts: 5
+  initialBackoff: 100ms
+properties:
+  Application: billing
+enrichers: [hostname, process]
+`)
+
+	logger, err := NewFromConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if logger.seqURL != "http://seq.example:5341/api/events/raw" || logger.apiKey != "secret-key" {
+		t.Errorf("Unexpected server settings %q %q", logger.seqURL, logger.apiKey)
+	}
+	if logger.basicAuth == nil || *logger.basicAuth != (basicAuth{username: "shipper", password: "proxy-secret"}) {
+		t.Errorf("Unexpected basic auth %+v", logger.basicAuth)
+	}
+	if cap(logger.logChan) != 500 {
+		t.Errorf("Expected buffer size 500, got %d", cap(logger.logChan))
+	}
+	if logger.minLevel.Load() != int32(levelRank(LevelWarning)) {
+		t.Errorf("Expected min level Warning, got rank %d", logger.minLevel.Load())
+	}
+	if _, ok := logger.encoder.(CLEFEncoder); !ok {
+		t.Errorf("Expected CLEF encoder, got %T", logger.encoder)
+	}
+	if logger.batchSize != 50 || logger.batchInterval != 2*time.Second {
+		t.Errorf("Unexpected batching %d %v", logger.batchSize, logger.batchInterval)
+	}
+	if logger.retry.maxAttempts != 5 || logger.retry.initialBackoff != 100*time.Millisecond || logger.retry.maxBackoff != defaultRetryPolicy.maxBackoff {
+		t.Errorf("Unexpected retry policy %+v", logger.retry)
+	}
+	if logger.globalFields.load()["Application"] != "billing" {
+		t.Errorf("Expected Application global field, got %#v", logger.globalFields.load())
+	}
+	if len(logger.enrichers) != 2 {
+		t.Errorf("Expected 2 enrichers, got %d", len(logger.enrichers))
+	}
+}
+
+func TestNewFromConfigFileJSON(t *testing.T) {
+	keyFile := writeConfigFile(t, "api-key", "file-key\n")
+	path := writeConfigFile(t, "seqlogger.json", `{
+		"serverUrl": "http://localhost:5341/api/events/raw",
+		"apiKey": "file:`+keyFile+`",
+		"batch": {"interval": "250ms"}
+	}`)
+
+	logger, err := NewFromConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logger.apiKey != "file-key" {
+		t.Errorf("Expected API key from file, got %q", logger.apiKey)
+	}
+	if logger.batchSize != defaultBatchSize || logger.batchInterval != 250*time.Millisecond {
+		t.Errorf("Unexpected batching %d %v", logger.batchSize, logger.batchInterval)
+	}
+}
+
+func TestLoadConfigFileRejectsInvalidSettings(t *testing.T) {
+	tests := map[string]string{
+		"missing server":   `minLevel: Debug`,
+		"unknown level":    "serverUrl: http://localhost\nminLevel: Loud",
+		"bad override":     "serverUrl: http://localhost\noverrides:\n  payments: Loud",
+		"bad fallback":     "serverUrl: http://localhost\nfallback:\n  type: file",
+		"bad compression":  "serverUrl: http://localhost\ncompression:\n  algorithm: lz4",
+		"unknown format":   "serverUrl: http://localhost\nformat: xml",
+		"unknown endpoint": "serverUrl: http://localhost\nendpoint: grpc",
+		"bad enricher":     "serverUrl: http://localhost\nenrichers: [weather]",
+		"bad duration":     "serverUrl: http://localhost\nbatch:\n  interval: soon",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewFromConfigFile(writeConfigFile(t, "seqlogger.yaml", content)); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
+
+// openFiles counts the descriptors the process holds on path, skipping where /proc is missing
+func openFiles(t *testing.T, path string) int {
+	t.Helper()
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("No /proc/self/fd to list open files")
+	}
+	n := 0
+	for _, fd := range fds {
+		if target, err := os.Readlink(filepath.Join("/proc/self/fd", fd.Name())); err == nil && target == path {
+			n++
+		}
+	}
+	return n
+}
+
+func TestNewFromConfigFileClosesSinkFilesOnError(t *testing.T) {
+	dir := t.TempDir()
+	sinkPath := filepath.Join(dir, "errors.clef")
+	fallbackPath := filepath.Join(dir, "fallback.clef")
+	for name, content := range map[string]string{
+		"config error": "serverUrl: ftp://localhost\nsinks:\n  - type: file\n    path: " + sinkPath +
+			"\nfallback:\n  type: file\n  path: " + fallbackPath,
+		"bad fallback": "serverUrl: http://localhost\nsinks:\n  - type: file\n    path: " + sinkPath +
+			"\nfallback:\n  type: file\n  path: " + fallbackPath + "\n  format: xml",
+	} {
+		if _, err := NewFromConfigFile(writeConfigFile(t, "seqlogger.yaml", content)); err == nil {
+			t.Fatalf("Expected the %s to fail", name)
+		}
+		if n := openFiles(t, sinkPath) + openFiles(t, fallbackPath); n != 0 {
+			t.Errorf("Expected the sink files closed after the %s, %d still open", name, n)
+		}
+	}
+}
+
+func TestCloseClosesConfigSinkFiles(t *testing.T) {
+	server := newSeqRecorder(t)
+	sinkPath := filepath.Join(t.TempDir(), "errors.clef")
+	logger, err := NewFromConfigFile(writeConfigFile(t, "seqlogger.yaml",
+		"serverUrl: "+server.URL+"\nsinks:\n  - type: file\n    path: "+sinkPath))
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	if n := openFiles(t, sinkPath); n != 1 {
+		t.Fatalf("Expected the sink file open, got %d descriptors", n)
+	}
+
+	logger.Log(LevelError, "Payment failed", nil)
+	logger.Close()
+	if n := openFiles(t, sinkPath); n != 0 {
+		t.Errorf("Expected the sink file closed by Close, %d still open", n)
+	}
+	if data, err := os.ReadFile(sinkPath); err != nil || !strings.Contains(string(data), "Payment failed") {
+		t.Errorf("Expected the last event written before the file was closed, got %q, %v", data, err)
+	}
+	logger.Close()
+}
+
+func TestCloseClosesConfigFallbackFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	fallbackPath := filepath.Join(t.TempDir(), "fallback.clef")
+	logger, err := NewFromConfigFile(writeConfigFile(t, "seqlogger.yaml",
+		"serverUrl: "+server.URL+"\nfallback:\n  type: file\n  path: "+fallbackPath+"\n  maxSize: 1048576\n  maxFiles: 2"))
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	logger.Log(LevelError, "Payment failed", nil)
+	logger.Close()
+	if n := openFiles(t, fallbackPath); n != 0 {
+		t.Errorf("Expected the fallback file closed by Close, %d still open", n)
+	}
+	if data, err := os.ReadFile(fallbackPath); err != nil || !strings.Contains(string(data), "Payment failed") {
+		t.Errorf("Expected the rejected event in the fallback file, got %q, %v", data, err)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ConfigError is returned by NewSEQLogger for a setting it can't work with. Several
+// problems are joined into one error, each still found with errors.As.
+type ConfigError struct {
+	// Setting names what is wrong: "server URL", "buffer size", or an option such as "WithSpill"
+	Setting string
+	Reason  string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("Invalid logger configuration: %s %s", e.Setting, e.Reason)
+}
+
+// checkConfig reports what NewSEQLogger can't start with, once the options are applied,
+// so a misconfigured logger fails when it is created instead of on the first send
+func (l *SEQLogger) checkConfig(bufferSize int) error {
+	var errs []error
+	invalid := func(setting, format string, args ...interface{}) {
+		errs = append(errs, &ConfigError{Setting: setting, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	if u, err := url.Parse(l.seqURL); err != nil {
+		invalid("server URL", "%q can't be parsed: %v", l.seqURL, err)
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		invalid("server URL", "%q must start with http:// or https://", l.seqURL)
+	} else if u.Host == "" {
+		invalid("server URL", "%q has no host", l.seqURL)
+	}
+	if l.format != FormatAuto && l.format != FormatCLEF && l.format != FormatRaw {
+		invalid("WithFormat", "format %q is unknown", l.format)
+	}
+	if bufferSize < 0 {
+		invalid("buffer size", "must not be negative, got %d", bufferSize)
+	}
+	if l.batchSize < 1 {
+		invalid("WithBatching", "size must be at least 1, got %d", l.batchSize)
+	}
+	if l.retry.maxAttempts < 1 {
+		invalid("WithRetry", "attempts must be at least 1, got %d", l.retry.maxAttempts)
+	}
+	if l.spillPath != "" && l.spillMaxBytes <= 0 {
+		invalid("WithSpill", "size must be positive, got %d", l.spillMaxBytes)
+	}
+	if l.memoryBudget != nil && l.memoryBudget.maxBytes <= 0 {
+		invalid("WithMemoryBudget", "must be positive, got %d", l.memoryBudget.maxBytes)
+	}
+	if l.pressure != nil && (l.pressure.fraction <= 0 || l.pressure.fraction > 1) {
+		invalid("WithMemoryPressure", "fraction must be in (0, 1], got %g", l.pressure.fraction)
+	}
+	for _, w := range l.watermarks {
+		if w.fraction <= 0 || w.fraction > 1 {
+			invalid("WithWatermark", "fraction must be in (0, 1], got %g", w.fraction)
+		}
+	}
+	if l.tokens != nil && l.basicAuth != nil {
+		invalid("WithTokenSource", "can't be combined with WithBasicAuth, as both set the Authorization header")
+	}
+	if l.pump != nil {
+		if bufferSize < 1 {
+			invalid("WithPumpMode", "needs a buffer size of at least 1, got %d", bufferSize)
+		}
+		for _, conflict := range []struct {
+			option string
+			used   bool
+		}{
+			{"WithFairQueueing", l.fair != nil},
+			{"WithSpill", l.spillPath != ""},
+			{"WithMemoryPressure", l.pressure != nil},
+			{"WithPrewarm", l.prewarm},
+			{"WithErrorAggregation", l.errorAggregator != nil},
+		} {
+			if conflict.used {
+				invalid("WithPumpMode", "can't be combined with %s, as it needs a goroutine of its own", conflict.option)
+			}
+		}
+	}
+	errs = append(errs, l.optionErrs...)
+	return errors.Join(errs...)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewSEQLoggerRejectsBadConfiguration(t *testing.T) {
+	cases := map[string]struct {
+		seqURL     string
+		bufferSize int
+		opts       []Option
+		setting    string
+	}{
+		"no scheme":       {"localhost:5341", 10, nil, "server URL"},
+		"ftp scheme":      {"ftp://seq.example", 10, nil, "server URL"},
+		"no host":         {"http://", 10, nil, "server URL"},
+		"negative buffer": {"http://seq.example", -1, nil, "buffer size"},
+		"empty batches":   {"http://seq.example", 10, []Option{WithBatching(0, time.Second)}, "WithBatching"},
+		"no attempts":     {"http://seq.example", 10, []Option{WithRetry(0, time.Second, time.Second)}, "WithRetry"},
+		"watermark":       {"http://seq.example", 10, []Option{WithWatermark(1.5, func(int, int) {})}, "WithWatermark"},
+		"compression":     {"http://seq.example", 10, []Option{WithCompression("lz4", 0)}, "WithCompression"},
+		"two authorizations": {"http://seq.example", 10, []Option{
+			WithBasicAuth("seq", "secret"),
+			WithTokenSource(func(context.Context) (string, time.Time, error) { return "token", time.Time{}, nil }),
+		}, "WithTokenSource"},
+	}
+	for name, c := range cases {
+		logger, err := NewSEQLogger(c.seqURL, "", c.bufferSize, c.opts...)
+		var invalid *ConfigError
+		if logger != nil || !errors.As(err, &invalid) || invalid.Setting != c.setting {
+			t.Errorf("%s: expected a *ConfigError for %s, got %v", name, c.setting, err)
+		}
+	}
+}
+
+func TestNewSEQLoggerReportsEveryProblem(t *testing.T) {
+	_, err := NewSEQLogger("seq.example", "", -1, WithBatching(0, 0))
+	for _, want := range []string{"server URL", "buffer size", "WithBatching"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %s in %v", want, err)
+		}
+	}
+}
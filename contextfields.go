@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// contextExtractor pulls one property out of a context.Context, see WithContextField
+type contextExtractor struct {
+	name    string
+	extract func(ctx context.Context) interface{}
+}
+
+// WithContextField adds a property name to the events logged with LogCtx, taking its
+// value from the event's context with extract, e.g. the tenant or user a request
+// middleware stored there. A nil value leaves the property out. Call-site fields take
+// precedence over extracted ones, which take precedence over a child logger's fields.
+func WithContextField(name string, extract func(ctx context.Context) interface{}) Option {
+	return func(l *SEQLogger) {
+		l.contextExtractors = append(l.contextExtractors, contextExtractor{name: name, extract: extract})
+	}
+}
+
+// LogCtx logs like Log, adding the properties WithContextField extracts from ctx
+func (l *SEQLogger) LogCtx(ctx context.Context, level, message string, fields map[string]interface{}) {
+	root := l.pipeline()
+	root.log(root.extractContextFields(ctx, l.contextFields), time.Time{}, level, message, fields)
+}
+
+// extractContextFields returns contextFields with the properties extracted from ctx,
+// copying contextFields only if there are any
+func (l *SEQLogger) extractContextFields(ctx context.Context, contextFields map[string]interface{}) map[string]interface{} {
+	var extracted map[string]interface{}
+	for _, e := range l.contextExtractors {
+		value := e.extract(ctx)
+		if value == nil {
+			continue
+		}
+		if extracted == nil {
+			extracted = make(map[string]interface{}, len(l.contextExtractors))
+		}
+		extracted[e.name] = value
+	}
+	return mergeFields(contextFields, extracted)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+type tenantKey struct{}
+
+func TestLogCtxExtractsContextFields(t *testing.T) {
+	tenant := func(ctx context.Context) interface{} { return ctx.Value(tenantKey{}) }
+	logger := newQueueLogger(10, WithContextField("Tenant", tenant))
+	child := logger.ForContext(map[string]interface{}{"Tenant": "default", "Component": "billing"})
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	child.LogCtx(ctx, LevelInformation, "Invoice {InvoiceId} sent", map[string]interface{}{"InvoiceId": 12})
+	child.LogCtx(context.Background(), LevelInformation, "Invoice {InvoiceId} sent", map[string]interface{}{"InvoiceId": 13})
+	child.LogCtx(ctx, LevelInformation, "Invoice {InvoiceId} sent", map[string]interface{}{"InvoiceId": 14, "Tenant": "override"})
+
+	if sent := <-logger.logChan; sent.Fields["Tenant"] != "acme" || sent.Fields["Component"] != "billing" || sent.Fields["InvoiceId"] != 12 {
+		t.Errorf("Expected the tenant from the context, got %v", sent.Fields)
+	}
+	if sent := <-logger.logChan; sent.Fields["Tenant"] != "default" {
+		t.Errorf("Expected a nil extracted value to be left out, got %v", sent.Fields)
+	}
+	if sent := <-logger.logChan; sent.Fields["Tenant"] != "override" {
+		t.Errorf("Expected call-site fields to win, got %v", sent.Fields)
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+// CorrelationIDProperty carries the id tying together the events of one request or
+// job, across the services it passes through
+const CorrelationIDProperty = "CorrelationId"
+
+// CorrelationID returns the CorrelationId of the logger's context, or "" if it has
+// none, e.g. to pass on in the headers of outgoing requests
+func (l *SEQLogger) CorrelationID() string {
+	id, _ := l.contextFields[CorrelationIDProperty].(string)
+	return id
+}
+
+// keepCorrelationID restores the CorrelationId of parent in the fields of a child
+// logger, so a child can add one but never replace the one it inherited
+func keepCorrelationID(parent, child map[string]interface{}) {
+	inherited, ok := parent[CorrelationIDProperty]
+	if !ok {
+		return
+	}
+	if id, ok := child[CorrelationIDProperty]; ok && id != inherited {
+		selfLogf("Kept inherited CorrelationId %v instead of %v", inherited, id)
+	}
+	child[CorrelationIDProperty] = inherited
+}
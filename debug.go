@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxRecentErrors bounds how many delivery errors the debug handler shows
+const maxRecentErrors = 20
+
+// RecentError is a delivery error shown by the debug handler
+type RecentError struct {
+	Time  time.Time
+	Error string
+}
+
+// recentErrors keeps the last delivery errors, oldest first
+type recentErrors struct {
+	mu     sync.Mutex
+	errors []RecentError
+}
+
+// add records err, forgetting the oldest error once maxRecentErrors are kept
+func (r *recentErrors) add(err error, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.errors) >= maxRecentErrors {
+		r.errors = append(r.errors[:0], r.errors[1:]...)
+	}
+	r.errors = append(r.errors, RecentError{Time: now, Error: err.Error()})
+}
+
+// list returns a copy of the errors kept
+func (r *recentErrors) list() []RecentError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RecentError(nil), r.errors...)
+}
+
+// debugState is the pipeline state rendered by the debug handler
+type debugState struct {
+	Stats
+	QueueCapacity  int
+	MinimumLevel   string
+	LevelOverrides map[string]string `json:",omitempty"`
+	RecentErrors   []RecentError
+	Closed         bool
+}
+
+// Handler returns an http.Handler for live troubleshooting, to be mounted under e.g.
+// /debug/seqlogger on an admin port, never on a public one. GET renders the queue
+// depth and capacity, the delivery Stats, the last delivery errors and the minimum
+// levels as JSON. POST changes a minimum level at run time and renders the new state:
+// level=Debug sets the logger's minimum level, and source=http&level=Debug the
+// override for the SourceContext prefix http, as WithLevelOverride would.
+func (l *SEQLogger) Handler() http.Handler {
+	root := l.pipeline()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPost:
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			level := r.Form.Get("level")
+			if _, ok := knownLevelRank(level); !ok {
+				http.Error(w, fmt.Sprintf("Unknown level %q", level), http.StatusBadRequest)
+				return
+			}
+			if source := r.Form.Get("source"); source != "" {
+				root.setLevelOverride(source, level)
+				selfLogf("Minimum level of %s set to %s through the debug handler", source, level)
+			} else {
+				root.minLevel.Store(int32(minLevelRank(level)))
+				selfLogf("Minimum level set to %s through the debug handler", level)
+			}
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(root.debugState())
+	})
+}
+
+// debugState returns the state rendered by the debug handler
+func (l *SEQLogger) debugState() debugState {
+	state := debugState{
+		Stats:         l.Stats(),
+		QueueCapacity: l.QueueCapacity(),
+		MinimumLevel:  levelNames[l.minLevel.Load()],
+		RecentErrors:  l.recentErrors.list(),
+		Closed:        l.closed.Load(),
+	}
+	if overrides := l.levelOverrides.Load(); overrides != nil && len(*overrides) > 0 {
+		state.LevelOverrides = make(map[string]string, len(*overrides))
+		for _, override := range *overrides {
+			state.LevelOverrides[override.prefix] = levelNames[override.minLevel]
+		}
+	}
+	return state
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandlerRendersPipelineState(t *testing.T) {
+	logger := newQueueLogger(5, WithMinLevel(LevelInformation), WithLevelOverride("http", LevelWarning))
+	logger.Log(LevelInformation, "Queued", nil)
+	logger.recordFlushError(errors.New("SEQ server responded with 503"))
+
+	recorder := httptest.NewRecorder()
+	logger.Named("Billing").Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/seqlogger", nil))
+
+	var state debugState
+	if err := json.Unmarshal(recorder.Body.Bytes(), &state); err != nil {
+		t.Fatalf("Failed to decode %s: %v", recorder.Body.String(), err)
+	}
+	if state.QueueDepth != 1 || state.QueueCapacity != 5 || state.MinimumLevel != LevelInformation || state.LevelOverrides["http"] != LevelWarning {
+		t.Errorf("Unexpected state %+v", state)
+	}
+	if len(state.RecentErrors) != 1 || !strings.Contains(state.RecentErrors[0].Error, "503") {
+		t.Errorf("Expected the delivery error, got %+v", state.RecentErrors)
+	}
+}
+
+func TestHandlerChangesLevels(t *testing.T) {
+	logger := newQueueLogger(5, WithMinLevel(LevelInformation))
+	handler := logger.Handler()
+
+	post := func(query string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/debug/seqlogger?"+query, nil))
+		return recorder
+	}
+	if recorder := post("level=Debug"); recorder.Code != http.StatusOK || !logger.IsEnabled(LevelDebug) {
+		t.Errorf("Expected Debug enabled, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := post("source=http&level=Error"); recorder.Code != http.StatusOK || logger.Named("http").IsEnabled(LevelWarning) {
+		t.Errorf("Expected Warning disabled for http, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := post("level=Loud"); recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown level rejected, got %d", recorder.Code)
+	}
+}
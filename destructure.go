@@ -0,0 +1,157 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// reflectValue destructures structs, pointers, maps and collections that the type switch
+// in value doesn't know about, so their nested values are normalized as well
+func (w *walker) reflectValue(rv reflect.Value, depth int) (interface{}, bool) {
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil, true
+		}
+		if rv.Kind() == reflect.Pointer {
+			if !w.enter(rv) {
+				return cycleMarker, true
+			}
+			defer w.leave()
+		}
+		converted, _ := w.value(rv.Elem().Interface(), depth)
+		return converted, true
+	case reflect.Struct:
+		if depth >= w.maxDepth {
+			return depthLimitMarker, true
+		}
+		properties := make(map[string]interface{}, rv.NumField())
+		w.destructure(properties, rv, depth+1)
+		return properties, true
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return rv.Interface(), false
+		}
+		if depth >= w.maxDepth {
+			return depthLimitMarker, true
+		}
+		if !w.enter(rv) {
+			return cycleMarker, true
+		}
+		defer w.leave()
+		return w.reflectMap(rv, depth+1), true
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			return w.binary(rv.Bytes()), true
+		}
+		if depth >= w.maxDepth {
+			return depthLimitMarker, true
+		}
+		if rv.Kind() == reflect.Slice && rv.Len() > 0 {
+			if !w.enter(rv) {
+				return cycleMarker, true
+			}
+			defer w.leave()
+		}
+		length := rv.Len()
+		if length > w.maxProperties {
+			length = w.maxProperties
+		}
+		elements := make([]interface{}, length)
+		for i := range elements {
+			elements[i], _ = w.value(rv.Index(i).Interface(), depth+1)
+		}
+		return elements, true
+	}
+	return rv.Interface(), false
+}
+
+// reflectMap copies a map with string keys, keeping at most maxProperties entries in key order
+func (w *walker) reflectMap(rv reflect.Value, depth int) map[string]interface{} {
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	if len(keys) > w.maxProperties {
+		keys = keys[:w.maxProperties]
+	}
+
+	properties := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		properties[key.String()], _ = w.value(rv.MapIndex(key).Interface(), depth)
+	}
+	return properties
+}
+
+// destructure adds the exported fields of the struct rv to properties, honoring json tags
+// and promoting the fields of exported embedded structs the way encoding/json does
+func (w *walker) destructure(properties map[string]interface{}, rv reflect.Value, depth int) {
+	t := rv.Type()
+	for i := 0; i < t.NumField() && len(properties) < w.maxProperties; i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitEmpty, skip := jsonFieldName(field)
+		if skip {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if field.Anonymous && name == "" {
+			embedded := fv
+			if embedded.Kind() == reflect.Pointer {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				w.destructure(properties, embedded, depth)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		properties[name], _ = w.value(fv.Interface(), depth)
+	}
+}
+
+// jsonFieldName parses the json tag of a struct field
+func jsonFieldName(field reflect.StructField) (name string, omitEmpty, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	for _, option := range strings.Split(options, ",") {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, false
+}
+
+// isEmptyValue reports whether v is empty as defined by encoding/json's omitempty
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package main
+
+import "io"
+
+// Encoder serializes a batch of log messages into the body of an ingestion request
+type Encoder interface {
+	// Encode writes the encoded batch to w
+	Encode(w io.Writer, batch []LogMessage) error
+	// ContentType returns the Content-Type header sent with the encoded body
+	ContentType() string
+}
+
+// RawEncoder writes batches in SEQ's raw events format and is the default Encoder.
+// Marshal serializes events that carry fields; when nil, encoding/json is used.
+// It can be set to a compatible function such as jsoniter's Marshal.
+type RawEncoder struct {
+	Marshal func(v interface{}) ([]byte, error)
+}
+
+// ContentType returns the Content-Type of SEQ's raw events format
+func (e RawEncoder) ContentType() string {
+	return "application/json"
+}
+
+// Encode writes batch to w wrapped inside an "Events" array.
+// Events without fields take the fast path and never build an intermediate map.
+// The event type id is written as the EventTypeIDProperty property unless a field
+// of that name is already set.
+func (e RawEncoder) Encode(w io.Writer, batch []LogMessage) error {
+	buf, pooled := w.(*encodeBuffer)
+	if !pooled {
+		buf = getEncodeBuffer()
+		defer putEncodeBuffer(buf)
+	}
+
+	buf.WriteString(`{"Events":[`)
+	for i := range batch {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		logMessage := &batch[i]
+		if len(logMessage.Fields) == 0 {
+			writeFieldlessEvent(&buf.Buffer, logMessage)
+			continue
+		}
+
+		buf.event["Timestamp"] = logMessage.Timestamp
+		buf.event["Level"] = logMessage.Level
+		buf.event["MessageTemplate"] = logMessage.MessageTemplate
+		buf.event["Properties"] = rawProperties(buf, logMessage)
+		if len(logMessage.Renderings) > 0 {
+			buf.event["Renderings"] = rawRenderings(logMessage.Renderings)
+		} else {
+			delete(buf.event, "Renderings")
+		}
+		if err := e.encodeEvent(buf); err != nil {
+			return err
+		}
+	}
+	buf.WriteString("]}\n")
+
+	if !pooled {
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+	return nil
+}
+
+// encodeEvent appends the event map held by buf using the configured Marshal function
+func (e RawEncoder) encodeEvent(buf *encodeBuffer) error {
+	if e.Marshal == nil {
+		return buf.enc.Encode(buf.event)
+	}
+
+	data, err := e.Marshal(buf.event)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
+
+// rawProperties returns the fields of logMessage with its event type id added, copied
+// into the properties map of buf so the event's own map is left alone
+func rawProperties(buf *encodeBuffer, logMessage *LogMessage) map[string]interface{} {
+	if _, set := logMessage.Fields[EventTypeIDProperty]; set || logMessage.EventID == 0 {
+		return logMessage.Fields
+	}
+	clear(buf.properties)
+	for key, value := range logMessage.Fields {
+		buf.properties[key] = value
+	}
+	var id [8]byte
+	buf.properties[EventTypeIDProperty] = string(appendEventTypeID(id[:0], logMessage.EventID))
+	return buf.properties
+}
+
+// rawRendering is a single formatted value in SEQ's raw events format
+type rawRendering struct {
+	Format    string `json:"Format"`
+	Rendering string `json:"Rendering"`
+}
+
+// rawRenderings groups renderings by property name as SEQ's raw events format expects
+func rawRenderings(renderings []Rendering) map[string][]rawRendering {
+	grouped := make(map[string][]rawRendering, len(renderings))
+	for _, r := range renderings {
+		grouped[r.Property] = append(grouped[r.Property], rawRendering{Format: r.Format, Rendering: r.Rendering})
+	}
+	return grouped
+}
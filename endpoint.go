@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Ingestion endpoints accepted by SEQ servers. /ingest/clef takes CLEF and exists since
+// SEQ 5; /api/events/raw is the legacy endpoint every version accepts raw JSON batches on.
+const (
+	EndpointCLEF = "/ingest/clef"
+	EndpointRaw  = "/api/events/raw"
+)
+
+// negotiationTimeout bounds the startup probe of a server's ingestion endpoints
+const negotiationTimeout = 5 * time.Second
+
+// Format is the payload format of the requests to the SEQ server, see WithFormat
+type Format string
+
+// Formats accepted by WithFormat
+const (
+	// FormatAuto probes the server for the CLEF endpoint, using raw events JSON when it's missing
+	FormatAuto Format = ""
+	// FormatCLEF sends CLEF, newline-delimited compact JSON, which SEQ 5 and later accept
+	FormatCLEF Format = "clef"
+	// FormatRaw sends {"Events": [...]} batches of raw events JSON, which every SEQ version accepts
+	FormatRaw Format = "raw"
+)
+
+// negotiatedEndpoints caches the endpoint the probe found for each base URL, so the
+// loggers of a process sending to the same server probe it once
+var negotiatedEndpoints sync.Map
+
+// WithFormat pins the payload format instead of detecting it, for environments where
+// the startup probe is unwelcome or can't get through. With a base server URL it also
+// picks the format's endpoint; a URL naming an endpoint keeps it, the raw endpoint
+// taking CLEF too as the content type says so. An encoder set with WithEncoder takes
+// precedence over the format's.
+func WithFormat(format Format) Option {
+	return func(l *SEQLogger) {
+		l.format = format
+	}
+}
+
+// resolveEndpoint turns a server base URL such as http://localhost:5341 into an
+// ingestion endpoint, probing the server unless WithEndpoint or WithFormat chose one
+// or the encoder writes a format only the legacy endpoint accepts. A base URL may
+// have a path, as behind a reverse proxy at http://proxy/seq. A URL that already
+// names an endpoint is used as is, less a trailing slash or a repeat of the endpoint.
+// The CLEF endpoint also switches the default encoder to CLEFEncoder.
+func (l *SEQLogger) resolveEndpoint() {
+	u, err := url.Parse(l.seqURL)
+	if err != nil {
+		return
+	}
+
+	prefix, endpoint := splitEndpoint(u.Path)
+	if endpoint == "" {
+		base := *u
+		base.Path, base.RawPath, base.RawQuery = prefix, "", ""
+		endpoint = l.endpoint
+		switch {
+		case endpoint != "":
+		case l.format == FormatCLEF:
+			endpoint = EndpointCLEF
+		case l.format == FormatRaw:
+			endpoint = EndpointRaw
+		case l.encoder != nil && l.encoder.ContentType() != (CLEFEncoder{}).ContentType():
+			endpoint = EndpointRaw
+		default:
+			endpoint = l.negotiateEndpoint(base.String())
+		}
+	}
+	u.Path, u.RawPath = prefix+endpoint, ""
+	l.seqURL = u.String()
+	if l.encoder == nil && (l.format == FormatCLEF || l.format == FormatAuto && endpoint == EndpointCLEF) {
+		l.encoder = CLEFEncoder{}
+	}
+}
+
+// splitEndpoint splits a URL path into the server's base path, with no trailing slash,
+// and the ingestion endpoint it ends with, "" if none. Empty segments are dropped, and
+// an endpoint repeated at the end of the path counts once.
+func splitEndpoint(p string) (prefix, endpoint string) {
+	for strings.Contains(p, "//") {
+		p = strings.ReplaceAll(p, "//", "/")
+	}
+	p = strings.TrimSuffix(p, "/")
+	for _, known := range []string{EndpointCLEF, EndpointRaw} {
+		if !strings.HasSuffix(p, known) {
+			continue
+		}
+		for strings.HasSuffix(p, known) {
+			p = strings.TrimSuffix(p, known)
+		}
+		return p, known
+	}
+	return p, ""
+}
+
+// negotiateEndpoint posts an empty CLEF payload to base's /ingest/clef endpoint. Servers
+// that don't know it answer 404 or 405 and get the legacy endpoint, as do servers that
+// can't be reached, since every version accepts it. Only an answer is cached, so an
+// unreachable server is probed again by the next logger.
+func (l *SEQLogger) negotiateEndpoint(base string) string {
+	if endpoint, ok := negotiatedEndpoints.Load(base); ok {
+		return endpoint.(string)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), negotiationTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", base+EndpointCLEF, bytes.NewReader(nil))
+	if err != nil {
+		return EndpointRaw
+	}
+	l.setHeaders(req)
+	if err := l.authorize(ctx, req); err != nil {
+		selfLogf("Failed to negotiate the ingestion endpoint, using %s: %v", EndpointRaw, err)
+		return EndpointRaw
+	}
+	req.Header.Set("Content-Type", (CLEFEncoder{}).ContentType())
+
+	resp, err := l.transport.client.Do(req)
+	if err != nil {
+		selfLogf("Failed to negotiate the ingestion endpoint, using %s: %v", EndpointRaw, err)
+		return EndpointRaw
+	}
+	resp.Body.Close()
+	endpoint := EndpointCLEF
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		endpoint = EndpointRaw
+	}
+	negotiatedEndpoints.Store(base, endpoint)
+	return endpoint
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// newEndpointServer starts a server that accepts ingestion on the given paths only
+func newEndpointServer(t *testing.T, paths ...string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, path := range paths {
+			if r.URL.Path == path {
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEndpointNegotiation(t *testing.T) {
+	tests := []struct {
+		name     string
+		paths    []string
+		opts     []Option
+		endpoint string
+		clef     bool
+	}{
+		{"current server", []string{EndpointCLEF, EndpointRaw}, nil, EndpointCLEF, true},
+		{"legacy server", []string{EndpointRaw}, nil, EndpointRaw, false},
+		{"manual override", []string{EndpointCLEF, EndpointRaw}, []Option{WithEndpoint(EndpointRaw)}, EndpointRaw, false},
+		{"raw encoder", []string{EndpointCLEF, EndpointRaw}, []Option{WithEncoder(RawEncoder{})}, EndpointRaw, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newEndpointServer(t, test.paths...)
+			logger := newTestLogger(t, server.URL+"/", 1, test.opts...)
+			defer logger.Close()
+
+			if logger.seqURL != server.URL+test.endpoint {
+				t.Errorf("Expected endpoint %s, got %s", server.URL+test.endpoint, logger.seqURL)
+			}
+			if _, ok := logger.encoder.(CLEFEncoder); ok != test.clef {
+				t.Errorf("Expected CLEF encoding %v, got encoder %T", test.clef, logger.encoder)
+			}
+		})
+	}
+}
+
+func TestEndpointURLIsNotNegotiated(t *testing.T) {
+	var probed atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probed.Store(true)
+	}))
+	defer server.Close()
+
+	logger := newTestLogger(t, server.URL+EndpointRaw, 1)
+	defer logger.Close()
+	if probed.Load() || logger.seqURL != server.URL+EndpointRaw {
+		t.Errorf("Expected %s to be used as is, got %s (probed %v)", server.URL+EndpointRaw, logger.seqURL, probed.Load())
+	}
+}
+
+func TestSplitEndpoint(t *testing.T) {
+	tests := []struct {
+		path, prefix, endpoint string
+	}{
+		{"", "", ""},
+		{"/", "", ""},
+		{"/seq/", "/seq", ""},
+		{EndpointRaw, "", EndpointRaw},
+		{EndpointRaw + "/", "", EndpointRaw},
+		{EndpointRaw + EndpointRaw, "", EndpointRaw},
+		{"/seq//ingest/clef/", "/seq", EndpointCLEF},
+	}
+	for _, test := range tests {
+		if prefix, endpoint := splitEndpoint(test.path); prefix != test.prefix || endpoint != test.endpoint {
+			t.Errorf("splitEndpoint(%q) = %q, %q, expected %q, %q", test.path, prefix, endpoint, test.prefix, test.endpoint)
+		}
+	}
+}
+
+func TestEndpointIsAppendedToBasePath(t *testing.T) {
+	server := newEndpointServer(t, "/seq"+EndpointCLEF, "/seq"+EndpointRaw)
+	logger := newTestLogger(t, server.URL+"/seq/", 1)
+	defer logger.Close()
+	if logger.seqURL != server.URL+"/seq"+EndpointCLEF {
+		t.Errorf("Expected the endpoint under the base path, got %s", logger.seqURL)
+	}
+
+	named := newTestLogger(t, server.URL+EndpointRaw+"/?clef", 1)
+	defer named.Close()
+	if named.seqURL != server.URL+EndpointRaw+"?clef" {
+		t.Errorf("Expected the named endpoint kept with its query, got %s", named.seqURL)
+	}
+}
+
+func TestFormatPinsEndpointWithoutProbing(t *testing.T) {
+	var probes atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes.Add(1)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	clef := newTestLogger(t, server.URL, 1, WithFormat(FormatCLEF))
+	defer clef.Close()
+	raw := newTestLogger(t, server.URL, 1, WithFormat(FormatRaw))
+	defer raw.Close()
+	named := newTestLogger(t, server.URL+EndpointRaw, 1, WithFormat(FormatCLEF))
+	defer named.Close()
+
+	if probes.Load() != 0 {
+		t.Errorf("Expected no probe, got %d", probes.Load())
+	}
+	if _, ok := clef.encoder.(CLEFEncoder); !ok || clef.seqURL != server.URL+EndpointCLEF {
+		t.Errorf("Expected CLEF on %s, got %T on %s", EndpointCLEF, clef.encoder, clef.seqURL)
+	}
+	if _, ok := raw.encoder.(RawEncoder); !ok || raw.seqURL != server.URL+EndpointRaw {
+		t.Errorf("Expected raw events on %s, got %T on %s", EndpointRaw, raw.encoder, raw.seqURL)
+	}
+	if _, ok := named.encoder.(CLEFEncoder); !ok || named.seqURL != server.URL+EndpointRaw {
+		t.Errorf("Expected CLEF on the named endpoint, got %T on %s", named.encoder, named.seqURL)
+	}
+	if _, err := NewSEQLogger(server.URL, "", 1, WithFormat("xml")); err == nil {
+		t.Error("Expected an unknown format to be rejected")
+	}
+}
+
+func TestServerIsProbedOnce(t *testing.T) {
+	var probes atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == EndpointCLEF {
+			probes.Add(1)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		logger := newTestLogger(t, server.URL, 1)
+		logger.Close()
+		if logger.seqURL != server.URL+EndpointCLEF {
+			t.Errorf("Expected the CLEF endpoint, got %s", logger.seqURL)
+		}
+	}
+	if probes.Load() != 1 {
+		t.Errorf("Expected the server probed once, got %d probes", probes.Load())
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+)
+
+// Enricher adds properties to every event logged through a SEQLogger.
+// Properties passed to Log take precedence over enriched ones.
+type Enricher interface {
+	Enrich(fields map[string]interface{})
+}
+
+// EnricherFunc adapts a function to the Enricher interface
+type EnricherFunc func(fields map[string]interface{})
+
+// Enrich calls f(fields)
+func (f EnricherFunc) Enrich(fields map[string]interface{}) {
+	f(fields)
+}
+
+// HostnameEnricher adds the machine name as MachineName
+func HostnameEnricher() Enricher {
+	hostname, _ := os.Hostname()
+	return EnricherFunc(func(fields map[string]interface{}) {
+		fields["MachineName"] = hostname
+	})
+}
+
+// ProcessEnricher adds the ProcessId and ProcessName of the running program
+func ProcessEnricher() Enricher {
+	pid := os.Getpid()
+	name := filepath.Base(os.Args[0])
+	return EnricherFunc(func(fields map[string]interface{}) {
+		fields["ProcessId"] = pid
+		fields["ProcessName"] = name
+	})
+}
+
+// RuntimeEnricher adds the Go version, operating system and architecture
+func RuntimeEnricher() Enricher {
+	return EnricherFunc(func(fields map[string]interface{}) {
+		fields["GoVersion"] = runtime.Version()
+		fields["OS"] = runtime.GOOS
+		fields["Arch"] = runtime.GOARCH
+	})
+}
+
+// GoroutinesEnricher adds the number of goroutines at the time of logging as Goroutines
+func GoroutinesEnricher() Enricher {
+	return EnricherFunc(func(fields map[string]interface{}) {
+		fields["Goroutines"] = runtime.NumGoroutine()
+	})
+}
+
+// namedEnrichers are the built-in enrichers that can be referenced from a configuration file
+var namedEnrichers = map[string]func() Enricher{
+	"hostname":   HostnameEnricher,
+	"process":    ProcessEnricher,
+	"runtime":    RuntimeEnricher,
+	"goroutines": GoroutinesEnricher,
+}
+
+// configEnricher is an enricher named in a configuration file, replaced when the file is reloaded
+type configEnricher struct {
+	Enricher
+}
+
+// levelEnricher is an enricher that only runs for events of at least a level, see ForLevel
+type levelEnricher struct {
+	Enricher
+	minRank int
+}
+
+// ForLevel runs enricher only for events at minLevel or above, so expensive
+// enrichment, such as StackTraceEnricher for Error events, doesn't tax high-volume
+// Information events
+func ForLevel(minLevel string, enricher Enricher) Enricher {
+	return levelEnricher{Enricher: enricher, minRank: levelRank(minLevel)}
+}
+
+// StackTraceEnricher adds the stack of the goroutine logging the event as StackTrace.
+// It is costly, so it is meant to be added with ForLevel.
+func StackTraceEnricher() Enricher {
+	return EnricherFunc(func(fields map[string]interface{}) {
+		fields[StackTraceProperty] = string(debug.Stack())
+	})
+}
+
+// enrich runs the enrichers applying to an event of level into a fresh map and
+// merges fields over the result
+func enrich(enrichers []Enricher, level string, fields map[string]interface{}) map[string]interface{} {
+	if len(enrichers) == 0 {
+		return fields
+	}
+
+	rank := levelRank(level)
+	enriched := make(map[string]interface{}, len(fields)+4)
+	for _, enricher := range enrichers {
+		if conditional, ok := enricher.(levelEnricher); ok && rank < conditional.minRank {
+			continue
+		}
+		enricher.Enrich(enriched)
+	}
+	for key, value := range fields {
+		enriched[key] = value
+	}
+	return enriched
+}
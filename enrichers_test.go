@@ -0,0 +1,21 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestForLevelSkipsLowerLevels(t *testing.T) {
+	logger := newQueueLogger(10, WithEnricher(ForLevel(LevelError, StackTraceEnricher())), WithEnricher(GoroutinesEnricher()))
+
+	logger.Log(LevelInformation, "Order placed", nil)
+	logger.Log(LevelError, "Payment failed", nil)
+
+	placed, failed := <-logger.logChan, <-logger.logChan
+	if _, ok := placed.Fields[StackTraceProperty]; ok || placed.Fields["Goroutines"] == nil {
+		t.Errorf("Expected only the unconditional enricher for Information, got %v", placed.Fields)
+	}
+	if stack, _ := failed.Fields[StackTraceProperty].(string); !strings.Contains(stack, "TestForLevelSkipsLowerLevels") {
+		t.Errorf("Expected a stack trace for Error, got %v", failed.Fields)
+	}
+}
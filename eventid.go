@@ -0,0 +1,29 @@
+package main
+
+// EventTypeIDProperty carries an event's type id in RawEncoder's events, as the raw
+// format has no counterpart to CLEF's @i
+const EventTypeIDProperty = "EventTypeId"
+
+// eventTypeID computes a stable event type id from a message template using
+// Jenkins' one-at-a-time hash, the same scheme Serilog's compact formatter
+// uses for CLEF's @i, so every occurrence of a template shares one id
+func eventTypeID(template string) uint32 {
+	var hash uint32
+	for i := 0; i < len(template); i++ {
+		hash += uint32(template[i])
+		hash += hash << 10
+		hash ^= hash >> 6
+	}
+	hash += hash << 3
+	hash ^= hash >> 11
+	hash += hash << 15
+	return hash
+}
+
+// appendEventTypeID appends id as the eight lowercase hex digits used for CLEF's @i
+func appendEventTypeID(dst []byte, id uint32) []byte {
+	for shift := 28; shift >= 0; shift -= 4 {
+		dst = append(dst, hexDigits[(id>>uint(shift))&0xF])
+	}
+	return dst
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Properties of events read by an EventLogReader
+const (
+	EventLogEventIDProperty   = "EventId"
+	EventLogProviderProperty  = "Provider"
+	EventLogChannelProperty   = "Channel"
+	EventLogComputerProperty  = "Computer"
+	EventLogRecordIDProperty  = "RecordId"
+	EventLogUserIDProperty    = "UserId"
+	EventLogEventDataProperty = "EventData"
+)
+
+const (
+	// defaultEventLogInterval is how often an EventLogReader queries its channels for new records
+	defaultEventLogInterval = 5 * time.Second
+	// eventLogBatch bounds the records read from a channel by one query
+	eventLogBatch = 500
+)
+
+// eventLogLevels maps Windows event levels to levels; 0, LogAlways, is Information
+var eventLogLevels = [6]string{LevelInformation, LevelFatal, LevelError, LevelWarning, LevelInformation, LevelVerbose}
+
+// EventLogReader ships the records of Windows Event Log channels, queried with
+// wevtutil so no cgo or Windows API bindings are needed. Each record's rendered
+// message becomes the message, its EventData a property, and its level maps to the
+// nearest SEQ level. The last record id shipped from each channel is kept in a
+// checkpoint file, so a restarted reader resumes after it; a channel without a
+// checkpoint ships new records only.
+type EventLogReader struct {
+	logger     *SEQLogger
+	channels   []string
+	checkpoint string // path of the checkpoint file, empty to keep record ids in memory only
+	interval   time.Duration
+	command    string // the wevtutil executable
+
+	saved map[string]uint64 // last record id shipped, by channel
+}
+
+// eventLogRecord is an event as rendered by wevtutil's RenderedXml format
+type eventLogRecord struct {
+	System struct {
+		Provider struct {
+			Name string `xml:"Name,attr"`
+		} `xml:"Provider"`
+		EventID     uint32 `xml:"EventID"`
+		Level       int    `xml:"Level"`
+		TimeCreated struct {
+			SystemTime string `xml:"SystemTime,attr"`
+		} `xml:"TimeCreated"`
+		EventRecordID uint64 `xml:"EventRecordID"`
+		Channel       string `xml:"Channel"`
+		Computer      string `xml:"Computer"`
+		Security      struct {
+			UserID string `xml:"UserID,attr"`
+		} `xml:"Security"`
+	} `xml:"System"`
+	EventData struct {
+		Data []struct {
+			Name  string `xml:"Name,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"Data"`
+	} `xml:"EventData"`
+	RenderingInfo struct {
+		Message string `xml:"Message"`
+	} `xml:"RenderingInfo"`
+}
+
+// NewEventLogReader creates an EventLogReader shipping the records of channels, such
+// as Application or System, through logger and keeping its record ids in checkpoint
+func NewEventLogReader(logger *SEQLogger, checkpoint string, channels ...string) *EventLogReader {
+	return &EventLogReader{
+		logger:     logger,
+		channels:   channels,
+		checkpoint: checkpoint,
+		interval:   defaultEventLogInterval,
+		command:    "wevtutil",
+	}
+}
+
+// Run queries the channels for new records until ctx is done or wevtutil can't be run
+func (e *EventLogReader) Run(ctx context.Context) error {
+	if err := e.loadCheckpoint(); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		if err := e.poll(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll ships the records added to every channel since the last poll. A channel that
+// can't be queried is skipped until the next poll, but a missing wevtutil is an error.
+func (e *EventLogReader) poll(ctx context.Context) error {
+	for _, channel := range e.channels {
+		if err := e.pollChannel(ctx, channel); err != nil {
+			// wevtutil is missing or can't be run, which won't change by the next poll
+			var execErr *exec.Error
+			var pathErr *os.PathError
+			if errors.As(err, &execErr) || errors.As(err, &pathErr) {
+				return err
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+			selfLogf("Failed to read event log channel %s: %v", channel, err)
+		}
+	}
+	return nil
+}
+
+// pollChannel ships the records of channel after the checkpointed one
+func (e *EventLogReader) pollChannel(ctx context.Context, channel string) error {
+	last, ok := e.saved[channel]
+	if !ok {
+		// Start after the newest record
+		records, err := e.query(ctx, channel, "/rd:true", "/c:1")
+		if err != nil {
+			return err
+		}
+		if len(records) > 0 {
+			last = records[0].System.EventRecordID
+		}
+		e.saved[channel] = last
+		e.saveCheckpoint()
+		return nil
+	}
+
+	for {
+		records, err := e.query(ctx, channel, fmt.Sprintf("/q:*[System[(EventRecordID>%d)]]", last), "/c:"+strconv.Itoa(eventLogBatch))
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			e.logRecord(record)
+			last = max(last, record.System.EventRecordID)
+		}
+		if len(records) > 0 {
+			e.saved[channel] = last
+			e.saveCheckpoint()
+		}
+		if len(records) < eventLogBatch {
+			return nil
+		}
+	}
+}
+
+// query runs wevtutil qe on channel with args, oldest records first unless args say otherwise
+func (e *EventLogReader) query(ctx context.Context, channel string, args ...string) ([]eventLogRecord, error) {
+	args = append([]string{"qe", channel, "/f:RenderedXml", "/e:Events"}, args...)
+	output, err := exec.CommandContext(ctx, e.command, args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("%s failed: %w: %s", e.command, err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, err
+	}
+	return parseEventLogRecords(output)
+}
+
+// parseEventLogRecords reads the records of an <Events> document
+func parseEventLogRecords(data []byte) ([]eventLogRecord, error) {
+	var events struct {
+		Events []eventLogRecord `xml:"Event"`
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	if err := xml.Unmarshal(data, &events); err != nil {
+		return nil, fmt.Errorf("invalid event XML: %w", err)
+	}
+	return events.Events, nil
+}
+
+// logRecord logs an event log record. A record without a rendered message, whose
+// provider's message file isn't installed, is logged with its event id and provider.
+func (e *EventLogReader) logRecord(record eventLogRecord) {
+	system := record.System
+	fields := map[string]interface{}{
+		EventLogEventIDProperty:  system.EventID,
+		EventLogProviderProperty: system.Provider.Name,
+		EventLogChannelProperty:  system.Channel,
+		EventLogComputerProperty: system.Computer,
+		EventLogRecordIDProperty: system.EventRecordID,
+	}
+	if system.Security.UserID != "" {
+		fields[EventLogUserIDProperty] = system.Security.UserID
+	}
+	if data := record.EventData.Data; len(data) > 0 {
+		eventData := make(map[string]interface{}, len(data))
+		for i, d := range data {
+			// Parameters may be unnamed, and are then known by position
+			name := d.Name
+			if name == "" {
+				name = "Param" + strconv.Itoa(i+1)
+			}
+			eventData[name] = d.Value
+		}
+		fields[EventLogEventDataProperty] = eventData
+	}
+
+	level := LevelInformation
+	if system.Level >= 0 && system.Level < len(eventLogLevels) {
+		level = eventLogLevels[system.Level]
+	}
+	var timestamp time.Time
+	if t, err := time.Parse(time.RFC3339Nano, system.TimeCreated.SystemTime); err == nil {
+		timestamp = t
+	}
+
+	template := "Event {" + EventLogEventIDProperty + "} from {" + EventLogProviderProperty + "}"
+	if message := strings.TrimSpace(record.RenderingInfo.Message); message != "" {
+		template = escapeTemplate(message)
+	}
+	e.logger.LogAt(timestamp, level, template, fields)
+}
+
+// loadCheckpoint reads the record ids saved by a previous run
+func (e *EventLogReader) loadCheckpoint() error {
+	e.saved = make(map[string]uint64)
+	if e.checkpoint == "" {
+		return nil
+	}
+	data, err := os.ReadFile(e.checkpoint)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &e.saved); err != nil {
+		selfLogf("Ignoring unreadable event log checkpoint %s: %v", e.checkpoint, err)
+		e.saved = make(map[string]uint64)
+	}
+	return nil
+}
+
+// saveCheckpoint writes the record ids reached, replacing the checkpoint file atomically
+func (e *EventLogReader) saveCheckpoint() {
+	if e.checkpoint == "" {
+		return
+	}
+	data, err := json.Marshal(e.saved)
+	if err != nil {
+		selfLogf("Failed to encode event log checkpoint: %v", err)
+		return
+	}
+	tmp := e.checkpoint + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		selfLogf("Failed to write event log checkpoint: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, e.checkpoint); err != nil {
+		selfLogf("Failed to write event log checkpoint: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// eventLogXML is an <Events> document as written by wevtutil qe /f:RenderedXml /e:Events
+const eventLogXML = `<Events><Event xmlns='http://schemas.microsoft.com/win/2004/08/events/event'><System><Provider Name='Service Control Manager' Guid='{555908d1-a6d7-4695-8e1e-26931d2012f4}'/><EventID Qualifiers='16384'>7036</EventID><Level>4</Level><TimeCreated SystemTime='2024-01-01T00:00:00.5000000Z'/><EventRecordID>11</EventRecordID><Channel>System</Channel><Computer>HOST</Computer><Security UserID='S-1-5-18'/></System><EventData><Data Name='param1'>Windows Update</Data><Data>running</Data></EventData><RenderingInfo Culture='en-US'><Message>The {Windows Update} service entered the running state.</Message></RenderingInfo></Event>
+<Event xmlns='http://schemas.microsoft.com/win/2004/08/events/event'><System><Provider Name='MyApp'/><EventID>1000</EventID><Level>2</Level><TimeCreated SystemTime='2024-01-01T00:00:01Z'/><EventRecordID>12</EventRecordID><Channel>System</Channel><Computer>HOST</Computer><Security/></System></Event></Events>`
+
+func TestEventLogReaderMapsRecords(t *testing.T) {
+	records, err := parseEventLogRecords([]byte(eventLogXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := newQueueLogger(10)
+	reader := NewEventLogReader(logger, "")
+	for _, record := range records {
+		reader.logRecord(record)
+	}
+
+	logMessage := <-logger.logChan
+	if logMessage.MessageTemplate != "The {{Windows Update}} service entered the running state." || logMessage.Level != LevelInformation {
+		t.Errorf("Unexpected event %+v", logMessage)
+	}
+	if logMessage.Timestamp != "2024-01-01T00:00:00.5Z" {
+		t.Errorf("Expected the record's creation time, got %q", logMessage.Timestamp)
+	}
+	if logMessage.Fields[EventLogEventIDProperty] != uint32(7036) || logMessage.Fields[EventLogUserIDProperty] != "S-1-5-18" {
+		t.Errorf("Unexpected properties %v", logMessage.Fields)
+	}
+	want := map[string]interface{}{"param1": "Windows Update", "Param2": "running"}
+	if got := logMessage.Fields[EventLogEventDataProperty]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the event data %v, got %v", want, got)
+	}
+
+	logMessage = <-logger.logChan
+	if logMessage.MessageTemplate != "Event {EventId} from {Provider}" || logMessage.Level != LevelError {
+		t.Errorf("Expected a record without a message to name its event, got %+v", logMessage)
+	}
+	if _, ok := logMessage.Fields[EventLogUserIDProperty]; ok {
+		t.Errorf("Expected no user id, got %v", logMessage.Fields)
+	}
+}
+
+func TestEventLogReaderResumesFromCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	command := filepath.Join(dir, "wevtutil")
+	argsFile := filepath.Join(dir, "args")
+	records := filepath.Join(dir, "records.xml")
+	if err := os.WriteFile(records, []byte(eventLogXML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The newest record is 10 until records are added, then 11 and 12
+	script := `#!/bin/sh
+echo "$@" >> ` + argsFile + `
+case "$*" in
+*/rd:true*) echo "<Events><Event><System><EventRecordID>10</EventRecordID></System></Event></Events>" ;;
+*EventRecordID\>10*) cat ` + records + ` ;;
+*) echo "<Events></Events>" ;;
+esac
+`
+	if err := os.WriteFile(command, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	checkpoint := filepath.Join(dir, "eventlog.checkpoint")
+	newReader := func(logger *SEQLogger) *EventLogReader {
+		reader := NewEventLogReader(logger, checkpoint, "System")
+		reader.command = command
+		if err := reader.loadCheckpoint(); err != nil {
+			t.Fatal(err)
+		}
+		return reader
+	}
+
+	logger := newQueueLogger(10)
+	if err := newReader(logger).poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := drainTemplates(logger); len(got) != 0 {
+		t.Errorf("Expected the first run to ship new records only, got %v", got)
+	}
+
+	// A restarted reader ships the records after the checkpointed one, once
+	reader := newReader(logger)
+	for i := 0; i < 2; i++ {
+		if err := reader.poll(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := drainTemplates(logger); len(got) != 2 {
+		t.Errorf("Expected records 11 and 12, got %v", got)
+	}
+	if data, err := os.ReadFile(checkpoint); err != nil || string(data) != `{"System":12}` {
+		t.Errorf("Expected the checkpoint to hold record 12, got %q, %v", data, err)
+	}
+
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(args), "qe System /f:RenderedXml /e:Events /q:*[System[(EventRecordID>12)]] /c:500") {
+		t.Errorf("Expected a query after the last record, got %s", args)
+	}
+}
+
+func TestEventLogReaderRequiresWevtutil(t *testing.T) {
+	reader := NewEventLogReader(newQueueLogger(1), "", "System")
+	reader.command = filepath.Join(t.TempDir(), "missing")
+	if err := reader.Run(context.Background()); err == nil {
+		t.Error("Expected an error when wevtutil can't be run")
+	}
+}
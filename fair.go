@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// fairQueue holds events in a bounded sub-queue per SourceContext and hands them to
+// the main queue in weighted round-robin order, so a component flooding the logger
+// fills only its own sub-queue and the others keep their share of the bandwidth
+type fairQueue struct {
+	capacity int            // events per sub-queue
+	weights  map[string]int // events per turn, by SourceContext
+	mu       sync.Mutex
+	ready    *sync.Cond // signalled when an event is pushed or the queue is closed
+	room     *sync.Cond // broadcast when events leave a sub-queue
+	sources  map[string]*fairSource
+	ring     []*fairSource // sources with waiting events, in turn order
+	next     int           // index in ring of the source whose turn is next
+	queued   int           // events waiting across sub-queues
+	pushed   uint64
+	moved    uint64
+	closed   bool
+	done     chan struct{} // closed when the pump has moved the last event
+}
+
+// fairSource is the sub-queue of one SourceContext
+type fairSource struct {
+	name   string
+	events []LogMessage
+}
+
+// WithFairQueueing gives each SourceContext, the name of a logger created with Named,
+// its own sub-queue of up to capacity events, and moves events from the sub-queues
+// to the main queue in weighted round-robin order: a source takes up to its weight
+// in events per turn, 1 unless set in weights. A noisy component that fills its
+// sub-queue blocks its own Log calls only, while events of the other components,
+// and of loggers without a SourceContext, which share the "" sub-queue, keep flowing.
+// With WithSpill, events are spilled only beyond the WithMemoryBudget limit, as the
+// sub-queues, not the spill file, absorb a full main queue.
+func WithFairQueueing(capacity int, weights map[string]int) Option {
+	return func(l *SEQLogger) {
+		if capacity < 1 {
+			capacity = 1
+		}
+		f := &fairQueue{
+			capacity: capacity,
+			weights:  make(map[string]int, len(weights)),
+			sources:  make(map[string]*fairSource),
+			done:     make(chan struct{}),
+		}
+		for source, weight := range weights {
+			f.weights[source] = weight
+		}
+		f.ready = sync.NewCond(&f.mu)
+		f.room = sync.NewCond(&f.mu)
+		l.fair = f
+	}
+}
+
+// push adds logMessage to the sub-queue of its SourceContext, waiting while that
+// sub-queue is full. It returns false once the queue is closed.
+func (f *fairQueue) push(logMessage *LogMessage) bool {
+	name, _ := logMessage.Fields[SourceContextProperty].(string)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var source *fairSource
+	for {
+		if f.closed {
+			return false
+		}
+		// A sub-queue emptied while waiting leaves sources, so it is looked up again
+		source = f.sources[name]
+		if source == nil {
+			source = &fairSource{name: name}
+			f.sources[name] = source
+		}
+		if len(source.events) < f.capacity {
+			break
+		}
+		f.room.Wait()
+	}
+	if len(source.events) == 0 {
+		f.ring = append(f.ring, source)
+	}
+	source.events = append(source.events, *logMessage)
+	f.queued++
+	f.pushed++
+	f.ready.Signal()
+	return true
+}
+
+// pop waits for events and takes the turn of the next source: up to its weight in
+// events. It returns nil once the queue is closed and empty.
+func (f *fairQueue) pop() []LogMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.ring) == 0 {
+		if f.closed {
+			return nil
+		}
+		f.ready.Wait()
+	}
+
+	if f.next >= len(f.ring) {
+		f.next = 0
+	}
+	source := f.ring[f.next]
+	n := f.weights[source.name]
+	if n < 1 {
+		n = 1
+	}
+	if n > len(source.events) {
+		n = len(source.events)
+	}
+	events := make([]LogMessage, n)
+	copy(events, source.events)
+	source.events = append(source.events[:0], source.events[n:]...)
+	f.queued -= n
+
+	if len(source.events) == 0 {
+		// The source leaves the ring, and the next one moves up into its turn
+		f.ring = append(f.ring[:f.next], f.ring[f.next+1:]...)
+		delete(f.sources, source.name)
+	} else {
+		f.next++
+	}
+	f.room.Broadcast()
+	return events
+}
+
+// len returns how many events wait in the sub-queues
+func (f *fairQueue) len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.queued
+}
+
+// markMoved records that n popped events are in the main queue
+func (f *fairQueue) markMoved(n int) {
+	f.mu.Lock()
+	f.moved += uint64(n)
+	f.mu.Unlock()
+}
+
+// waitMoved waits until every event pushed before the call is in the main queue
+func (f *fairQueue) waitMoved(ctx context.Context) error {
+	f.mu.Lock()
+	target := f.pushed
+	f.mu.Unlock()
+
+	ticker := time.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		f.mu.Lock()
+		moved := f.moved
+		f.mu.Unlock()
+		if moved >= target {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// pumpFair moves events from the sub-queues into logChan until the queue is closed
+// and empty
+func (l *SEQLogger) pumpFair() {
+	defer close(l.fair.done)
+	for events := l.fair.pop(); events != nil; events = l.fair.pop() {
+		l.requeue(events)
+		l.fair.markMoved(len(events))
+	}
+}
+
+// closeFair waits for the pump to move the events still in the sub-queues into
+// logChan; it is called by Close once no more events can be logged, and turns away
+// the Log calls still waiting for room
+func (l *SEQLogger) closeFair() {
+	l.fair.mu.Lock()
+	l.fair.closed = true
+	l.fair.ready.Signal()
+	l.fair.room.Broadcast()
+	l.fair.mu.Unlock()
+	<-l.fair.done
+}
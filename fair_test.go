@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sourceEvent builds an event logged by the logger named source
+func sourceEvent(source, message string) *LogMessage {
+	return &LogMessage{MessageTemplate: message, Fields: map[string]interface{}{SourceContextProperty: source}}
+}
+
+func TestFairQueueAlternatesByWeight(t *testing.T) {
+	logger := newQueueLogger(0, WithFairQueueing(10, map[string]int{"Billing": 2}))
+	for _, message := range []string{"n1", "n2", "n3", "n4"} {
+		logger.fair.push(sourceEvent("Noisy", message))
+	}
+	for _, message := range []string{"b1", "b2", "b3"} {
+		logger.fair.push(sourceEvent("Billing", message))
+	}
+
+	var order []string
+	for logger.fair.len() > 0 {
+		for _, logMessage := range logger.fair.pop() {
+			order = append(order, logMessage.MessageTemplate)
+		}
+	}
+	if got, want := strings.Join(order, " "), "n1 b1 b2 n2 b3 n3 n4"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestFairQueueBlocksOnlyTheFullSource(t *testing.T) {
+	logger := newQueueLogger(0, WithFairQueueing(2, nil))
+	logger.fair.push(sourceEvent("Noisy", "n1"))
+	logger.fair.push(sourceEvent("Noisy", "n2"))
+
+	blocked := make(chan struct{})
+	go func() {
+		logger.fair.push(sourceEvent("Noisy", "n3"))
+		close(blocked)
+	}()
+	logger.fair.push(sourceEvent("Quiet", "q1"))
+
+	select {
+	case <-blocked:
+		t.Fatal("Expected the full sub-queue to block its source")
+	case <-time.After(20 * time.Millisecond):
+	}
+	logger.fair.pop()
+	select {
+	case <-blocked:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the source to be unblocked once its sub-queue had room")
+	}
+	if depth := logger.QueueDepth(); depth != 3 {
+		t.Errorf("Expected a queue depth of 3, got %d", depth)
+	}
+}
+
+func TestFairQueueingDeliversOnFlushAndClose(t *testing.T) {
+	server := newSeqRecorder(t)
+	logger := newTestLogger(t, server.URL+EndpointRaw, 10, WithFairQueueing(5, nil))
+	logger.Named("Billing").Log(LevelInformation, "Invoice sent", nil)
+	if err := logger.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	if received := server.received(); !strings.Contains(received, "Invoice sent") {
+		t.Errorf("Expected the event delivered by Flush, got %s", received)
+	}
+
+	logger.Named("Search").Log(LevelInformation, "Index rebuilt", nil)
+	logger.Close()
+	if received := server.received(); !strings.Contains(received, "Index rebuilt") {
+		t.Errorf("Expected the event delivered by Close, got %s", received)
+	}
+}
+
+func TestFairQueueResizeAndCloseWithFullSubQueue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+	logger := newTestLogger(t, server.URL+EndpointRaw, 1, WithFairQueueing(1, nil), WithBatching(1, 0))
+
+	var wg sync.WaitGroup
+	noisy := logger.Named("Noisy")
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !logger.closed.Load() {
+				noisy.Log(LevelInformation, "Cache miss", nil)
+			}
+		}()
+	}
+	// Let the Log calls fill the sub-queue and wait for room
+	time.Sleep(50 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := logger.Resize(100); err != nil {
+			t.Errorf("Failed to resize: %v", err)
+		}
+		logger.Close()
+		wg.Wait()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Resize and Close to return with a full sub-queue")
+	}
+}
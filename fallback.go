@@ -0,0 +1,14 @@
+package main
+
+// fallBack hands a batch that could not be delivered to the fallback sink, or to the
+// local log when there is none or the fallback fails too
+func (l *SEQLogger) fallBack(batch []LogMessage) {
+	if l.fallback != nil {
+		err := l.fallback.Emit(batch)
+		if err == nil {
+			return
+		}
+		selfLogf("Failed to write log messages to fallback %T: %v", l.fallback, err)
+	}
+	logLocally(batch)
+}
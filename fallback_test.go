@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFallbackReceivesUndeliveredBatches(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	logger := newQueueLogger(1, WithEncoder(RawEncoder{}), WithFallback(NewWriterSink(&out, nil)))
+	logger.seqURL = server.URL
+	logger.retry = defaultRetryPolicy
+
+	batch := []LogMessage{
+		{Timestamp: "2024-01-02T03:04:05Z", Level: LevelError, MessageTemplate: "Payment {PaymentId} failed",
+			Fields: map[string]interface{}{"PaymentId": 7}},
+	}
+	if err := logger.deliver(server.Client(), batch); err == nil {
+		t.Fatal("Expected delivery to fail")
+	}
+
+	var event map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(out.Bytes()), &event); err != nil {
+		t.Fatalf("Expected a CLEF line, got %q: %v", out.String(), err)
+	}
+	if event["@mt"] != "Payment {PaymentId} failed" || event["PaymentId"] != float64(7) {
+		t.Errorf("Unexpected fallback event %v", event)
+	}
+	if strings.Count(out.String(), "\n") != 1 {
+		t.Errorf("Expected one line per event, got %q", out.String())
+	}
+}
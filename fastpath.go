@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// hexDigits is used to write \u escapes for control characters
+const hexDigits = "0123456789abcdef"
+
+// writeFieldlessEvent writes an event carrying only timestamp, level, template and event type id without building a map
+func writeFieldlessEvent(buf *bytes.Buffer, logMessage *LogMessage) {
+	buf.WriteString(`{"Timestamp":`)
+	writeJSONString(buf, logMessage.Timestamp)
+	buf.WriteString(`,"Level":`)
+	writeJSONString(buf, logMessage.Level)
+	buf.WriteString(`,"MessageTemplate":`)
+	writeJSONString(buf, logMessage.MessageTemplate)
+	if logMessage.EventID != 0 {
+		buf.WriteString(`,"Properties":{"` + EventTypeIDProperty + `":"`)
+		var id [8]byte
+		buf.Write(appendEventTypeID(id[:0], logMessage.EventID))
+		buf.WriteString(`"}`)
+	}
+	buf.WriteByte('}')
+}
+
+// writeJSONString writes s as a quoted JSON string, escaping it the same way encoding/json does
+func writeJSONString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			buf.WriteString(s[start:i])
+			switch c {
+			case '"', '\\':
+				buf.WriteByte('\\')
+				buf.WriteByte(c)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			case '\t':
+				buf.WriteString(`\t`)
+			default:
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hexDigits[c>>4])
+				buf.WriteByte(hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf.WriteString(s[start:i])
+			buf.WriteString("\ufffd")
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			buf.WriteString(s[start:i])
+			buf.WriteString(`\u202`)
+			buf.WriteByte(hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf.WriteString(s[start:])
+	buf.WriteByte('"')
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// maxFeedbackBody bounds how much of an ingestion response is read for level feedback
+const maxFeedbackBody = 4 << 10
+
+// WithLevelFeedback follows the minimum level SEQ reports in its ingestion responses,
+// the MinimumLevelAccepted of the API key, so events the server would discard aren't
+// sent in the first place. The server's level applies on top of WithMinLevel and the
+// level overrides; once the server reports none, every event they allow is sent again.
+func WithLevelFeedback() Option {
+	return func(l *SEQLogger) {
+		l.levelFeedback = true
+	}
+}
+
+// applyLevelFeedback reads the MinimumLevelAccepted of a successful ingestion response
+func (l *SEQLogger) applyLevelFeedback(body io.Reader) {
+	var response struct {
+		MinimumLevelAccepted *string
+	}
+	data, err := io.ReadAll(io.LimitReader(body, maxFeedbackBody))
+	if err != nil || json.Unmarshal(data, &response) != nil {
+		return
+	}
+
+	var rank int
+	if response.MinimumLevelAccepted != nil {
+		var ok bool
+		if rank, ok = knownLevelRank(*response.MinimumLevelAccepted); !ok {
+			return
+		}
+	}
+	if previous := l.serverMinLevel.Swap(int32(rank)); previous != int32(rank) {
+		selfLogf("SEQ server accepts events from level %s", levelNames[rank])
+	}
+}
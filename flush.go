@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// maxFlushErrors bounds how many delivery errors are kept for the next Flush
+const maxFlushErrors = 100
+
+// Flush blocks until every event logged before the call has been delivered to the
+// SEQ server and sinks, or logged locally after failing, and returns the delivery
+// errors seen since the previous Flush joined into one error. A batch job can check
+// it before exiting to know that all logs were shipped. It returns ErrClosed once
+// the logger is closed.
+func (l *SEQLogger) Flush(ctx context.Context) error {
+	l = l.pipeline()
+	if l.pump != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return l.FlushNow()
+	}
+	if l.fair != nil {
+		if err := l.fair.waitMoved(ctx); err != nil {
+			return err
+		}
+	}
+	if l.spill != nil {
+		if err := l.spill.waitDrained(ctx); err != nil {
+			return err
+		}
+	}
+
+	done := make(chan error, 1)
+	if err := l.enqueueFlush(ctx, done); err != nil {
+		return err
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// enqueueFlush queues a flush marker unless the logger is closed or ctx is done first
+func (l *SEQLogger) enqueueFlush(ctx context.Context, done chan error) error {
+	l.closeMu.RLock()
+	defer l.closeMu.RUnlock()
+	if l.closed.Load() {
+		return ErrClosed
+	}
+
+	select {
+	case l.logChan <- LogMessage{flush: done}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// recordFlushError keeps a delivery error for the next Flush and the debug handler
+func (l *SEQLogger) recordFlushError(err error) {
+	l.recentErrors.add(err, time.Now())
+	if len(l.flushErrs) >= maxFlushErrors {
+		l.flushDropped++
+		return
+	}
+	l.flushErrs = append(l.flushErrs, err)
+}
+
+// completeFlush answers a flush marker with the errors recorded since the previous one
+func (l *SEQLogger) completeFlush(done chan error) {
+	done <- l.takeFlushErrors()
+}
+
+// takeFlushErrors returns the errors recorded since the previous flush joined into one
+func (l *SEQLogger) takeFlushErrors() error {
+	errs := l.flushErrs
+	if l.flushDropped > 0 {
+		errs = append(errs, fmt.Errorf("%d more delivery errors", l.flushDropped))
+	}
+	l.flushErrs = nil
+	l.flushDropped = 0
+	return errors.Join(errs...)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlushWaitsForDelivery(t *testing.T) {
+	seq := newSeqRecorder(t)
+	logger := newTestLogger(t, seq.URL+EndpointRaw, 100, WithBatching(10, time.Hour))
+	defer logger.Close()
+
+	for i := 0; i < 15; i++ {
+		logger.Log(LevelInformation, "Order {OrderId} placed", map[string]interface{}{"OrderId": i})
+	}
+	if err := logger.Flush(context.Background()); err != nil {
+		t.Fatalf("Expected a clean flush, got %v", err)
+	}
+	if n := strings.Count(seq.received(), "Order {OrderId} placed"); n != 15 {
+		t.Errorf("Expected 15 events delivered by Flush, got %d", n)
+	}
+}
+
+func TestFlushReportsDeliveryErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	logger := newTestLogger(t, server.URL+EndpointRaw, 10)
+	defer logger.Close()
+
+	logger.Log(LevelInformation, "Order placed", nil)
+	err := logger.Flush(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "400") {
+		t.Fatalf("Expected the 400 response to be reported, got %v", err)
+	}
+	if err := logger.Flush(context.Background()); err != nil {
+		t.Errorf("Expected errors to be reported only once, got %v", err)
+	}
+}
+
+func TestFlushHonoursContext(t *testing.T) {
+	logger := newQueueLogger(0)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := logger.Flush(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected the deadline to end Flush, got %v", err)
+	}
+}
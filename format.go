@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// formatValue renders value with a .NET-style format specifier as used in message templates
+// such as "{Elapsed:000}" or "{At:yyyy-MM-dd}", falling back to fmt.Sprint for unsupported formats
+func formatValue(value interface{}, format string) string {
+	if t, ok := value.(time.Time); ok {
+		return t.Format(timeLayout(format))
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if s, ok := formatNumber(float64(rv.Int()), rv.Int(), true, format); ok {
+			return s
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u := rv.Uint(); u <= math.MaxInt64 {
+			if s, ok := formatNumber(float64(u), int64(u), true, format); ok {
+				return s
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		if s, ok := formatNumber(rv.Float(), 0, false, format); ok {
+			return s
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// formatNumber applies a standard (D, X, F, N, P) or custom ("000", "#,##0.00") numeric format
+func formatNumber(f float64, i int64, integer bool, format string) (string, bool) {
+	if format == "" {
+		return "", false
+	}
+
+	if precision, ok := standardPrecision(format); ok {
+		switch format[0] {
+		case 'D', 'd':
+			if !integer {
+				return "", false
+			}
+			return withSign(i < 0, padLeft(strconv.FormatUint(absInt(i), 10), precision)), true
+		case 'X', 'x':
+			if !integer {
+				return "", false
+			}
+			s := padLeft(strconv.FormatUint(uint64(i), 16), precision)
+			if format[0] == 'X' {
+				s = strings.ToUpper(s)
+			}
+			return s, true
+		case 'F', 'f':
+			return formatFixed(f, defaultPrecision(precision, 2)), true
+		case 'N', 'n':
+			s := formatFixed(math.Abs(f), defaultPrecision(precision, 2))
+			whole, fraction, hasFraction := strings.Cut(s, ".")
+			s = groupThousands(whole)
+			if hasFraction {
+				s += "." + fraction
+			}
+			return withSign(f < 0, s), true
+		case 'P', 'p':
+			return formatFixed(f*100, defaultPrecision(precision, 2)) + "%", true
+		}
+		return "", false
+	}
+
+	return formatCustomNumber(f, format)
+}
+
+// standardPrecision parses the precision of a standard numeric format such as "N2", returning -1 when absent
+func standardPrecision(format string) (int, bool) {
+	c := format[0]
+	if (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') {
+		return 0, false
+	}
+	if len(format) == 1 {
+		return -1, true
+	}
+	precision, err := strconv.Atoi(format[1:])
+	if err != nil || precision < 0 || precision > 99 {
+		return 0, false
+	}
+	return precision, true
+}
+
+// formatCustomNumber applies a custom numeric format made of '0', '#', ',' and '.' characters
+func formatCustomNumber(f float64, format string) (string, bool) {
+	if strings.Trim(format, "0#,.") != "" || strings.Count(format, ".") > 1 {
+		return "", false
+	}
+
+	wholeFormat, fractionFormat, _ := strings.Cut(format, ".")
+	minWhole := strings.Count(wholeFormat, "0")
+	minFraction := strings.Count(fractionFormat, "0")
+	maxFraction := minFraction + strings.Count(fractionFormat, "#")
+
+	s := formatFixed(math.Abs(f), maxFraction)
+	whole, fraction, _ := strings.Cut(s, ".")
+	for len(fraction) > minFraction && fraction[len(fraction)-1] == '0' {
+		fraction = fraction[:len(fraction)-1]
+	}
+	if whole == "0" && minWhole == 0 {
+		whole = ""
+	}
+	whole = padLeft(whole, minWhole)
+	if strings.Contains(wholeFormat, ",") {
+		whole = groupThousands(whole)
+	}
+
+	s = whole
+	if fraction != "" {
+		s += "." + fraction
+	}
+	return withSign(f < 0 && strings.Trim(s, "0.,") != "", s), true
+}
+
+// timeLayoutTokens maps .NET custom date and time format tokens to their Go layout equivalent
+var timeLayoutTokens = map[string]string{
+	"yyyy": "2006", "yy": "06",
+	"MMMM": "January", "MMM": "Jan", "MM": "01", "M": "1",
+	"dddd": "Monday", "ddd": "Mon", "dd": "02", "d": "2",
+	"HH": "15", "hh": "03", "h": "3",
+	"mm": "04", "m": "4",
+	"ss": "05", "s": "5",
+	"fffffff": "0000000", "ffffff": "000000", "fffff": "00000", "ffff": "0000",
+	"fff": "000", "ff": "00", "f": "0",
+	"tt": "PM", "zzz": "-07:00", "K": "Z07:00",
+}
+
+// timeLayout converts a .NET date and time format string into a Go time layout
+func timeLayout(format string) string {
+	switch format {
+	case "o", "O":
+		return time.RFC3339Nano
+	case "s":
+		return "2006-01-02T15:04:05"
+	case "u":
+		return "2006-01-02 15:04:05Z"
+	case "d":
+		return "1/2/2006"
+	case "D":
+		return "Monday, January 2, 2006"
+	case "t":
+		return "3:04 PM"
+	case "T":
+		return "3:04:05 PM"
+	case "g":
+		return "1/2/2006 3:04 PM"
+	case "G":
+		return "1/2/2006 3:04:05 PM"
+	}
+
+	var layout strings.Builder
+	for i := 0; i < len(format); {
+		if format[i] == '\'' {
+			end := strings.IndexByte(format[i+1:], '\'')
+			if end < 0 {
+				layout.WriteString(format[i+1:])
+				break
+			}
+			layout.WriteString(format[i+1 : i+1+end])
+			i += end + 2
+			continue
+		}
+
+		run := 1
+		for i+run < len(format) && format[i+run] == format[i] {
+			run++
+		}
+		if replacement, ok := timeLayoutTokens[format[i:i+run]]; ok {
+			layout.WriteString(replacement)
+		} else {
+			layout.WriteString(format[i : i+run])
+		}
+		i += run
+	}
+	return layout.String()
+}
+
+// formatFixed formats f with precision fraction digits, rounding halves away from zero like .NET does
+func formatFixed(f float64, precision int) string {
+	if precision <= 15 {
+		scale := math.Pow(10, float64(precision))
+		if rounded := math.Round(f*scale) / scale; !math.IsInf(rounded, 0) && !math.IsNaN(rounded) {
+			f = rounded
+		}
+	}
+	return strconv.FormatFloat(f, 'f', precision, 64)
+}
+
+// groupThousands inserts ',' between every group of three digits of whole
+func groupThousands(whole string) string {
+	if len(whole) <= 3 {
+		return whole
+	}
+	var b strings.Builder
+	head := len(whole) % 3
+	if head > 0 {
+		b.WriteString(whole[:head])
+	}
+	for i := head; i < len(whole); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(whole[i : i+3])
+	}
+	return b.String()
+}
+
+// padLeft pads s with leading zeros up to width digits
+func padLeft(s string, width int) string {
+	if len(s) >= width {
+		return s
+	}
+	return strings.Repeat("0", width-len(s)) + s
+}
+
+// withSign prefixes s with '-' when negative is set
+func withSign(negative bool, s string) string {
+	if negative {
+		return "-" + s
+	}
+	return s
+}
+
+// absInt returns the magnitude of i, valid for math.MinInt64 as well
+func absInt(i int64) uint64 {
+	if i < 0 {
+		return uint64(-(i + 1)) + 1
+	}
+	return uint64(i)
+}
+
+// defaultPrecision returns precision, or fallback when the format didn't specify one
+func defaultPrecision(precision, fallback int) int {
+	if precision < 0 {
+		return fallback
+	}
+	return precision
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ForwardTagProperty carries the Fluentd tag of events received by ServeForward
+const ForwardTagProperty = "FluentTag"
+
+// ServeForward accepts connections speaking the Fluentd forward protocol on ln, such as
+// those of Docker's fluentd logging driver or fluent-bit, and logs the records they
+// send until ctx is done. The log, message or msg key of a record becomes the message,
+// its level or severity key the level and the other keys its properties. Chunks sent
+// with an ack request are acknowledged once their records are queued.
+func (l *SEQLogger) ServeForward(ctx context.Context, ln net.Listener) error {
+	mapping := defaultPipeMapping()
+	return l.serveForward(ctx, ln, &mapping)
+}
+
+// serveForward is ServeForward mapping records with mapping
+func (l *SEQLogger) serveForward(ctx context.Context, ln net.Listener, mapping *pipeMapping) error {
+	stop := context.AfterFunc(ctx, func() { ln.Close() })
+	defer stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+			closeConn := context.AfterFunc(ctx, func() { conn.Close() })
+			defer closeConn()
+
+			if err := l.handleForward(conn, mapping); err != nil && ctx.Err() == nil {
+				selfLogf("Failed to read forward protocol message from %v: %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// handleForward logs the messages of a forward protocol connection until it is closed
+func (l *SEQLogger) handleForward(conn net.Conn, mapping *pipeMapping) error {
+	reader := &msgpackReader{r: bufio.NewReader(conn)}
+	for {
+		v, err := reader.decode()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		message, ok := v.([]interface{})
+		if !ok || len(message) < 2 {
+			return fmt.Errorf("expected a [tag, ...] array, got %T", v)
+		}
+		tag, ok := forwardString(message[0])
+		if !ok {
+			return fmt.Errorf("expected a string tag, got %T", message[0])
+		}
+
+		var options map[string]interface{}
+		switch entries := message[1].(type) {
+		case []interface{}:
+			// Forward mode: [tag, [[time, record], ...], options]
+			for _, entry := range entries {
+				if err := l.logForwardEntry(tag, entry, mapping); err != nil {
+					return err
+				}
+			}
+			options = forwardOptions(message, 2)
+		case string, []byte:
+			// PackedForward mode: [tag, MessagePack stream of [time, record], options]
+			options = forwardOptions(message, 2)
+			if err := l.logPackedForward(tag, entries, options, mapping); err != nil {
+				return err
+			}
+		default:
+			// Message mode: [tag, time, record, options]
+			if len(message) < 3 {
+				return errors.New("message mode entry without a record")
+			}
+			if err := l.logForwardEntry(tag, message[1:3], mapping); err != nil {
+				return err
+			}
+			options = forwardOptions(message, 3)
+		}
+
+		if chunk, ok := options["chunk"]; ok {
+			if _, err := conn.Write(appendMsgpack(nil, map[string]interface{}{"ack": chunk})); err != nil {
+				return fmt.Errorf("failed to acknowledge chunk: %w", err)
+			}
+		}
+	}
+}
+
+// logPackedForward logs the [time, record] entries packed into a PackedForward message,
+// gzip-compressed when options say so (CompressedPackedForward)
+func (l *SEQLogger) logPackedForward(tag string, entries interface{}, options map[string]interface{}, mapping *pipeMapping) error {
+	var r io.Reader
+	switch entries := entries.(type) {
+	case string:
+		r = strings.NewReader(entries)
+	case []byte:
+		r = bytes.NewReader(entries)
+	}
+	if compressed, _ := options["compressed"].(string); compressed == "gzip" {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("invalid compressed entries: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	reader := &msgpackReader{r: bufio.NewReader(r)}
+	for {
+		entry, err := reader.decode()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid packed entries: %w", err)
+		}
+		if err := l.logForwardEntry(tag, entry, mapping); err != nil {
+			return err
+		}
+	}
+}
+
+// logForwardEntry logs a [time, record] entry
+func (l *SEQLogger) logForwardEntry(tag string, entry interface{}, mapping *pipeMapping) error {
+	pair, ok := entry.([]interface{})
+	if !ok || len(pair) < 2 {
+		return fmt.Errorf("expected a [time, record] entry, got %T", entry)
+	}
+	record, ok := pair[1].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected a record map, got %T", pair[1])
+	}
+	for key, value := range record {
+		// Fluentd treats binary and string values alike, e.g. fluent-bit's log lines
+		if s, ok := value.([]byte); ok {
+			record[key] = string(s)
+		}
+	}
+
+	event := mapping.object(record)
+	if event.timestamp.IsZero() {
+		event.timestamp = forwardTime(pair[0])
+	}
+	if event.template == "" {
+		raw, _ := json.Marshal(record)
+		event.template = escapeTemplate(string(raw))
+	}
+	event.fields = withField(event.fields, ForwardTagProperty, tag)
+	l.LogAt(event.timestamp, event.level, event.template, event.fields)
+	return nil
+}
+
+// forwardTime reads an entry's time: Unix seconds or an EventTime extension with
+// nanoseconds. Unknown forms give the zero time, which logs the event at the current time.
+func forwardTime(v interface{}) time.Time {
+	switch t := v.(type) {
+	case int64:
+		return time.Unix(t, 0)
+	case uint64:
+		return time.Unix(int64(t), 0)
+	case float64:
+		return time.Unix(0, int64(t*float64(time.Second)))
+	case msgpackExt:
+		if t.typ == 0 && len(t.data) == 8 {
+			return time.Unix(int64(binary.BigEndian.Uint32(t.data[:4])), int64(binary.BigEndian.Uint32(t.data[4:])))
+		}
+	}
+	return time.Time{}
+}
+
+// forwardString reads a str or bin value as a string
+func forwardString(v interface{}) (string, bool) {
+	switch s := v.(type) {
+	case string:
+		return s, true
+	case []byte:
+		return string(s), true
+	}
+	return "", false
+}
+
+// forwardOptions returns the options map at message[i], if there is one
+func forwardOptions(message []interface{}, i int) map[string]interface{} {
+	if len(message) <= i {
+		return nil
+	}
+	options, _ := message[i].(map[string]interface{})
+	return options
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startForwardReceiver serves the forward protocol for logger on a local port and returns its address
+func startForwardReceiver(t *testing.T, logger *SEQLogger) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- logger.ServeForward(ctx, ln) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("ServeForward failed: %v", err)
+		}
+	})
+	return ln.Addr().String()
+}
+
+func TestForwardReceiverModes(t *testing.T) {
+	logger := newQueueLogger(10)
+	addr := startForwardReceiver(t, logger)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	eventTime := msgpackExt{typ: 0, data: []byte{0x65, 0x93, 0x7d, 0x25, 0, 0, 0, 0}} // 2024-01-02T03:04:05Z
+	record := func(line string) map[string]interface{} {
+		return map[string]interface{}{"log": line, "container_name": "/web", "source": "stdout"}
+	}
+
+	var packed []byte
+	packed = appendMsgpack(packed, []interface{}{int64(1704164645), record("packed")})
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write(appendMsgpack(nil, []interface{}{int64(1704164645), record("compressed")}))
+	gz.Close()
+
+	var messages []byte
+	messages = appendMsgpack(messages, []interface{}{"docker.web", eventTime, record("message mode")})
+	messages = appendMsgpack(messages, []interface{}{"docker.web", []interface{}{
+		[]interface{}{int64(1704164645), record("forward mode")},
+	}})
+	messages = appendMsgpack(messages, []interface{}{"docker.web", packed})
+	messages = appendMsgpack(messages, []interface{}{"docker.web", compressed.Bytes(), map[string]interface{}{"compressed": "gzip", "chunk": "c1"}})
+	if _, err := conn.Write(messages); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	ack, err := (&msgpackReader{r: conn}).decode()
+	if err != nil {
+		t.Fatalf("Expected an ack: %v", err)
+	}
+	if m, ok := ack.(map[string]interface{}); !ok || m["ack"] != "c1" {
+		t.Errorf("Expected ack of chunk c1, got %v", ack)
+	}
+
+	for _, want := range []string{"message mode", "forward mode", "packed", "compressed"} {
+		select {
+		case logMessage := <-logger.logChan:
+			if logMessage.MessageTemplate != want || logMessage.Fields[ForwardTagProperty] != "docker.web" || logMessage.Fields["container_name"] != "/web" {
+				t.Errorf("Unexpected event for %q: %+v", want, logMessage)
+			}
+			if !strings.HasPrefix(logMessage.Timestamp, "2024-01-02T03:04:05") {
+				t.Errorf("Expected the entry time, got %s", logMessage.Timestamp)
+			}
+			if _, ok := logMessage.Fields["log"]; ok {
+				t.Errorf("Expected the log key to become the message, got %v", logMessage.Fields)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Event %q was not logged", want)
+		}
+	}
+}
+
+func TestForwardReceiverDropsMalformedConnection(t *testing.T) {
+	logger := newQueueLogger(10)
+	captureSelfLog(t)
+	addr := startForwardReceiver(t, logger)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write(appendMsgpack(nil, "not an array"))
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Error("Expected the receiver to close the connection")
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Properties of events received by the GELF receiver
+const (
+	GELFHostProperty        = "Host"
+	GELFFullMessageProperty = "FullMessage"
+)
+
+const (
+	// maxGELFMessage bounds a GELF message after decompression
+	maxGELFMessage = 8 << 20
+	// maxGELFChunks is the most chunks a GELF message may be split into
+	maxGELFChunks = 128
+	// maxGELFPartials bounds the chunked messages being reassembled at once
+	maxGELFPartials = 1024
+	// gelfChunkTimeout is how long the chunks of a message are kept waiting for the rest
+	gelfChunkTimeout = 5 * time.Second
+)
+
+// gelfChunkMagic starts every chunk of a chunked GELF datagram
+var gelfChunkMagic = []byte{0x1e, 0x0f}
+
+// gelfMessage is the JSON payload of a GELF message. Additional fields, prefixed with
+// an underscore, are read separately.
+type gelfMessage struct {
+	Host         string   `json:"host"`
+	ShortMessage string   `json:"short_message"`
+	FullMessage  string   `json:"full_message"`
+	Timestamp    *float64 `json:"timestamp"`
+	Level        *int     `json:"level"`
+}
+
+// ServeGELF accepts GELF connections on ln, which send null-byte delimited JSON
+// messages, and logs the messages until ctx is done. Levels are syslog severities,
+// mapped as by the syslog receiver, and additional fields become properties.
+func (l *SEQLogger) ServeGELF(ctx context.Context, ln net.Listener) error {
+	stop := context.AfterFunc(ctx, func() { ln.Close() })
+	defer stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+			closeConn := context.AfterFunc(ctx, func() { conn.Close() })
+			defer closeConn()
+
+			if err := l.handleGELFStream(conn); err != nil && ctx.Err() == nil {
+				selfLogf("Failed to read GELF message from %v: %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// handleGELFStream logs the null-byte delimited messages of a GELF connection until it is closed
+func (l *SEQLogger) handleGELFStream(conn io.Reader) error {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), maxGELFMessage)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, 0); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		if err := l.logGELF(scanner.Bytes()); err != nil {
+			selfLogf("Failed to read GELF message: %v", err)
+		}
+	}
+	return scanner.Err()
+}
+
+// ServeGELFPacket logs the GELF messages received as datagrams on conn until ctx is
+// done. Datagrams may be gzip or zlib compressed and chunked.
+func (l *SEQLogger) ServeGELFPacket(ctx context.Context, conn net.PacketConn) error {
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	chunks := newGELFChunks()
+	buf := make([]byte, 65536)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		datagram := buf[:n]
+		if bytes.HasPrefix(datagram, gelfChunkMagic) {
+			if datagram = chunks.add(datagram, time.Now()); datagram == nil {
+				continue
+			}
+		}
+		if err := l.logGELFDatagram(datagram); err != nil {
+			selfLogf("Failed to read GELF message: %v", err)
+		}
+	}
+}
+
+// logGELFDatagram decompresses a complete GELF datagram and logs its message
+func (l *SEQLogger) logGELFDatagram(datagram []byte) error {
+	var r io.Reader
+	var err error
+	switch {
+	case bytes.HasPrefix(datagram, []byte{0x1f, 0x8b}):
+		r, err = gzip.NewReader(bytes.NewReader(datagram))
+	case len(datagram) > 1 && datagram[0]&0x0f == 8 && (uint16(datagram[0])<<8|uint16(datagram[1]))%31 == 0:
+		// A zlib header: deflate compression and a valid header checksum
+		r, err = zlib.NewReader(bytes.NewReader(datagram))
+	default:
+		return l.logGELF(datagram)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid compressed message: %w", err)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, maxGELFMessage+1))
+	if err != nil {
+		return fmt.Errorf("invalid compressed message: %w", err)
+	}
+	if len(data) > maxGELFMessage {
+		return fmt.Errorf("message larger than %d bytes", maxGELFMessage)
+	}
+	return l.logGELF(data)
+}
+
+// logGELF logs a GELF JSON message
+func (l *SEQLogger) logGELF(data []byte) error {
+	var m gelfMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if m.ShortMessage == "" {
+		return errors.New("missing short_message")
+	}
+	var object map[string]interface{}
+	json.Unmarshal(data, &object)
+
+	fields := make(map[string]interface{})
+	for key, value := range object {
+		// "_id" is reserved and not an additional field
+		if strings.HasPrefix(key, "_") && key != "_id" {
+			fields[key[1:]] = value
+		}
+	}
+	if m.Host != "" {
+		fields[GELFHostProperty] = m.Host
+	}
+	if m.FullMessage != "" {
+		fields[GELFFullMessageProperty] = m.FullMessage
+	}
+
+	// GELF's default level is 1, alert
+	level := LevelFatal
+	if m.Level != nil && *m.Level >= 0 && *m.Level < len(syslogLevels) {
+		level = syslogLevels[*m.Level]
+	}
+	var timestamp time.Time
+	if m.Timestamp != nil && *m.Timestamp > 0 && !math.IsInf(*m.Timestamp, 0) {
+		sec, frac := math.Modf(*m.Timestamp)
+		timestamp = time.Unix(int64(sec), int64(frac*1e9))
+	}
+
+	l.LogAt(timestamp, level, escapeTemplate(m.ShortMessage), fields)
+	return nil
+}
+
+// gelfChunks reassembles chunked GELF datagrams
+type gelfChunks struct {
+	partials map[[8]byte]*gelfPartial
+}
+
+// gelfPartial is a chunked message waiting for the rest of its chunks
+type gelfPartial struct {
+	started  time.Time
+	chunks   [][]byte
+	received int
+}
+
+func newGELFChunks() *gelfChunks {
+	return &gelfChunks{partials: make(map[[8]byte]*gelfPartial)}
+}
+
+// add stores a chunk: magic, an 8-byte message id, a sequence number and count, then
+// the payload. It returns the reassembled datagram once every chunk has arrived.
+// Messages whose chunks don't all arrive within gelfChunkTimeout are dropped.
+func (c *gelfChunks) add(chunk []byte, now time.Time) []byte {
+	if len(chunk) < 12 {
+		return nil
+	}
+	var id [8]byte
+	copy(id[:], chunk[2:10])
+	seq, count := int(chunk[10]), int(chunk[11])
+	if count == 0 || count > maxGELFChunks || seq >= count {
+		return nil
+	}
+
+	partial, ok := c.partials[id]
+	if !ok {
+		c.expire(now)
+		if len(c.partials) >= maxGELFPartials {
+			return nil
+		}
+		partial = &gelfPartial{started: now, chunks: make([][]byte, count)}
+		c.partials[id] = partial
+	}
+	if len(partial.chunks) != count || partial.chunks[seq] != nil {
+		return nil
+	}
+	// The read buffer is reused for the next datagram
+	partial.chunks[seq] = append([]byte(nil), chunk[12:]...)
+	partial.received++
+	if partial.received < count {
+		return nil
+	}
+
+	delete(c.partials, id)
+	return bytes.Join(partial.chunks, nil)
+}
+
+// expire drops the messages that have waited too long for their chunks
+func (c *gelfChunks) expire(now time.Time) {
+	for id, partial := range c.partials {
+		if now.Sub(partial.started) > gelfChunkTimeout {
+			delete(c.partials, id)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// gelfChunk builds chunk seq of count of the GELF message with the given id
+func gelfChunk(id byte, seq, count int, payload []byte) []byte {
+	return append([]byte{0x1e, 0x0f, id, 0, 0, 0, 0, 0, 0, 0, byte(seq), byte(count)}, payload...)
+}
+
+func TestGELFMessage(t *testing.T) {
+	logger := newQueueLogger(1)
+	err := logger.logGELF([]byte(`{"version": "1.1", "host": "fw01", "short_message": "Dropped {packet}",
+		"full_message": "details", "timestamp": 1704164645.25, "level": 4, "_src_ip": "10.0.0.1", "_id": "x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logMessage := <-logger.logChan
+	if logMessage.Level != LevelWarning || logMessage.MessageTemplate != "Dropped {{packet}}" || logMessage.Timestamp != "2024-01-02T03:04:05.25Z" {
+		t.Errorf("Unexpected event %+v", logMessage)
+	}
+	fields := logMessage.Fields
+	if fields[GELFHostProperty] != "fw01" || fields[GELFFullMessageProperty] != "details" || fields["src_ip"] != "10.0.0.1" {
+		t.Errorf("Unexpected properties %v", fields)
+	}
+	if _, ok := fields["id"]; ok {
+		t.Errorf("Expected the reserved _id field to be ignored, got %v", fields)
+	}
+
+	if err := logger.logGELF([]byte(`{"host": "fw01"}`)); err == nil {
+		t.Error("Expected a message without short_message to be rejected")
+	}
+}
+
+func TestGELFChunkReassembly(t *testing.T) {
+	chunks := newGELFChunks()
+	now := time.Now()
+
+	if chunks.add(gelfChunk(1, 1, 2, []byte("world")), now) != nil {
+		t.Fatal("Expected an incomplete message to wait for its chunks")
+	}
+	if got := chunks.add(gelfChunk(1, 0, 2, []byte("hello ")), now); string(got) != "hello world" {
+		t.Errorf("Expected the reassembled message, got %q", got)
+	}
+
+	chunks.add(gelfChunk(2, 0, 2, []byte("stale")), now)
+	chunks.add(gelfChunk(3, 0, 2, []byte("new")), now.Add(2*gelfChunkTimeout))
+	if _, ok := chunks.partials[[8]byte{2}]; ok {
+		t.Error("Expected the incomplete message to expire")
+	}
+	if chunks.add(gelfChunk(4, 0, maxGELFChunks+1, nil), now) != nil || chunks.add(gelfChunk(4, 3, 2, nil), now) != nil {
+		t.Error("Expected invalid sequence numbers to be ignored")
+	}
+}
+
+func TestGELFReceivers(t *testing.T) {
+	logger := newQueueLogger(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go logger.ServeGELFPacket(ctx, packetConn)
+	go logger.ServeGELF(ctx, ln)
+
+	udp, err := net.Dial("udp", packetConn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udp.Close()
+
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write([]byte(`{"short_message": "gzip", "level": 6}`))
+	gz.Close()
+	udp.Write(gzipped.Bytes())
+
+	var zlibbed bytes.Buffer
+	zw := zlib.NewWriter(&zlibbed)
+	zw.Write([]byte(`{"short_message": "chunked zlib", "level": 6}`))
+	zw.Close()
+	half := zlibbed.Len() / 2
+	udp.Write(gelfChunk(9, 0, 2, zlibbed.Bytes()[:half]))
+	udp.Write(gelfChunk(9, 1, 2, zlibbed.Bytes()[half:]))
+
+	tcp, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcp.Close()
+	tcp.Write([]byte("{\"short_message\": \"tcp one\", \"level\": 6}\x00{\"short_message\": \"tcp two\", \"level\": 6}\x00"))
+
+	want := map[string]bool{"gzip": true, "chunked zlib": true, "tcp one": true, "tcp two": true}
+	for len(want) > 0 {
+		select {
+		case logMessage := <-logger.logChan:
+			if !want[logMessage.MessageTemplate] || logMessage.Level != LevelInformation {
+				t.Fatalf("Unexpected event %+v", logMessage)
+			}
+			delete(want, logMessage.MessageTemplate)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Events %v were not logged", want)
+		}
+	}
+}
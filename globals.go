@@ -0,0 +1,93 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// globalFields holds the properties applied to every event. Updates replace the whole
+// map, so Log reads a consistent snapshot without taking a lock.
+type globalFields struct {
+	mu     sync.Mutex
+	fields atomic.Pointer[map[string]interface{}]
+}
+
+// load returns the current snapshot of the global fields; it must not be modified
+func (g *globalFields) load() map[string]interface{} {
+	if fields := g.fields.Load(); fields != nil {
+		return *fields
+	}
+	return nil
+}
+
+// update applies fn to a copy of the global fields and publishes the result for future events
+func (g *globalFields) update(fn func(globals map[string]interface{})) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	fields := copyFields(g.load())
+	if fields == nil {
+		fields = make(map[string]interface{})
+	}
+	fn(fields)
+	g.fields.Store(&fields)
+}
+
+// SetGlobalField adds or replaces a global property applied to events logged from now on,
+// e.g. a leader/follower role that changes after an election
+func (l *SEQLogger) SetGlobalField(key string, value interface{}) {
+	l = l.pipeline()
+	l.globalFields.update(func(globals map[string]interface{}) {
+		globals[key] = value
+	})
+}
+
+// RemoveGlobalField stops applying a global property to events logged from now on
+func (l *SEQLogger) RemoveGlobalField(key string) {
+	l = l.pipeline()
+	l.globalFields.update(func(globals map[string]interface{}) {
+		delete(globals, key)
+	})
+}
+
+// mergeFields returns the union of globals and fields, with fields taking precedence.
+// Neither map is modified; when one of them is empty the other is returned as is.
+func mergeFields(globals, fields map[string]interface{}) map[string]interface{} {
+	if len(globals) == 0 {
+		return fields
+	}
+	if len(fields) == 0 {
+		return globals
+	}
+
+	merged := make(map[string]interface{}, len(globals)+len(fields))
+	for key, value := range globals {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return merged
+}
+
+// copyFields returns a shallow copy of fields so later changes by the caller aren't observed
+func copyFields(fields map[string]interface{}) map[string]interface{} {
+	if fields == nil {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		copied[key] = value
+	}
+	return copied
+}
+
+// withField returns a copy of fields with key set to value, leaving fields untouched
+func withField(fields map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		copied[k] = v
+	}
+	copied[key] = value
+	return copied
+}
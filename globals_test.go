@@ -0,0 +1,63 @@
+package main
+
+import "testing"
+
+func TestGlobalFieldsAreMergedIntoEvents(t *testing.T) {
+	globals := map[string]interface{}{
+		"Application": "billing",
+		"Environment": "production",
+		"Version":     "1.0.0",
+	}
+	logger := newQueueLogger(2, WithGlobalFields(globals))
+	globals["Application"] = "changed after construction"
+
+	logger.Log("Information", "Application started", nil)
+	logger.Log("Information", "Deployed {Version}", map[string]interface{}{"Version": "2.0.0"})
+
+	started := <-logger.logChan
+	if started.Fields["Application"] != "billing" || started.Fields["Environment"] != "production" {
+		t.Errorf("Expected global fields on field-less event, got %#v", started.Fields)
+	}
+	deployed := <-logger.logChan
+	if deployed.Fields["Version"] != "2.0.0" || deployed.Fields["Application"] != "billing" {
+		t.Errorf("Expected call-site fields to take precedence over globals, got %#v", deployed.Fields)
+	}
+}
+
+func TestSetAndRemoveGlobalField(t *testing.T) {
+	logger := newQueueLogger(3, WithGlobalFields(map[string]interface{}{"Application": "billing"}))
+
+	logger.Log("Information", "Before election", nil)
+	logger.SetGlobalField("Role", "leader")
+	logger.Log("Information", "After election", nil)
+	logger.RemoveGlobalField("Role")
+	logger.RemoveGlobalField("Application")
+	logger.Log("Information", "After step down", nil)
+
+	if before := <-logger.logChan; before.Fields["Role"] != nil || before.Fields["Application"] != "billing" {
+		t.Errorf("Unexpected fields before election %#v", before.Fields)
+	}
+	if after := <-logger.logChan; after.Fields["Role"] != "leader" || after.Fields["Application"] != "billing" {
+		t.Errorf("Unexpected fields after election %#v", after.Fields)
+	}
+	if last := <-logger.logChan; len(last.Fields) != 0 {
+		t.Errorf("Expected removed global fields to be gone, got %#v", last.Fields)
+	}
+}
+
+func TestGlobalFieldsConcurrentUpdates(t *testing.T) {
+	logger := newDiscardLogger(1024)
+	defer close(logger.logChan)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			logger.SetGlobalField("Iteration", i)
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		logger.Log("Information", "Working", nil)
+	}
+	<-done
+}
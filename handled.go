@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// Properties of the events logged by Error and Recover
+const (
+	// HandledProperty is true for errors the program dealt with, logged with Error,
+	// and false for panics that reached Recover, so SEQ signals can alert on
+	// Handled = false alone
+	HandledProperty = "Handled"
+	// StackTraceProperty carries the stack of a recovered panic
+	StackTraceProperty = "StackTrace"
+)
+
+// recoverFlushTimeout bounds how long Recover waits for the panic event to be delivered
+const recoverFlushTimeout = 5 * time.Second
+
+// Error logs an Error event for an error the program handled, with err as the Error
+// property and Handled = true
+func (l *SEQLogger) Error(err error, message string, fields map[string]interface{}) {
+	handled := make(map[string]interface{}, len(fields)+2)
+	for key, value := range fields {
+		handled[key] = value
+	}
+	handled[ErrorProperty] = err
+	handled[HandledProperty] = true
+	l.Log(LevelError, message, handled)
+}
+
+// Recover, deferred at the top of main or of a goroutine, logs a panic as a Fatal
+// "Unhandled panic: {Error}" event with Handled = false and the stack, waits for it
+// to be delivered, then panics again so the program still crashes:
+//
+//	defer logger.Recover()
+func (l *SEQLogger) Recover() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	l.Log(LevelFatal, "Unhandled panic: {"+ErrorProperty+"}", map[string]interface{}{
+		ErrorProperty:      fmt.Sprint(r),
+		HandledProperty:    false,
+		StackTraceProperty: string(debug.Stack()),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), recoverFlushTimeout)
+	defer cancel()
+	if err := l.Flush(ctx); err != nil {
+		selfLogf("Failed to deliver the panic event: %v", err)
+	}
+	panic(r)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestErrorIsMarkedHandled(t *testing.T) {
+	logger := newQueueLogger(1)
+	fields := map[string]interface{}{"Order": 7}
+	logger.Error(errors.New("card declined"), "Payment for {Order} failed", fields)
+
+	logMessage := <-logger.logChan
+	if logMessage.Level != LevelError || logMessage.Fields[HandledProperty] != true || logMessage.Fields[ErrorProperty] != "card declined" {
+		t.Errorf("Unexpected event %+v", logMessage)
+	}
+	if len(fields) != 1 {
+		t.Errorf("Expected the caller's fields to be left alone, got %v", fields)
+	}
+}
+
+func TestRecoverLogsUnhandledPanic(t *testing.T) {
+	server := newSeqRecorder(t)
+	logger := newTestLogger(t, server.URL+EndpointRaw, 10)
+	defer logger.Close()
+
+	var repanicked interface{}
+	func() {
+		defer func() { repanicked = recover() }()
+		defer logger.Recover()
+		panic("nil map")
+	}()
+
+	if repanicked != "nil map" {
+		t.Errorf("Expected Recover to panic again, got %v", repanicked)
+	}
+	received := server.received()
+	for _, want := range []string{`"Handled":false`, `"Error":"nil map"`, "TestRecoverLogsUnhandledPanic", `"Level":"Fatal"`} {
+		if !strings.Contains(received, want) {
+			t.Errorf("Expected %s in the delivered event, got %s", want, received)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"runtime"
+)
+
+// Version is the version of the logger reported in its User-Agent; release builds set
+// it with -ldflags "-X main.Version=1.4.0"
+var Version = "dev"
+
+// defaultUserAgent identifies the logger and Go version to SEQ server operators,
+// e.g. seqlogger-go/1.4.0 go1.22.1
+func defaultUserAgent() string {
+	return "seqlogger-go/" + Version + " " + runtime.Version()
+}
+
+// WithUserAgent replaces the User-Agent of requests to the SEQ server, e.g. with the
+// name and version of an application embedding the logger
+func WithUserAgent(userAgent string) Option {
+	return func(l *SEQLogger) {
+		l.userAgent = userAgent
+	}
+}
+
+// WithHeader sets a header on every request to the SEQ server, e.g. a tenant id or
+// routing hint an ingress gateway requires. Headers set by the logger itself, such as
+// Content-Type and X-Seq-ApiKey, take precedence.
+func WithHeader(key, value string) Option {
+	return func(l *SEQLogger) {
+		if l.headers == nil {
+			l.headers = make(http.Header)
+		}
+		l.headers.Set(key, value)
+	}
+}
+
+// WithHeaderProvider calls fn for every request to the SEQ server and sets the
+// headers it returns, for values that change over time such as short-lived tokens.
+// They take precedence over WithHeader, like WithHeader they don't replace the
+// logger's own headers.
+func WithHeaderProvider(fn func() http.Header) Option {
+	return func(l *SEQLogger) {
+		l.headerProviders = append(l.headerProviders, fn)
+	}
+}
+
+// WithBasicAuth sends username and password with HTTP Basic authentication on every
+// request to the SEQ server, in addition to the API key, for a reverse proxy requiring it
+func WithBasicAuth(username, password string) Option {
+	return func(l *SEQLogger) {
+		l.basicAuth = &basicAuth{username: username, password: password}
+	}
+}
+
+// basicAuth holds the credentials of WithBasicAuth
+type basicAuth struct {
+	username, password string
+}
+
+// setHeaders sets the User-Agent, the configured headers, then the credentials, on a
+// request to the SEQ server
+func (l *SEQLogger) setHeaders(req *http.Request) {
+	userAgent := l.userAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent()
+	}
+	req.Header.Set("User-Agent", userAgent)
+	for key, values := range l.headers {
+		req.Header[key] = values
+	}
+	for _, provider := range l.headerProviders {
+		for key, values := range provider() {
+			req.Header[http.CanonicalHeaderKey(key)] = values
+		}
+	}
+	if l.basicAuth != nil {
+		req.SetBasicAuth(l.basicAuth.username, l.basicAuth.password)
+	}
+	if l.apiKey != "" {
+		req.Header.Set("X-Seq-ApiKey", l.apiKey)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestCustomHeadersOnIngestionRequests(t *testing.T) {
+	var mu sync.Mutex
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = r.Header.Clone()
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	logger := newSenderLogger(server.URL,
+		WithHeader("X-Tenant", "acme"),
+		WithHeader("Content-Type", "text/plain"),
+		WithHeaderProvider(func() http.Header { return http.Header{"x-route": {"eu-west"}} }),
+	)
+	if err := logger.deliver(server.Client(), benchmarkBatch(1)); err != nil {
+		t.Fatalf("Failed to deliver: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if received.Get("X-Tenant") != "acme" || received.Get("X-Route") != "eu-west" {
+		t.Errorf("Expected the custom headers, got %v", received)
+	}
+	if received.Get("Content-Type") != logger.encoder.ContentType() {
+		t.Errorf("Expected the logger's Content-Type to win, got %q", received.Get("Content-Type"))
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	agents := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents <- r.Header.Get("User-Agent")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	if err := newSenderLogger(server.URL).deliver(server.Client(), benchmarkBatch(1)); err != nil {
+		t.Fatalf("Failed to deliver: %v", err)
+	}
+	if agent := <-agents; agent != "seqlogger-go/"+Version+" "+runtime.Version() {
+		t.Errorf("Unexpected default User-Agent %q", agent)
+	}
+
+	if err := newSenderLogger(server.URL, WithUserAgent("shop/2.1")).deliver(server.Client(), benchmarkBatch(1)); err != nil {
+		t.Fatalf("Failed to deliver: %v", err)
+	}
+	if agent := <-agents; agent != "shop/2.1" {
+		t.Errorf("Expected the overridden User-Agent, got %q", agent)
+	}
+}
+
+func TestBasicAuthWithAPIKey(t *testing.T) {
+	type credentials struct{ username, password, apiKey string }
+	received := make(chan credentials, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		received <- credentials{username, password, r.Header.Get("X-Seq-ApiKey")}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	logger := newSenderLogger(server.URL, WithBasicAuth("shipper", "s3cret"))
+	logger.apiKey = "key"
+	if err := logger.deliver(server.Client(), benchmarkBatch(1)); err != nil {
+		t.Fatalf("Failed to deliver: %v", err)
+	}
+	if got := <-received; got != (credentials{"shipper", "s3cret", "key"}) {
+		t.Errorf("Unexpected credentials %+v", got)
+	}
+}
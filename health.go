@@ -0,0 +1,46 @@
+package main
+
+import "time"
+
+// defaultHealthInterval is the minimum time between two health events
+const defaultHealthInterval = time.Minute
+
+// healthSourceContext is the SourceContext of the events the logger reports about itself
+const healthSourceContext = "SEQLogger"
+
+// healthMonitor decides when the processing goroutine reports the logger's own health
+// to Seq: when the queue is fuller than threshold or events have been dropped since
+// the last report, at most once per interval
+type healthMonitor struct {
+	threshold     float64
+	interval      time.Duration
+	lastReport    time.Time
+	reportedDrops uint64
+}
+
+// healthEvent returns a Warning about the queue depth and dropped events when one
+// is due; it is called by the processing goroutine with the depth seen on receive
+func (l *SEQLogger) healthEvent(depth int, now time.Time) (LogMessage, bool) {
+	h := l.health
+	if h == nil || now.Sub(h.lastReport) < h.interval {
+		return LogMessage{}, false
+	}
+
+	capacity := cap(l.currentQueue())
+	dropped := l.dropped.Load()
+	if dropped == h.reportedDrops && float64(depth) < h.threshold*float64(capacity) {
+		return LogMessage{}, false
+	}
+
+	logMessage := l.newLogMessage(LevelWarning,
+		"Logger queue holds {QueueDepth} of {QueueCapacity} events, {DroppedEvents} events dropped since the last report",
+		map[string]interface{}{
+			"QueueDepth":          depth,
+			"QueueCapacity":       capacity,
+			"DroppedEvents":       dropped - h.reportedDrops,
+			SourceContextProperty: healthSourceContext,
+		})
+	h.lastReport = now
+	h.reportedDrops = dropped
+	return logMessage, true
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHealthEventOnHighWatermark(t *testing.T) {
+	logger := newQueueLogger(10, WithHealthEvents(0.8, time.Minute))
+	now := time.Now()
+
+	if _, ok := logger.healthEvent(7, now); ok {
+		t.Fatal("Expected no health event below the threshold")
+	}
+	event, ok := logger.healthEvent(8, now)
+	if !ok {
+		t.Fatal("Expected a health event at the threshold")
+	}
+	if event.Level != LevelWarning || event.Fields["QueueDepth"] != 8 || event.Fields["QueueCapacity"] != 10 {
+		t.Errorf("Unexpected health event %+v", event)
+	}
+	if event.Fields[SourceContextProperty] != healthSourceContext {
+		t.Errorf("Expected SourceContext %q, got %v", healthSourceContext, event.Fields[SourceContextProperty])
+	}
+
+	if _, ok := logger.healthEvent(10, now.Add(30*time.Second)); ok {
+		t.Error("Expected health events to be rate-limited")
+	}
+	if _, ok := logger.healthEvent(10, now.Add(time.Minute)); !ok {
+		t.Error("Expected another health event once the interval has passed")
+	}
+}
+
+func TestHealthEventReportsDrops(t *testing.T) {
+	logger := newQueueLogger(10, WithHealthEvents(0.8, time.Second))
+	now := time.Now()
+
+	logger.dropped.Add(3)
+	event, ok := logger.healthEvent(0, now)
+	if !ok || event.Fields["DroppedEvents"] != uint64(3) {
+		t.Fatalf("Expected a health event reporting 3 drops, got %+v", event)
+	}
+	if _, ok := logger.healthEvent(0, now.Add(time.Second)); ok {
+		t.Error("Expected no health event without new drops")
+	}
+}
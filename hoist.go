@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ProxyHoistingEncoder writes batches in SEQ's raw events format but moves the
+// properties every event shares with the same value, such as host, application and
+// version, into a single top-level "Properties" object sent once per request:
+//
+//	{"Properties":{"Application":"shop"},"Events":[...]}
+//
+// It is only for a logger or sink sending to an IngestionHandler, which merges them
+// back into each event. SEQ itself ignores batch-level properties, so with it as the
+// encoder of a logger sending to SEQ every hoisted property is lost.
+type ProxyHoistingEncoder struct {
+	RawEncoder
+}
+
+// Encode writes batch with its shared properties hoisted
+func (e ProxyHoistingEncoder) Encode(w io.Writer, batch []LogMessage) error {
+	shared := sharedProperties(batch)
+	if len(shared) == 0 {
+		return e.RawEncoder.Encode(w, batch)
+	}
+
+	events := make([]LogMessage, len(batch))
+	for i, logMessage := range batch {
+		fields := make(map[string]interface{}, len(logMessage.Fields)-len(shared))
+		for key, value := range logMessage.Fields {
+			if _, ok := shared[key]; !ok {
+				fields[key] = value
+			}
+		}
+		logMessage.Fields = fields
+		events[i] = logMessage
+	}
+
+	raw := getEncodeBuffer()
+	defer putEncodeBuffer(raw)
+	if err := e.RawEncoder.Encode(raw, events); err != nil {
+		return err
+	}
+	properties, err := json.Marshal(shared)
+	if err != nil {
+		return err
+	}
+
+	// Splice the properties in front of the raw format's "Events" array
+	if _, err := io.WriteString(w, `{"Properties":`); err != nil {
+		return err
+	}
+	if _, err := w.Write(properties); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, ","); err != nil {
+		return err
+	}
+	_, err = w.Write(raw.Bytes()[1:])
+	return err
+}
+
+// sharedProperties returns the properties present with the same scalar value in every
+// event of a batch of two or more; structured values are never hoisted
+func sharedProperties(batch []LogMessage) map[string]interface{} {
+	if len(batch) < 2 {
+		return nil
+	}
+
+	var shared map[string]interface{}
+	for key, value := range batch[0].Fields {
+		if !hoistable(value) {
+			continue
+		}
+		same := true
+		for i := 1; i < len(batch) && same; i++ {
+			other, ok := batch[i].Fields[key]
+			same = ok && hoistable(other) && other == value
+		}
+		if same {
+			if shared == nil {
+				shared = make(map[string]interface{})
+			}
+			shared[key] = value
+		}
+	}
+	return shared
+}
+
+// hoistable reports whether value is a scalar that can be compared with ==
+func hoistable(value interface{}) bool {
+	switch value.(type) {
+	case string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+		return true
+	}
+	return false
+}
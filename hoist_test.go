@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestProxyHoistingEncoderHoistsSharedProperties(t *testing.T) {
+	batch := []LogMessage{
+		{Timestamp: "2024-01-02T03:04:05Z", Level: LevelInformation, MessageTemplate: "Order {OrderId} placed",
+			Fields: map[string]interface{}{"Application": "shop", "Version": "1.2", "OrderId": 1}},
+		{Timestamp: "2024-01-02T03:04:06Z", Level: LevelInformation, MessageTemplate: "Order {OrderId} placed",
+			Fields: map[string]interface{}{"Application": "shop", "Version": "1.3", "OrderId": 2}},
+	}
+
+	var out bytes.Buffer
+	if err := (ProxyHoistingEncoder{}).Encode(&out, batch); err != nil {
+		t.Fatal(err)
+	}
+
+	var payload struct {
+		Properties map[string]interface{}
+		Events     []struct{ Properties map[string]interface{} }
+	}
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("Encoded batch is not valid JSON: %v\n%s", err, out.String())
+	}
+	if len(payload.Properties) != 1 || payload.Properties["Application"] != "shop" {
+		t.Errorf("Expected only Application to be hoisted, got %v", payload.Properties)
+	}
+	if len(payload.Events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(payload.Events))
+	}
+	if e := payload.Events[1].Properties; e["Application"] != nil || e["Version"] != "1.3" || e["OrderId"] != float64(2) {
+		t.Errorf("Unexpected per-event properties %v", e)
+	}
+	if batch[0].Fields["Application"] != "shop" {
+		t.Error("Expected the batch itself to be left untouched")
+	}
+}
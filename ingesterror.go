@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+)
+
+// IngestionError is a rejection explained by the JSON error body SEQ returns, such as an
+// invalid payload, a level the API key may not ingest or a payload that is too large
+type IngestionError struct {
+	StatusCode int
+	// Message is SEQ's explanation of the rejection
+	Message string
+	// Line is the 1-based line of the offending event in a CLEF payload, 0 when the
+	// rejection isn't about a single event
+	Line int
+}
+
+func (e *IngestionError) Error() string {
+	return fmt.Sprintf("SEQ server rejected the batch with %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// ingestionLinePattern finds the line number SEQ quotes when a single event is malformed
+var ingestionLinePattern = regexp.MustCompile(`(?i)\bline\s+(\d+)`)
+
+// parseIngestionError reads SEQ's {"Error": "..."} response body; it returns nil for
+// bodies that aren't in that form
+func parseIngestionError(statusCode int, body []byte) *IngestionError {
+	var response struct {
+		Error string `json:"Error"`
+	}
+	if json.Unmarshal(body, &response) != nil || response.Error == "" {
+		return nil
+	}
+
+	e := &IngestionError{StatusCode: statusCode, Message: response.Error}
+	if statusCode == http.StatusBadRequest {
+		if match := ingestionLinePattern.FindStringSubmatch(response.Error); match != nil {
+			e.Line, _ = strconv.Atoi(match[1])
+		}
+	}
+	return e
+}
+
+// malformedEvent returns the index in a batch of n events of the single event SEQ
+// rejected, when err names one. Only CLEF payloads, with one event per line, map
+// line numbers to events.
+func (l *SEQLogger) malformedEvent(err error, n int) (int, bool) {
+	var ingestionErr *IngestionError
+	if !errors.As(err, &ingestionErr) || ingestionErr.Line < 1 || ingestionErr.Line > n || n < 2 {
+		return 0, false
+	}
+	if _, ok := l.encoder.(CLEFEncoder); !ok {
+		return 0, false
+	}
+	return ingestionErr.Line - 1, true
+}
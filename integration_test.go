@@ -0,0 +1,183 @@
+//go:build integration
+
+// Integration tests run the logger against a real Seq server started with
+// testcontainers. They require a working Docker daemon and are opt-in:
+//
+//	go test -tags integration -run Integration ./...
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// seqImage is the container image used for integration tests
+const seqImage = "datalust/seq:latest"
+
+// seqEvent is the subset of Seq's query API event representation verified by the tests
+type seqEvent struct {
+	Timestamp             string `json:"Timestamp"`
+	Level                 string `json:"Level"`
+	MessageTemplateTokens []struct {
+		Text         string `json:"Text"`
+		PropertyName string `json:"PropertyName"`
+	} `json:"MessageTemplateTokens"`
+	Properties []struct {
+		Name  string      `json:"Name"`
+		Value interface{} `json:"Value"`
+	} `json:"Properties"`
+}
+
+// property returns the value of the named property and whether it was present
+func (e seqEvent) property(name string) (interface{}, bool) {
+	for _, p := range e.Properties {
+		if p.Name == name {
+			return p.Value, true
+		}
+	}
+	return nil, false
+}
+
+// template reassembles the message template from its tokens
+func (e seqEvent) template() string {
+	var s string
+	for _, t := range e.MessageTemplateTokens {
+		if t.PropertyName != "" {
+			s += "{" + t.PropertyName + "}"
+			continue
+		}
+		s += t.Text
+	}
+	return s
+}
+
+// startSeq starts a Seq container and returns its base URL
+func startSeq(t *testing.T) string {
+	t.Helper()
+
+	ctx := context.Background()
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        seqImage,
+			ExposedPorts: []string{"80/tcp"},
+			Env: map[string]string{
+				"ACCEPT_EULA":                   "Y",
+				"SEQ_FIRSTRUN_NOAUTHENTICATION": "true",
+			},
+			WaitingFor: wait.ForHTTP("/api").WithPort("80/tcp").WithStartupTimeout(2 * time.Minute),
+		},
+		Started: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to start Seq container: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := container.Terminate(context.Background()); err != nil {
+			t.Logf("Failed to terminate Seq container: %v", err)
+		}
+	})
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get Seq container host: %v", err)
+	}
+	port, err := container.MappedPort(ctx, "80/tcp")
+	if err != nil {
+		t.Fatalf("Failed to get Seq container port: %v", err)
+	}
+
+	return fmt.Sprintf("http://%s:%s", host, port.Port())
+}
+
+// queryEvents polls Seq's query API until at least want events match filter or the timeout elapses
+func queryEvents(t *testing.T, baseURL, filter string, want int) []seqEvent {
+	t.Helper()
+
+	query := url.Values{}
+	query.Set("filter", filter)
+	query.Set("count", "100")
+	endpoint := baseURL + "/api/events?" + query.Encode()
+
+	var events []seqEvent
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		req, err := http.NewRequest("GET", endpoint, nil)
+		if err != nil {
+			t.Fatalf("Failed to create query request: %v", err)
+		}
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			events = nil
+			err = json.NewDecoder(resp.Body).Decode(&events)
+			resp.Body.Close()
+			if err == nil && len(events) >= want {
+				return events
+			}
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	t.Fatalf("Expected %d events matching %q, found %d", want, filter, len(events))
+	return nil
+}
+
+func TestIntegrationIngestsEvents(t *testing.T) {
+	baseURL := startSeq(t)
+	runID := fmt.Sprintf("run-%d", time.Now().UnixNano())
+
+	logger := newTestLogger(t, baseURL+"/api/events/raw", 10)
+	logger.Log("Information", "Application started", map[string]interface{}{
+		"RunId":   runID,
+		"version": "1.0.0",
+	})
+	logger.Log("Error", "An error occurred", map[string]interface{}{
+		"RunId":  runID,
+		"userID": "12345",
+		"details": map[string]interface{}{
+			"module": "user-service",
+		},
+	})
+
+	events := queryEvents(t, baseURL, fmt.Sprintf("RunId = '%s'", runID), 2)
+
+	byTemplate := make(map[string]seqEvent, len(events))
+	for _, e := range events {
+		byTemplate[e.template()] = e
+	}
+
+	started, ok := byTemplate["Application started"]
+	if !ok {
+		t.Fatalf("Event %q not found in %+v", "Application started", events)
+	}
+	if started.Level != "Information" {
+		t.Errorf("Expected level Information, got %q", started.Level)
+	}
+	if v, _ := started.property("version"); v != "1.0.0" {
+		t.Errorf("Expected version property 1.0.0, got %v", v)
+	}
+
+	failed, ok := byTemplate["An error occurred"]
+	if !ok {
+		t.Fatalf("Event %q not found in %+v", "An error occurred", events)
+	}
+	if failed.Level != "Error" {
+		t.Errorf("Expected level Error, got %q", failed.Level)
+	}
+	details, _ := failed.property("details")
+	if d, ok := details.(map[string]interface{}); !ok || d["module"] != "user-service" {
+		t.Errorf("Expected nested details property, got %v", details)
+	}
+	if _, err := time.Parse(time.RFC3339, started.Timestamp); err != nil {
+		t.Errorf("Expected RFC3339 timestamp, got %q: %v", started.Timestamp, err)
+	}
+}
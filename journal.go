@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// journalCursorInterval is how often a JournalReader saves its cursor while entries arrive
+const journalCursorInterval = time.Second
+
+// JournalReader ships systemd journal entries, read with journalctl so no cgo or
+// systemd library is needed. PRIORITY maps to levels as syslog severities do, MESSAGE
+// becomes the message and the other fields properties named in Pascal case, e.g.
+// _SYSTEMD_UNIT becomes SystemdUnit. The cursor of the last entry queued is saved in
+// a file, so a restarted reader resumes after it; the first run ships new entries only.
+type JournalReader struct {
+	logger  *SEQLogger
+	cursor  string   // path of the cursor file, empty to start from new entries on every run
+	matches []string // journalctl matches, such as _SYSTEMD_UNIT=nginx.service
+	command string   // the journalctl executable
+}
+
+// NewJournalReader creates a JournalReader shipping the entries matching matches, all
+// of them when there are none, through logger and keeping its cursor in cursor
+func NewJournalReader(logger *SEQLogger, cursor string, matches ...string) *JournalReader {
+	return &JournalReader{logger: logger, cursor: cursor, matches: matches, command: "journalctl"}
+}
+
+// Run follows the journal until ctx is done or journalctl fails
+func (j *JournalReader) Run(ctx context.Context) error {
+	args, err := j.args()
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, j.command, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("Failed to start %s: %w", j.command, err)
+	}
+
+	readErr := j.read(stdout)
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		return nil
+	}
+	if readErr != nil {
+		return readErr
+	}
+	if err != nil {
+		return fmt.Errorf("%s failed: %w: %s", j.command, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// args returns the journalctl arguments following the journal after the saved cursor
+func (j *JournalReader) args() ([]string, error) {
+	args := []string{"--output=json", "--follow", "--no-pager"}
+	cursor, err := j.loadCursor()
+	if err != nil {
+		return nil, err
+	}
+	if cursor != "" {
+		args = append(args, "--after-cursor="+cursor)
+	} else {
+		args = append(args, "--lines=0")
+	}
+	return append(args, j.matches...), nil
+}
+
+// read logs the JSON entries of r, saving the cursor as they are queued
+func (j *JournalReader) read(r io.Reader) error {
+	var cursor string
+	lastSaved := time.Now()
+	err := scanLines(r, func(line int, text []byte) {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(text, &entry); err != nil {
+			selfLogf("Skipping unreadable journal entry: %v", err)
+			return
+		}
+		if c := j.logEntry(entry); c != "" {
+			cursor = c
+		}
+		if now := time.Now(); now.Sub(lastSaved) >= journalCursorInterval {
+			j.saveCursor(cursor)
+			lastSaved = now
+		}
+	})
+	j.saveCursor(cursor)
+	return err
+}
+
+// logEntry logs a journal entry and returns its cursor
+func (j *JournalReader) logEntry(entry map[string]interface{}) string {
+	cursor, _ := entry["__CURSOR"].(string)
+
+	var timestamp time.Time
+	if s, ok := entry["__REALTIME_TIMESTAMP"].(string); ok {
+		if micros, err := strconv.ParseInt(s, 10, 64); err == nil {
+			timestamp = time.UnixMicro(micros)
+		}
+	}
+	level := LevelInformation
+	if s, ok := entry["PRIORITY"].(string); ok {
+		if priority, err := strconv.Atoi(s); err == nil && priority >= 0 && priority < len(syslogLevels) {
+			level = syslogLevels[priority]
+		}
+	}
+	message, _ := journalValue(entry["MESSAGE"]).(string)
+
+	fields := make(map[string]interface{}, len(entry))
+	for key, value := range entry {
+		// Double underscores mark the journal's own addressing fields
+		if strings.HasPrefix(key, "__") || key == "MESSAGE" || key == "PRIORITY" {
+			continue
+		}
+		fields[journalPropertyName(key)] = journalValue(value)
+	}
+
+	j.logger.LogAt(timestamp, level, escapeTemplate(message), fields)
+	return cursor
+}
+
+// journalValue converts the binary values journalctl writes as arrays of bytes to
+// strings when they are valid UTF-8; fields with several values are arrays of those
+func journalValue(value interface{}) interface{} {
+	array, ok := value.([]interface{})
+	if !ok {
+		return value
+	}
+	data := make([]byte, 0, len(array))
+	for _, v := range array {
+		b, ok := v.(float64)
+		if !ok || b < 0 || b > 255 {
+			// Not a byte array: a field with several values
+			for i := range array {
+				array[i] = journalValue(array[i])
+			}
+			return array
+		}
+		data = append(data, byte(b))
+	}
+	if !utf8.Valid(data) {
+		return value
+	}
+	return string(data)
+}
+
+// journalPropertyName turns a journal field name such as _SYSTEMD_UNIT into SystemdUnit
+func journalPropertyName(field string) string {
+	var b strings.Builder
+	for _, word := range strings.Split(strings.TrimLeft(field, "_"), "_") {
+		if word == "" {
+			continue
+		}
+		b.WriteString(word[:1])
+		b.WriteString(strings.ToLower(word[1:]))
+	}
+	if b.Len() == 0 {
+		return field
+	}
+	return b.String()
+}
+
+// loadCursor reads the cursor saved by a previous run, "" if there is none
+func (j *JournalReader) loadCursor() (string, error) {
+	if j.cursor == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(j.cursor)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	return strings.TrimSpace(string(data)), err
+}
+
+// saveCursor replaces the cursor file atomically
+func (j *JournalReader) saveCursor(cursor string) {
+	if j.cursor == "" || cursor == "" {
+		return
+	}
+	tmp := j.cursor + ".tmp"
+	if err := os.WriteFile(tmp, []byte(cursor+"\n"), 0o644); err != nil {
+		selfLogf("Failed to write journal cursor: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, j.cursor); err != nil {
+		selfLogf("Failed to write journal cursor: %v", err)
+	}
+}
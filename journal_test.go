@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJournalReaderMapsEntries(t *testing.T) {
+	cursor := filepath.Join(t.TempDir(), "journal.cursor")
+	logger := newQueueLogger(10)
+	reader := NewJournalReader(logger, cursor)
+
+	entries := `{"__CURSOR":"s=1","__REALTIME_TIMESTAMP":"1704067200000000","PRIORITY":"3","MESSAGE":"disk {sda} failing","_SYSTEMD_UNIT":"smartd.service","_PID":"42"}
+not json
+{"__CURSOR":"s=2","MESSAGE":[104,105],"CODE_LINE":["1","2"]}
+`
+	if err := reader.read(strings.NewReader(entries)); err != nil {
+		t.Fatal(err)
+	}
+
+	logMessage := <-logger.logChan
+	if logMessage.MessageTemplate != "disk {{sda}} failing" || logMessage.Level != LevelError {
+		t.Errorf("Unexpected event %+v", logMessage)
+	}
+	if logMessage.Timestamp != "2024-01-01T00:00:00Z" {
+		t.Errorf("Expected the realtime timestamp, got %q", logMessage.Timestamp)
+	}
+	if logMessage.Fields["SystemdUnit"] != "smartd.service" || logMessage.Fields["Pid"] != "42" {
+		t.Errorf("Expected Pascal case properties, got %v", logMessage.Fields)
+	}
+	if _, ok := logMessage.Fields["Cursor"]; ok {
+		t.Errorf("Expected the journal's own fields to be left out, got %v", logMessage.Fields)
+	}
+
+	logMessage = <-logger.logChan
+	if logMessage.MessageTemplate != "hi" || logMessage.Level != LevelInformation {
+		t.Errorf("Expected the binary message to be decoded, got %+v", logMessage)
+	}
+	if got := logMessage.Fields["CodeLine"]; !reflect.DeepEqual(got, []interface{}{"1", "2"}) {
+		t.Errorf("Expected a field with several values to stay an array, got %#v", got)
+	}
+
+	if data, err := os.ReadFile(cursor); err != nil || string(data) != "s=2\n" {
+		t.Errorf("Expected the last cursor to be saved, got %q, %v", data, err)
+	}
+}
+
+func TestJournalReaderResumesAfterCursor(t *testing.T) {
+	cursor := filepath.Join(t.TempDir(), "journal.cursor")
+	reader := NewJournalReader(newQueueLogger(1), cursor, "_SYSTEMD_UNIT=nginx.service")
+
+	args, err := reader.args()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"--output=json", "--follow", "--no-pager", "--lines=0", "_SYSTEMD_UNIT=nginx.service"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("Expected the first run to start at new entries, got %v", args)
+	}
+
+	reader.saveCursor("s=abc;i=12")
+	args, err = reader.args()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args[3] != "--after-cursor=s=abc;i=12" {
+		t.Errorf("Expected the saved cursor to be resumed after, got %v", args)
+	}
+}
+
+func TestJournalReaderRunReportsFailures(t *testing.T) {
+	dir := t.TempDir()
+	command := filepath.Join(dir, "journalctl")
+	script := "#!/bin/sh\necho '{\"__CURSOR\":\"c1\",\"MESSAGE\":\"hello\"}'\necho 'no journal' >&2\nexit 1\n"
+	if err := os.WriteFile(command, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := newQueueLogger(10)
+	reader := NewJournalReader(logger, filepath.Join(dir, "journal.cursor"))
+	reader.command = command
+	err := reader.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "no journal") {
+		t.Errorf("Expected journalctl's error output, got %v", err)
+	}
+	if got := drainTemplates(logger); !reflect.DeepEqual(got, []string{"hello"}) {
+		t.Errorf("Expected the entries read before the failure, got %v", got)
+	}
+}
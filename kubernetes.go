@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Properties of events collected by a KubernetesCollector
+const (
+	KubernetesNamespaceProperty   = "KubernetesNamespace"
+	KubernetesPodProperty         = "KubernetesPod"
+	KubernetesContainerProperty   = "KubernetesContainer"
+	KubernetesContainerIDProperty = "KubernetesContainerId"
+	KubernetesNodeProperty        = "KubernetesNode"
+	KubernetesLabelsProperty      = "KubernetesLabels"
+	KubernetesStreamProperty      = "Stream"
+)
+
+const (
+	// DefaultContainerLogs matches the container log files the kubelet links on every node
+	DefaultContainerLogs = "/var/log/containers/*.log"
+	// serviceAccountDir holds the credentials Kubernetes mounts into a pod
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	// kubeletRefreshInterval is how often the pods are read from the kubelet
+	kubeletRefreshInterval = 30 * time.Second
+	// kubeletRetryInterval is the least time between reads for a pod that isn't known yet
+	kubeletRetryInterval = 5 * time.Second
+	// kubeletTimeout bounds a request to the kubelet
+	kubeletTimeout = 5 * time.Second
+)
+
+// KubernetesCollector ships the logs of the containers running on a node, from the
+// files the kubelet keeps under /var/log/containers, so it can run as a DaemonSet in
+// place of a log forwarder. Lines in the CRI format and Docker's json-file format are
+// both read, lines the runtime split are joined again, and JSON logged by the
+// containers is mapped like the pipe subcommand maps it. Events carry the pod,
+// namespace and container named by the file and, with a kubelet, the pod's labels.
+type KubernetesCollector struct {
+	tailer  *FileTailer
+	kubelet *kubeletClient // nil to collect without the pods' labels
+	mapping pipeMapping
+
+	partial map[string]*splitLine // by file path
+}
+
+// splitLine is the start of a line split by a container runtime
+type splitLine struct {
+	timestamp time.Time // of the first part
+	content   []byte
+}
+
+// NewKubernetesCollector creates a KubernetesCollector shipping the container logs
+// matching patterns, DefaultContainerLogs when there are none, through logger and
+// keeping its offsets in checkpoint
+func NewKubernetesCollector(logger *SEQLogger, checkpoint string, patterns ...string) *KubernetesCollector {
+	if len(patterns) == 0 {
+		patterns = []string{DefaultContainerLogs}
+	}
+	c := &KubernetesCollector{
+		tailer:  NewFileTailer(logger, checkpoint, patterns...),
+		mapping: defaultPipeMapping(),
+		partial: make(map[string]*splitLine),
+	}
+	c.tailer.parse = c.parseLine
+	return c
+}
+
+// UseKubelet adds the labels of the pods served by the kubelet at url, such as
+// https://$NODE_NAME:10250, authenticating with the pod's service account. The
+// kubelet's certificate is checked against the service account's CA, unless insecure
+// is set, as is needed where kubelets serve self-signed certificates.
+func (c *KubernetesCollector) UseKubelet(url string, insecure bool) error {
+	kubelet, err := newKubeletClient(url, serviceAccountDir, insecure)
+	if err != nil {
+		return err
+	}
+	c.kubelet = kubelet
+	return nil
+}
+
+// Run collects the container logs until ctx is done
+func (c *KubernetesCollector) Run(ctx context.Context) error {
+	return c.tailer.Run(ctx)
+}
+
+// parseLine turns a line of a container log file into an event
+func (c *KubernetesCollector) parseLine(path string, line []byte) (ingestEvent, bool) {
+	timestamp, stream, content, complete, err := parseContainerLine(line)
+	if err != nil {
+		// Not written by a container runtime; ship the line as it is
+		return c.mapping.event(line), true
+	}
+	if partial, ok := c.partial[path]; ok {
+		partial.content = append(partial.content, content...)
+		timestamp, content = partial.timestamp, partial.content
+	}
+	if !complete && len(content) < maxTailLine {
+		if _, ok := c.partial[path]; !ok {
+			c.partial[path] = &splitLine{timestamp: timestamp, content: append([]byte(nil), content...)}
+		}
+		return ingestEvent{}, false
+	}
+	delete(c.partial, path)
+
+	event := c.mapping.event(content)
+	if event.timestamp.IsZero() {
+		event.timestamp = timestamp
+	}
+	if stream != "" {
+		event.fields = withField(event.fields, KubernetesStreamProperty, stream)
+	}
+	if pod, namespace, container, id, ok := parseContainerLogName(path); ok {
+		event.fields = withField(event.fields, KubernetesNamespaceProperty, namespace)
+		event.fields = withField(event.fields, KubernetesPodProperty, pod)
+		event.fields = withField(event.fields, KubernetesContainerProperty, container)
+		event.fields = withField(event.fields, KubernetesContainerIDProperty, id)
+		if c.kubelet != nil {
+			if info, ok := c.kubelet.pod(namespace, pod); ok {
+				if info.node != "" {
+					event.fields = withField(event.fields, KubernetesNodeProperty, info.node)
+				}
+				if len(info.labels) > 0 {
+					event.fields = withField(event.fields, KubernetesLabelsProperty, info.labels)
+				}
+			}
+		}
+	}
+	return event, true
+}
+
+// parseContainerLine reads a line of the CRI format, "TIMESTAMP STREAM P|F CONTENT",
+// or Docker's json-file format, {"log": ..., "stream": ..., "time": ...}. complete is
+// false for the parts of a line split by the runtime, but the last.
+func parseContainerLine(line []byte) (timestamp time.Time, stream string, content []byte, complete bool, err error) {
+	if bytes.HasPrefix(line, []byte("{")) {
+		var entry struct {
+			Log    *string `json:"log"`
+			Stream string  `json:"stream"`
+			Time   string  `json:"time"`
+		}
+		if err := json.Unmarshal(line, &entry); err != nil || entry.Log == nil {
+			return time.Time{}, "", nil, false, errors.New("not a json-file line")
+		}
+		timestamp, _ = time.Parse(time.RFC3339Nano, entry.Time)
+		// Docker ends every line with a newline, except the parts of a split one
+		content, complete = bytes.CutSuffix([]byte(*entry.Log), []byte("\n"))
+		return timestamp, entry.Stream, content, complete, nil
+	}
+
+	fields := bytes.SplitN(line, []byte(" "), 4)
+	if len(fields) < 3 {
+		return time.Time{}, "", nil, false, errors.New("not a CRI line")
+	}
+	timestamp, err = time.Parse(time.RFC3339Nano, string(fields[0]))
+	if err != nil {
+		return time.Time{}, "", nil, false, errors.New("not a CRI line")
+	}
+	if len(fields) == 4 {
+		content = fields[3]
+	}
+	switch string(fields[2]) {
+	case "F":
+		complete = true
+	case "P":
+	default:
+		return time.Time{}, "", nil, false, errors.New("not a CRI line")
+	}
+	return timestamp, string(fields[1]), content, complete, nil
+}
+
+// parseContainerLogName reads the pod, namespace, container and container id from the
+// name of a file under /var/log/containers, POD_NAMESPACE_CONTAINER-ID.log
+func parseContainerLogName(path string) (pod, namespace, container, id string, ok bool) {
+	parts := strings.Split(strings.TrimSuffix(filepath.Base(path), ".log"), "_")
+	if len(parts) != 3 {
+		return "", "", "", "", false
+	}
+	i := strings.LastIndexByte(parts[2], '-')
+	if i <= 0 {
+		return "", "", "", "", false
+	}
+	return parts[0], parts[1], parts[2][:i], parts[2][i+1:], true
+}
+
+// kubeletClient caches the pods read from a kubelet's /pods endpoint
+type kubeletClient struct {
+	url    string
+	token  string
+	client *http.Client
+
+	mu      sync.Mutex
+	pods    map[string]kubePod // by namespace/name
+	fetched time.Time
+}
+
+// kubePod is what events are enriched with from a pod
+type kubePod struct {
+	node   string
+	labels map[string]string
+}
+
+// newKubeletClient creates a client for the kubelet at url with the service account
+// credentials in dir, if there are any
+func newKubeletClient(url, dir string, insecure bool) (*kubeletClient, error) {
+	k := &kubeletClient{url: strings.TrimSuffix(url, "/")}
+	if token, err := os.ReadFile(filepath.Join(dir, "token")); err == nil {
+		k.token = strings.TrimSpace(string(token))
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: insecure}
+	if ca, err := os.ReadFile(filepath.Join(dir, "ca.crt")); err == nil && !insecure {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("Invalid service account CA %s", filepath.Join(dir, "ca.crt"))
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	k.client = &http.Client{Transport: transport, Timeout: kubeletTimeout}
+	return k, nil
+}
+
+// pod returns the pod namespace/name, reading the pods again when they are stale or,
+// at most once per kubeletRetryInterval, when the pod is new
+func (k *kubeletClient) pod(namespace, name string) (kubePod, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	key := namespace + "/" + name
+	pod, ok := k.pods[key]
+	if since := time.Since(k.fetched); since > kubeletRefreshInterval || (!ok && since > kubeletRetryInterval) {
+		k.fetched = time.Now()
+		if pods, err := k.fetch(); err != nil {
+			selfLogf("Failed to read pods from the kubelet: %v", err)
+		} else {
+			k.pods = pods
+			pod, ok = k.pods[key]
+		}
+	}
+	return pod, ok
+}
+
+// fetch reads the pods running on the kubelet's node
+func (k *kubeletClient) fetch() (map[string]kubePod, error) {
+	req, err := http.NewRequest(http.MethodGet, k.url+"/pods", nil)
+	if err != nil {
+		return nil, err
+	}
+	if k.token != "" {
+		req.Header.Set("Authorization", "Bearer "+k.token)
+	}
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name      string            `json:"name"`
+				Namespace string            `json:"namespace"`
+				Labels    map[string]string `json:"labels"`
+			} `json:"metadata"`
+			Spec struct {
+				NodeName string `json:"nodeName"`
+			} `json:"spec"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("invalid pod list: %w", err)
+	}
+	pods := make(map[string]kubePod, len(list.Items))
+	for _, item := range list.Items {
+		pods[item.Metadata.Namespace+"/"+item.Metadata.Name] = kubePod{node: item.Spec.NodeName, labels: item.Metadata.Labels}
+	}
+	return pods, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// containerLog is the name the kubelet gives the log file of a container
+const containerLog = "web-7d4b9_shop_nginx-0123456789abcdef.log"
+
+func TestKubernetesCollectorReadsRuntimeFormats(t *testing.T) {
+	dir := t.TempDir()
+	appendFile(t, filepath.Join(dir, containerLog),
+		"2024-01-01T00:00:00.5Z stdout P GET /ind\n"+
+			"2024-01-01T00:00:00.6Z stdout F ex.html\n"+
+			"2024-01-01T00:00:01Z stderr F {\"msg\": \"upstream down\", \"level\": \"error\"}\n")
+	appendFile(t, filepath.Join(dir, "api-1_shop_app-fedcba.log"),
+		`{"log":"part one, ","stream":"stdout","time":"2024-01-01T00:00:02Z"}`+"\n"+
+			`{"log":"part two\n","stream":"stdout","time":"2024-01-01T00:00:02Z"}`+"\n")
+
+	logger := newQueueLogger(10)
+	collector := NewKubernetesCollector(logger, "", filepath.Join(dir, "*.log"))
+	if err := collector.tailer.loadCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(collector.tailer.closeFiles)
+	collector.tailer.poll()
+
+	logMessage := <-logger.logChan
+	if logMessage.MessageTemplate != "part one, part two" || logMessage.Fields[KubernetesPodProperty] != "api-1" {
+		t.Errorf("Expected the split json-file line to be joined, got %+v", logMessage)
+	}
+	logMessage = <-logger.logChan
+	if logMessage.MessageTemplate != "GET /index.html" || logMessage.Timestamp != "2024-01-01T00:00:00.5Z" {
+		t.Errorf("Expected the split CRI line to be joined, got %+v", logMessage)
+	}
+	want := map[string]interface{}{
+		KubernetesNamespaceProperty:   "shop",
+		KubernetesPodProperty:         "web-7d4b9",
+		KubernetesContainerProperty:   "nginx",
+		KubernetesContainerIDProperty: "0123456789abcdef",
+		KubernetesStreamProperty:      "stdout",
+		FilePathProperty:              filepath.Join(dir, containerLog),
+	}
+	if !reflect.DeepEqual(logMessage.Fields, want) {
+		t.Errorf("Expected the properties %v, got %v", want, logMessage.Fields)
+	}
+	logMessage = <-logger.logChan
+	if logMessage.MessageTemplate != "upstream down" || logMessage.Level != LevelError || logMessage.Fields[KubernetesStreamProperty] != "stderr" {
+		t.Errorf("Expected the logged JSON to be mapped, got %+v", logMessage)
+	}
+}
+
+func TestKubernetesCollectorAddsPodLabels(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/pods" || r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"items": [{"metadata": {"name": "web-7d4b9", "namespace": "shop", "labels": {"app": "web"}}, "spec": {"nodeName": "node-1"}}]}`))
+	}))
+	defer server.Close()
+
+	credentials := t.TempDir()
+	if err := os.WriteFile(filepath.Join(credentials, "token"), []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	kubelet, err := newKubeletClient(server.URL, credentials, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	appendFile(t, filepath.Join(dir, containerLog), "2024-01-01T00:00:00Z stdout F one\n2024-01-01T00:00:00Z stdout F two\n")
+	logger := newQueueLogger(10)
+	collector := NewKubernetesCollector(logger, "", filepath.Join(dir, "*.log"))
+	collector.kubelet = kubelet
+	if err := collector.tailer.loadCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(collector.tailer.closeFiles)
+	collector.tailer.poll()
+
+	for i := 0; i < 2; i++ {
+		logMessage := <-logger.logChan
+		if got := logMessage.Fields[KubernetesLabelsProperty]; !reflect.DeepEqual(got, map[string]interface{}{"app": "web"}) {
+			t.Errorf("Expected the pod's labels, got %v", got)
+		}
+		if logMessage.Fields[KubernetesNodeProperty] != "node-1" {
+			t.Errorf("Expected the pod's node, got %v", logMessage.Fields)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Expected the pods to be read once, got %d requests", requests)
+	}
+}
+
+func TestParseContainerLogName(t *testing.T) {
+	pod, namespace, container, id, ok := parseContainerLogName("/var/log/containers/" + containerLog)
+	if !ok || pod != "web-7d4b9" || namespace != "shop" || container != "nginx" || id != "0123456789abcdef" {
+		t.Errorf("Unexpected parse %q %q %q %q %v", pod, namespace, container, id, ok)
+	}
+	if _, _, _, _, ok := parseContainerLogName("/var/log/app.log"); ok {
+		t.Error("Expected a file not named by the kubelet to be rejected")
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"runtime/pprof"
+	"strconv"
+	"sync/atomic"
+)
+
+// loggerInstances numbers the loggers created by NewSEQLogger, for the logger label
+var loggerInstances atomic.Uint64
+
+// goWorker starts fn on a goroutine labelled for CPU and goroutine profiles with
+// component=seqlogger, worker=name, e.g. "sender", and logger=the number of the logger
+// in the process, so a profile of a busy service attributes the logging overhead
+func (l *SEQLogger) goWorker(name string, fn func()) {
+	labels := pprof.Labels("component", "seqlogger", "worker", name, "logger", strconv.FormatUint(l.instance, 10))
+	go pprof.Do(context.Background(), labels, func(context.Context) {
+		fn()
+	})
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWorkersCarryProfileLabels(t *testing.T) {
+	server := newSeqRecorder(t)
+	logger := newTestLogger(t, server.URL+EndpointRaw, 10, WithFairQueueing(5, nil))
+	defer logger.Close()
+
+	// The workers may not have started running yet
+	var profile bytes.Buffer
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		profile.Reset()
+		if err := pprof.Lookup("goroutine").WriteTo(&profile, 1); err != nil {
+			t.Fatalf("Failed to write the goroutine profile: %v", err)
+		}
+		if strings.Contains(profile.String(), `"worker":"sender"`) && strings.Contains(profile.String(), `"worker":"fair"`) {
+			break
+		}
+	}
+	for _, worker := range []string{"sender", "fair"} {
+		if !strings.Contains(profile.String(), `"worker":"`+worker+`"`) {
+			t.Errorf("Expected a goroutine labelled worker=%s, got %s", worker, profile.String())
+		}
+	}
+	if !strings.Contains(profile.String(), `"component":"seqlogger"`) {
+		t.Error("Expected the workers labelled component=seqlogger")
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+// WithPriorityLane reserves an express lane for up to capacity Error and Fatal events,
+// which the processing goroutine takes before the backlog of the main queue, so
+// critical events reach SEQ promptly while the queue is backed up with Debug chatter.
+// Critical events that don't fit the lane wait in the main queue like any other.
+func WithPriorityLane(capacity int) Option {
+	return func(l *SEQLogger) {
+		l.express = make(chan LogMessage, capacity)
+	}
+}
+
+// expressEvent queues a critical event in the express lane, if there is one with
+// room; it reports whether the event was queued. closeMu is held for reading.
+func (l *SEQLogger) expressEvent(logMessage *LogMessage) bool {
+	if l.express == nil || levelRank(logMessage.Level) < levelRank(LevelError) {
+		return false
+	}
+	select {
+	case l.express <- *logMessage:
+		return true
+	default:
+		return false
+	}
+}
+
+// nextEvent waits for the next event or marker on queue, taking the express lane first.
+// It reports false once the queue is closed and both lanes are empty.
+func (l *SEQLogger) nextEvent(queue <-chan LogMessage) (LogMessage, bool) {
+	if l.express == nil {
+		logMessage, ok := <-queue
+		return logMessage, ok
+	}
+
+	select {
+	case logMessage := <-l.express:
+		return logMessage, true
+	default:
+	}
+	select {
+	case logMessage := <-l.express:
+		return logMessage, true
+	case logMessage, ok := <-queue:
+		if ok {
+			return logMessage, true
+		}
+		// Nothing is sent on either lane once the queue is closed
+		select {
+		case logMessage := <-l.express:
+			return logMessage, true
+		default:
+			return LogMessage{}, false
+		}
+	}
+}
+
+// takeExpress appends the events waiting in the express lane to batch, up to the batch size
+func (l *SEQLogger) takeExpress(batch []LogMessage) []LogMessage {
+	for l.express != nil && len(batch) < l.batchSize {
+		select {
+		case logMessage := <-l.express:
+			batch = append(batch, logMessage)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
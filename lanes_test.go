@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPriorityLaneJumpsBacklog(t *testing.T) {
+	logger := newQueueLogger(10, WithPriorityLane(1))
+	logger.batchSize = 10
+
+	logger.Log(LevelDebug, "Cache miss", nil)
+	logger.Log(LevelDebug, "Cache miss", nil)
+	logger.Log(LevelError, "Payment failed", nil)
+	logger.Log(LevelFatal, "Out of disk", nil)
+
+	if depth := logger.QueueDepth(); depth != 4 {
+		t.Errorf("Expected both lanes counted, got %d", depth)
+	}
+	first, _ := logger.nextEvent(logger.logChan)
+	if first.MessageTemplate != "Payment failed" {
+		t.Errorf("Expected the Error event first, got %q", first.MessageTemplate)
+	}
+	// The lane held one event, so the Fatal event waits in the main queue
+	var rest []string
+	for _, logMessage := range fillBatch(logger.logChan, logger.takeExpress(nil), 10, 0) {
+		rest = append(rest, logMessage.MessageTemplate)
+	}
+	if strings.Join(rest, ",") != "Cache miss,Cache miss,Out of disk" {
+		t.Errorf("Unexpected order %v", rest)
+	}
+
+	close(logger.logChan)
+	if _, ok := logger.nextEvent(logger.logChan); ok {
+		t.Error("Expected no more events once the queue is closed and drained")
+	}
+}
+
+func TestFlushDeliversExpressEvents(t *testing.T) {
+	server := newSeqRecorder(t)
+	logger := newTestLogger(t, server.URL+EndpointRaw, 10, WithPriorityLane(4))
+	defer logger.Close()
+
+	logger.Log(LevelInformation, "Started", nil)
+	logger.Log(LevelError, "Payment failed", nil)
+	if err := logger.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if received := server.received(); !strings.Contains(received, "Payment failed") || !strings.Contains(received, "Started") {
+		t.Errorf("Expected both events delivered by Flush, got %s", received)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import "fmt"
+
+// Lazy is a field value computed only when the event it is logged with passes the
+// minimum level, overrides and sampling, so expensive diagnostics cost nothing for
+// events that are dropped:
+//
+//	logger.Log(LevelDebug, "Cache state {Entries}", map[string]interface{}{
+//		"Entries": Lazy(func() interface{} { return cache.Snapshot() }),
+//	})
+//
+// A plain func() interface{} value is evaluated the same way. Only the event's top
+// level fields are evaluated; the function should not log through the same logger.
+type Lazy func() interface{}
+
+// resolveLazyFields returns fields with its lazy values replaced by their results,
+// copying fields rather than changing the caller's map
+func resolveLazyFields(fields map[string]interface{}) map[string]interface{} {
+	var resolved map[string]interface{}
+	for key, value := range fields {
+		var fn func() interface{}
+		switch value := value.(type) {
+		case Lazy:
+			fn = value
+		case func() interface{}:
+			fn = value
+		default:
+			continue
+		}
+		if resolved == nil {
+			resolved = copyFields(fields)
+		}
+		resolved[key] = callLazy(fn)
+	}
+	if resolved == nil {
+		return fields
+	}
+	return resolved
+}
+
+// callLazy evaluates a lazy value, turning a panic into a descriptive value
+func callLazy(fn func() interface{}) (value interface{}) {
+	if fn == nil {
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			value = fmt.Sprintf("!(PANIC=Lazy value: %v)", r)
+		}
+	}()
+	return fn()
+}
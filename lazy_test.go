@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLazyFieldsAreEvaluatedOnlyForKeptEvents(t *testing.T) {
+	logger := newQueueLogger(10, WithMinLevel(LevelInformation), WithSampling(map[string]float64{LevelWarning: 0}))
+	calls := 0
+	snapshot := Lazy(func() interface{} {
+		calls++
+		return map[string]interface{}{"Entries": 3}
+	})
+
+	logger.Log(LevelDebug, "Below the minimum level", map[string]interface{}{"Cache": snapshot})
+	logger.Log(LevelWarning, "Sampled out", map[string]interface{}{"Cache": snapshot})
+	if calls != 0 {
+		t.Fatalf("Expected dropped events not to evaluate lazy fields, got %d calls", calls)
+	}
+
+	fields := map[string]interface{}{
+		"Cache": snapshot,
+		"Count": func() interface{} { return 42 },
+	}
+	logger.Log(LevelError, "Cache state {Cache}", fields)
+	logMessage := <-logger.logChan
+	if calls != 1 {
+		t.Errorf("Expected the lazy field to be evaluated once, got %d calls", calls)
+	}
+	if cache, ok := logMessage.Fields["Cache"].(map[string]interface{}); !ok || cache["Entries"] != 3 {
+		t.Errorf("Expected the lazy value, got %#v", logMessage.Fields["Cache"])
+	}
+	if logMessage.Fields["Count"] != 42 {
+		t.Errorf("Expected a plain func() interface{} to be evaluated too, got %#v", logMessage.Fields["Count"])
+	}
+	if _, ok := fields["Cache"].(Lazy); !ok {
+		t.Errorf("Expected the caller's map to be left alone, got %#v", fields["Cache"])
+	}
+}
+
+func TestLazyFieldPanicIsReported(t *testing.T) {
+	logger := newQueueLogger(1)
+	logger.Log(LevelInformation, "Broken", map[string]interface{}{
+		"State": Lazy(func() interface{} { panic("no state") }),
+	})
+	if got, _ := (<-logger.logChan).Fields["State"].(string); !strings.Contains(got, "PANIC") || !strings.Contains(got, "no state") {
+		t.Errorf("Expected the panic to be described, got %q", got)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import "strings"
+
+// Levels understood by SEQ, from least to most severe
+const (
+	LevelVerbose     = "Verbose"
+	LevelDebug       = "Debug"
+	LevelInformation = "Information"
+	LevelWarning     = "Warning"
+	LevelError       = "Error"
+	LevelFatal       = "Fatal"
+)
+
+// levelCount is the number of distinct level ranks
+const levelCount = 6
+
+// levelNames maps each rank back to its canonical level name
+var levelNames = [levelCount]string{LevelVerbose, LevelDebug, LevelInformation, LevelWarning, LevelError, LevelFatal}
+
+// levelRanks maps level names and their common aliases to their severity
+var levelRanks = []struct {
+	name string
+	rank int
+}{
+	{LevelVerbose, 0}, {"Trace", 0},
+	{LevelDebug, 1},
+	{LevelInformation, 2}, {"Info", 2},
+	{LevelWarning, 3}, {"Warn", 3},
+	{LevelError, 4}, {"Err", 4},
+	{LevelFatal, 5}, {"Critical", 5},
+}
+
+// levelRank returns the severity of level, matched case-insensitively.
+// Unknown levels rank as Information.
+func levelRank(level string) int {
+	if rank, ok := knownLevelRank(level); ok {
+		return rank
+	}
+	return 2
+}
+
+// minLevelRank returns the rank used for a minimum level setting; an empty level lets every event through
+func minLevelRank(level string) int {
+	if level == "" {
+		return 0
+	}
+	return levelRank(level)
+}
+
+// knownLevelRank returns the severity of level and whether it is a known level name
+func knownLevelRank(level string) (int, bool) {
+	for _, l := range levelRanks {
+		if strings.EqualFold(l.name, level) {
+			return l.rank, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,49 @@
+package main
+
+import "sync"
+
+// conventionalProperties are supplied without a placeholder by convention
+var conventionalProperties = map[string]bool{
+	SourceContextProperty:  true,
+	EventTokenProperty:     true,
+	SequenceNumberProperty: true,
+}
+
+// lintWarnings remembers the template problems already reported, so each one is reported once
+var lintWarnings sync.Map
+
+// lintTemplate reports through the self log placeholders of the template with no matching
+// property and call-site fields that no placeholder uses. properties holds every
+// property of the event; fields only those passed to Log.
+func lintTemplate(template *parsedTemplate, properties, fields map[string]interface{}) {
+	for _, name := range template.properties {
+		if _, ok := properties[name]; !ok {
+			lintWarn(template.template, name, "Template %q references {%s} but no such property was supplied")
+		}
+	}
+
+	for name := range fields {
+		if conventionalProperties[name] || template.usesProperty(name) {
+			continue
+		}
+		lintWarn(template.template, name, "Template %q does not use the supplied property %q")
+	}
+}
+
+// lintWarn reports a template problem unless it was reported before
+func lintWarn(template, property, format string) {
+	key := format + "\x00" + template + "\x00" + property
+	if _, reported := lintWarnings.LoadOrStore(key, struct{}{}); !reported {
+		selfLogf(format, template, property)
+	}
+}
+
+// usesProperty reports whether a placeholder of the template names property
+func (p *parsedTemplate) usesProperty(property string) bool {
+	for _, name := range p.properties {
+		if name == property {
+			return true
+		}
+	}
+	return false
+}
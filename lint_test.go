@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// captureSelfLog collects self log messages for the rest of the test
+func captureSelfLog(t *testing.T) func() []string {
+	var mu sync.Mutex
+	var messages []string
+	SetSelfLog(func(format string, args ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		messages = append(messages, fmt.Sprintf(format, args...))
+	})
+	t.Cleanup(func() { SetSelfLog(nil) })
+
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), messages...)
+	}
+}
+
+func TestTemplateLint(t *testing.T) {
+	lintWarnings.Range(func(key, _ interface{}) bool {
+		lintWarnings.Delete(key)
+		return true
+	})
+	messages := captureSelfLog(t)
+	logger := newQueueLogger(10, WithTemplateLint(),
+		WithGlobalFields(map[string]interface{}{"Application": "shop"}))
+
+	logger.Log(LevelInformation, "User {UserId} logged in from {Application}", map[string]interface{}{
+		"UserID":              42,
+		SourceContextProperty: "auth",
+	})
+	logger.Log(LevelInformation, "User {UserId} logged in from {Application}", map[string]interface{}{"UserID": 42})
+	logger.Log(LevelInformation, "Order {OrderId} placed", map[string]interface{}{"OrderId": 1})
+
+	got := messages()
+	if len(got) != 2 {
+		t.Fatalf("Expected one warning per problem, got %q", got)
+	}
+	if !strings.Contains(got[0]+got[1], "references {UserId}") {
+		t.Errorf("Expected a warning about the missing UserId property, got %q", got)
+	}
+	if !strings.Contains(got[0]+got[1], `does not use the supplied property "UserID"`) {
+		t.Errorf("Expected a warning about the unused UserID property, got %q", got)
+	}
+}
@@ -1,11 +1,13 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,26 +17,222 @@ type LogMessage struct {
 	Level           string                 `json:"@level"`
 	MessageTemplate string                 `json:"@messageTemplate"`
 	Fields          map[string]interface{} `json:"@fields,omitempty"`
+	EventID         uint32                 `json:"@eventId,omitempty"`
+	Renderings      []Rendering            `json:"@renderings,omitempty"`
+
+	walSeq  uint64          // write-ahead log sequence number, 0 without a WAL
+	size    int64           // estimated size counted against the memory budget, 0 without one
+	logged  int64           // unix nanoseconds when the event was created, 0 once read back from disk
+	flush   chan error      // set on the marker queued by Flush instead of an event
+	resized chan LogMessage // set on the marker queued by Resize, the queue that follows this one
 }
 
 // SEQLogger represents a logger that sends logs to a SEQ server
 type SEQLogger struct {
-	seqURL  string
-	apiKey  string
-	logChan chan LogMessage
+	seqURL    string
+	endpoint  string // ingestion endpoint appended to a base seqURL, negotiated when empty
+	format    Format // payload format, detected when FormatAuto, see WithFormat
+	apiKey    string
+	logChan   chan LogMessage
+	encoder   Encoder
+	transport *Transport
+
+	batchSize     int
+	batchInterval time.Duration
+	retry         retryPolicy
+	compression   *compression
+	pausedUntil   atomic.Int64 // unix nanoseconds until which Retry-After holds requests back
+	pacer         *pacer
+	onAuthError   func(err *AuthError)
+	beforeSend    func(req *http.Request, body []byte) error
+	headers       http.Header    // set on every request, see WithHeader
+	userAgent     string         // replaces defaultUserAgent
+	signer        *requestSigner // signs ingestion requests, see WithHMACSigning
+	basicAuth     *basicAuth     // sent on every request, see WithBasicAuth
+	tokens        *bearerTokens  // sent on every request, see WithTokenSource
+
+	connectionResetAfter int    // see WithConnectionReset
+	dialAddress          string // connections go to it instead of the URL's host, see WithDialAddress
+	prewarm              bool   // connect as the logger is created, see WithPrewarm
+	tracing              bool   // ingestion requests are traced, see WithTracing
+	memoryBudget         *memoryBudget
+	pressure             *memoryPressure // see WithMemoryPressure
+	express              chan LogMessage // Error and Fatal events taken before logChan, see WithPriorityLane
+	fair                 *fairQueue      // per-SourceContext sub-queues in front of logChan, see WithFairQueueing
+	pump                 *pump           // delivery driven by the host instead of processLogs, see WithPumpMode
+	connectionFailures   atomic.Int32
+	headerProviders      []func() http.Header // called for every request, see WithHeaderProvider
+	afterSend            func(req *http.Request, resp *http.Response, err error)
+	minLevel             atomic.Int32
+	serverMinLevel       atomic.Int32 // reported by the server, see WithLevelFeedback
+	levelFeedback        bool
+	versionDetection     bool
+	serverVersion        serverVersion // read from the server, see WithVersionDetection
+	sampling             atomic.Pointer[samplingRates]
+
+	// configMinLevel and configSampling are the minimum level and sampling owned by the
+	// configuration file, empty for none; codeMinLevel and codeSampling are those they
+	// replaced, restored once a reloaded file leaves them out
+	reloadMu       sync.Mutex // serializes ReloadConfig
+	configMinLevel string
+	codeMinLevel   int32
+	configSampling *samplingRates
+	codeSampling   *samplingRates
+
+	levelOverrides  atomic.Pointer[levelOverrides]
+	overridesMu     sync.Mutex        // serializes changes to levelOverrides
+	configOverrides map[string]string // level overrides owned by the configuration file
+
+	normalizer   normalizer
+	offloader    *offloader // moves large values out of events, see WithOffload
+	globalFields globalFields
+	configFields map[string]interface{} // global fields owned by the configuration file
+	enrichers    []Enricher
+	// contextExtractors add properties from the context passed to LogCtx
+	contextExtractors []contextExtractor
+	middleware        []Middleware // wrap log, see WithMiddleware
+	mutators          []Mutator    // rewrite built events, see WithMutator
+	// reloadedEnrichers replaces enrichers once a configuration file naming some is reloaded
+	reloadedEnrichers atomic.Pointer[[]Enricher]
+
+	sinks       []routedSink
+	seqMinLevel int
+	fallback    Sink        // receives the batches Seq did not accept, instead of the local log
+	files       []io.Closer // opened by NewFromConfigFile for its sinks, closed by Close
+
+	auditDir string
+	walDir   string
+	wal      *writeAheadLog
+
+	spillPath     string
+	spillMaxBytes int64
+	spill         *spill
+
+	eventTokens     bool
+	sequenceNumbers bool
+	sequence        atomic.Uint64
+
+	watermarks []*watermark
+	health     *healthMonitor
+	// errorAggregator holds back repeated Error events, see WithErrorAggregation
+	errorAggregator *errorAggregator
+	schemas         eventSchemas  // the struct types registered with RegisterEvent
+	dropped         atomic.Uint64 // events lost to validation or delivery failures
+	deliveryStats   deliveryStats // events accepted by the SEQ server, see Stats
+	recentErrors    recentErrors  // the last delivery errors, see Handler
+
+	sessionMu sync.Mutex
+	session   *session // in progress, see StartSession
+
+	flushErrs    []error // delivery errors since the last flush, owned by processLogs
+	flushDropped int     // errors left out of flushErrs once it is full
+
+	closeMu sync.RWMutex                    // held for reading while sending on logChan, for writing while closing or resizing it
+	queue   atomic.Pointer[chan LogMessage] // logChan as of the last Resize, see currentQueue
+	closed  atomic.Bool                     // set under closeMu; read without it for a fast path
+	done    chan struct{}                   // closed when processLogs has sent the last event
+
+	lintTemplates bool
+	validators    map[string][]Validator // by template, "" for every event, see WithValidator
+	quarantine    Sink                   // receives the events validators reject
+
+	optionErrs    []error    // problems found by options, reported by NewSEQLogger
+	instance      uint64     // numbers the logger in the process for profiles, see goWorker
+	root          *SEQLogger // the logger whose pipeline a child logger sends through, nil for a root
+	contextFields map[string]interface{}
 }
 
-// NewSEQLogger creates a new SEQLogger
-func NewSEQLogger(seqURL, apiKey string, bufferSize int) *SEQLogger {
+// NewSEQLogger creates a new SEQLogger. seqURL is either an ingestion endpoint, such as
+// http://localhost:5341/api/events/raw, or the server's base URL, in which case the
+// endpoint is negotiated with the server. It returns a *ConfigError for a URL that
+// isn't http or https, a negative bufferSize or options it can't work with, such as
+// out of range sizes or options that conflict.
+func NewSEQLogger(seqURL, apiKey string, bufferSize int, opts ...Option) (*SEQLogger, error) {
 	logger := &SEQLogger{
-		seqURL:  seqURL,
-		apiKey:  apiKey,
-		logChan: make(chan LogMessage, bufferSize),
+		seqURL: seqURL,
+		apiKey: apiKey,
+
+		batchSize:            defaultBatchSize,
+		retry:                defaultRetryPolicy,
+		connectionResetAfter: defaultConnectionResetAfter,
+
+		normalizer: newNormalizer(),
+		done:       make(chan struct{}),
+		instance:   loggerInstances.Add(1),
+	}
+	for _, opt := range opts {
+		opt(logger)
+	}
+	if err := logger.checkConfig(bufferSize); err != nil {
+		return nil, err
+	}
+
+	logger.logChan = make(chan LogMessage, bufferSize)
+	queue := logger.logChan
+	logger.queue.Store(&queue)
+	if logger.transport == nil {
+		logger.transport = newPrivateTransport()
+	}
+	if logger.dialAddress != "" {
+		logger.transport = logger.transport.dialing(logger.dialAddress)
+	}
+	if logger.tracing && TracingCompiled {
+		logger.transport = logger.transport.traced()
+	}
+	logger.resolveEndpoint()
+	if logger.prewarm {
+		logger.goWorker("prewarm", logger.prewarmConnection)
+	}
+	if logger.encoder == nil {
+		logger.encoder = RawEncoder{}
+	}
+	if logger.versionDetection {
+		logger.gateFeatures(logger.detectServerVersion())
+	}
+
+	var replay []LogMessage
+	if logger.walDir != "" {
+		wal, unacked, err := openWAL(logger.walDir)
+		if err != nil {
+			selfLogf("Failed to open WAL, continuing without it: %v", err)
+		} else {
+			logger.wal, replay = wal, unacked
+		}
+	}
+
+	if logger.spillPath != "" {
+		spill, err := openSpill(logger.spillPath, logger.spillMaxBytes)
+		if err != nil {
+			selfLogf("Failed to open spill file, continuing without it: %v", err)
+		} else {
+			logger.spill = spill
+			logger.goWorker("spill", logger.drainSpill)
+		}
+	}
+
+	if logger.pump != nil {
+		logger.pump.delivery = logger.newDelivery(queue)
+	} else {
+		logger.goWorker("sender", func() { logger.processLogs(queue) })
+	}
+	if logger.fair != nil {
+		logger.goWorker("fair", logger.pumpFair)
+	}
+	if logger.pressure != nil {
+		logger.watchMemory()
 	}
 
-	go logger.processLogs()
+	// Events left unacknowledged by a previous run are sent before any new ones
+	for _, logMessage := range replay {
+		if logger.pump != nil && len(logger.logChan) == cap(logger.logChan) {
+			logger.pump.mu.Lock()
+			logger.pumpQueued(-1)
+			logger.pump.mu.Unlock()
+		}
+		logger.logChan <- logMessage
+	}
 
-	return logger
+	return logger, nil
 }
 
 // validateLogMessage validates the structure and content of the log message
@@ -45,82 +243,355 @@ func validateLogMessage(logMessage *LogMessage) error {
 	return nil
 }
 
-// processLogs listens on the logChan and sends log messages to the SEQ server
-func (l *SEQLogger) processLogs() {
-	client := &http.Client{}
-
-	for logMessage := range l.logChan {
-		// Wrap the log message inside an "Events" array
-		payload := map[string]interface{}{
-			"Events": []map[string]interface{}{
-				{
-					"Timestamp":       logMessage.Timestamp,
-					"Level":           logMessage.Level,
-					"MessageTemplate": logMessage.MessageTemplate,
-					"Properties":      logMessage.Fields,
-				},
-			},
-		}
+// defaultBatchSize is the default maximum number of log messages sent to the SEQ server in a single request
+const defaultBatchSize = 100
 
-		data, err := json.Marshal(payload)
-		if err != nil {
-			log.Printf("Failed to marshal log message: %v", err)
-			log.Printf("Local log: %s - %s", logMessage.Level, logMessage.MessageTemplate)
+// fillBatch appends queued log messages to batch until it holds size messages.
+// With a positive interval it waits up to that long for more messages to arrive;
+// otherwise it only takes the messages that are already queued. A flush or resize marker ends the batch.
+func fillBatch(logChan <-chan LogMessage, batch []LogMessage, size int, interval time.Duration) []LogMessage {
+	var timeout <-chan time.Time
+	if interval > 0 {
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for len(batch) < size {
+		if timeout == nil {
+			select {
+			case logMessage, ok := <-logChan:
+				if !ok {
+					return batch
+				}
+				batch = append(batch, logMessage)
+				if logMessage.flush != nil || logMessage.resized != nil {
+					return batch
+				}
+			default:
+				return batch
+			}
 			continue
 		}
 
-		req, err := http.NewRequest("POST", l.seqURL, bytes.NewBuffer(data))
-		if err != nil {
-			log.Printf("Failed to create HTTP request: %v", err)
-			log.Printf("Local log: %s - %s", logMessage.Level, logMessage.MessageTemplate)
+		select {
+		case logMessage, ok := <-logChan:
+			if !ok {
+				return batch
+			}
+			batch = append(batch, logMessage)
+			if logMessage.flush != nil || logMessage.resized != nil {
+				return batch
+			}
+		case <-timeout:
+			return batch
+		}
+	}
+	return batch
+}
+
+// logLocally writes the log messages of a batch that could not be delivered to the local log,
+// with their templates rendered so the output stays readable
+func logLocally(batch []LogMessage) {
+	for _, logMessage := range batch {
+		log.Printf("Local log: %s - %s", logMessage.Level, renderMessage(&logMessage))
+	}
+}
+
+// delivery is the state processLogs keeps from one batch to the next, and Pump and
+// FlushNow in pump mode
+type delivery struct {
+	client *http.Client
+	queue  chan LogMessage // the queue read, until a resize marker points to the next
+	batch  []LogMessage
+	routed []LogMessage
+}
+
+// newDelivery returns the delivery state for reading queue
+func (l *SEQLogger) newDelivery(queue chan LogMessage) *delivery {
+	return &delivery{
+		client: l.transport.client,
+		queue:  queue,
+		batch:  make([]LogMessage, 0, l.batchSize),
+		routed: make([]LogMessage, 0, l.batchSize),
+	}
+}
+
+// processLogs listens on queue, the logChan it was started with and those that replace
+// it, and sends batches of log messages to the SEQ server and sinks
+func (l *SEQLogger) processLogs(queue chan LogMessage) {
+	defer close(l.done)
+	if l.wal != nil {
+		defer l.wal.close()
+	}
+
+	d := l.newDelivery(queue)
+	for {
+		logMessage, ok := l.nextEvent(d.queue)
+		if !ok {
+			return
+		}
+		l.process(d, logMessage, l.batchInterval)
+	}
+}
+
+// process handles logMessage and sends it with the events queued behind it, waiting up
+// to interval for the batch to fill. It returns how many messages it took from the queues.
+func (l *SEQLogger) process(d *delivery, logMessage LogMessage, interval time.Duration) int {
+	// Everything queued before a flush marker has already been dispatched
+	if logMessage.flush != nil {
+		l.completeFlush(logMessage.flush)
+		return 1
+	}
+	if logMessage.resized != nil {
+		d.queue = logMessage.resized
+		return 1
+	}
+
+	depth := len(d.queue) + len(l.express) + 1
+	d.batch = fillBatch(d.queue, l.takeExpress(append(d.batch[:0], logMessage)), l.batchSize, interval)
+	taken := len(d.batch)
+	if l.memoryBudget != nil {
+		l.memoryBudget.release(d.batch)
+	}
+	var flush chan error
+	if last := len(d.batch) - 1; d.batch[last].flush != nil {
+		// Critical events logged before Flush may have gone into the express lane since
+		flush, d.batch = d.batch[last].flush, l.takeExpress(d.batch[:last])
+		taken += len(d.batch) - last
+	} else if d.batch[last].resized != nil {
+		d.queue, d.batch = d.batch[last].resized, d.batch[:last]
+	}
+	if healthEvent, ok := l.healthEvent(depth, time.Now()); ok {
+		d.batch = append(d.batch, healthEvent)
+	}
+	d.routed = l.dispatch(d.client, d.batch, d.routed)
+	if flush != nil {
+		l.completeFlush(flush)
+	}
+	return taken
+}
+
+// dispatch hands batch to every sink and to the SEQ server, each receiving only the
+// events at or above its minimum level; scratch is reused for the filtered batches
+func (l *SEQLogger) dispatch(client *http.Client, batch, scratch []LogMessage) []LogMessage {
+	for _, s := range l.sinks {
+		events := batch
+		if s.minLevel > 0 {
+			scratch = filterByLevel(batch, s.minLevel, scratch[:0])
+			events = scratch
+		}
+		if len(events) == 0 {
 			continue
 		}
-		req.Header.Set("Content-Type", "application/json")
+		if err := s.sink.Emit(events); err != nil {
+			selfLogf("Failed to write log messages to %T: %v", s.sink, err)
+			l.recordFlushError(fmt.Errorf("Failed to write log messages to %T: %w", s.sink, err))
+		}
+	}
 
-		if l.apiKey != "" {
-			req.Header.Set("X-Seq-ApiKey", l.apiKey)
+	events := batch
+	if l.seqMinLevel > 0 {
+		scratch = filterByLevel(batch, l.seqMinLevel, scratch[:0])
+		events = scratch
+	}
+	delivered := true
+	if len(events) > 0 {
+		if err := l.deliver(client, events); err != nil {
+			l.recordFlushError(err)
+			delivered = false
 		}
+	}
+	if l.wal != nil {
+		scratch = l.acknowledge(batch, scratch, delivered)
+	}
 
-		resp, err := client.Do(req)
-		if err != nil {
-			log.Printf("Failed to send log message: %v", err)
-			log.Printf("Local log: %s - %s", logMessage.Level, logMessage.MessageTemplate)
+	return scratch
+}
+
+// deliver encodes a batch and sends it to the SEQ server, returning why it was not accepted
+// When SEQ rejects a single malformed event, only that event is dropped and the rest is resent.
+func (l *SEQLogger) deliver(client *http.Client, batch []LogMessage) error {
+	buf := getEncodeBuffer()
+	defer putEncodeBuffer(buf)
+
+	for {
+		buf.Reset()
+		if err := l.encoder.Encode(buf, batch); err != nil {
+			encodable := l.dropUnencodable(batch)
+			if len(encodable) == 0 {
+				// Every event was reported on its own
+				return nil
+			}
+			if len(encodable) == len(batch) {
+				selfLogf("Failed to marshal log message: %v", err)
+				l.fallBack(batch)
+				l.dropped.Add(uint64(len(batch)))
+				return fmt.Errorf("Failed to marshal log message: %w", err)
+			}
+			batch = encodable
 			continue
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
-			var responseBody bytes.Buffer
-			responseBody.ReadFrom(resp.Body)
-			log.Printf("SEQ server responded with %v. Response: %v", resp.Status, responseBody.String())
-			log.Printf("Local log: %s - %s", logMessage.Level, logMessage.MessageTemplate)
+		err := l.sendBatch(client, buf.Bytes(), batch)
+		if err == nil {
+			return nil
+		}
+		i, ok := l.malformedEvent(err, len(batch))
+		if !ok {
+			l.dropped.Add(uint64(len(batch)))
+			return err
 		}
+
+		// The rejected event is reported to Flush but doesn't fail the rest of the batch
+		l.fallBack(batch[i : i+1])
+		l.dropped.Add(1)
+		l.recordFlushError(err)
+		batch = append(batch[:i:i], batch[i+1:]...)
 	}
 }
 
-// Log sends a log message to the logChan for processing
-func (l *SEQLogger) Log(level, message string, fields map[string]interface{}) {
+// dropUnencodable encodes the events of a batch that failed to encode one by one and
+// returns those that encode, so a property value the encoder can't handle, such as
+// NaN for encoding/json, costs only its own event. The others are reported to Flush,
+// logged locally and counted as dropped.
+func (l *SEQLogger) dropUnencodable(batch []LogMessage) []LogMessage {
+	buf := getEncodeBuffer()
+	defer putEncodeBuffer(buf)
+
+	encodable := make([]LogMessage, 0, len(batch))
+	for i := range batch {
+		buf.Reset()
+		err := l.encoder.Encode(buf, batch[i:i+1])
+		if err == nil {
+			encodable = append(encodable, batch[i])
+			continue
+		}
+		err = fmt.Errorf("Failed to marshal log message %q: %w", batch[i].MessageTemplate, err)
+		selfLogf("%v", err)
+		l.fallBack(batch[i : i+1])
+		l.dropped.Add(1)
+		l.recordFlushError(err)
+	}
+	return encodable
+}
+
+// SequenceNumberProperty carries the per-logger event sequence number added by WithSequenceNumbers
+const SequenceNumberProperty = "SequenceNumber"
+
+// newLogMessage builds an event from the call-site fields, the enrichers and the global
+// fields, evaluating their Lazy values and sanitizing the template and string values
+func (l *SEQLogger) newLogMessage(level, message string, fields map[string]interface{}) LogMessage {
+	message, _ = sanitizeString(message)
+	enrichers := l.enrichers
+	if reloaded := l.reloadedEnrichers.Load(); reloaded != nil {
+		enrichers = *reloaded
+	}
+	fields = resolveLazyFields(mergeFields(l.globalFields.load(), enrich(enrichers, level, fields)))
+	if l.offloader != nil {
+		fields = l.offloader.fields(fields)
+	}
+	parsed := templates.get(message)
+
+	now := time.Now()
 	logMessage := LogMessage{
-		Timestamp:       time.Now().UTC().Format(time.RFC3339), // Use RFC3339 format for timestamp
+		Timestamp:       now.UTC().Format(time.RFC3339), // Use RFC3339 format for timestamp
 		Level:           level,
 		MessageTemplate: message,
-		Fields:          fields,
+		Fields:          sanitizePropertyNames(l.normalizer.fields(fields)),
+		EventID:         parsed.eventID,
+		Renderings:      parsed.renderings(fields),
+		logged:          now.UnixNano(),
+	}
+	if l.sequenceNumbers {
+		logMessage.Fields = withField(logMessage.Fields, SequenceNumberProperty, l.sequence.Add(1))
+	}
+	if l.needsEventTokens() {
+		logMessage.Fields = withEventToken(logMessage.Fields)
+	}
+	return logMessage
+}
+
+// Log sends a log message to the logChan for processing. It does nothing once the logger is closed.
+// A field-less event costs at most logAllocBudget allocations on the caller's goroutine.
+func (l *SEQLogger) Log(level, message string, fields map[string]interface{}) {
+	l.pipeline().log(l.contextFields, time.Time{}, level, message, fields)
+}
+
+// LogAt logs like Log an event that happened at timestamp, e.g. one read back from another log
+func (l *SEQLogger) LogAt(timestamp time.Time, level, message string, fields map[string]interface{}) {
+	l.pipeline().log(l.contextFields, timestamp, level, message, fields)
+}
+
+// log filters, builds and queues an event, through the middleware if any; contextFields
+// are the fields of the child logger Log was called on, between the global fields and the
+// call-site fields in precedence. A zero timestamp stamps the event with the current time.
+func (l *SEQLogger) log(contextFields map[string]interface{}, timestamp time.Time, level, message string, fields map[string]interface{}) {
+	if l.middleware != nil {
+		l.logThroughMiddleware(contextFields, timestamp, level, message, fields)
+		return
+	}
+	l.logEvent(contextFields, timestamp, level, message, fields)
+}
+
+// logEvent is log past the middleware
+func (l *SEQLogger) logEvent(contextFields map[string]interface{}, timestamp time.Time, level, message string, fields map[string]interface{}) {
+	if l.closed.Load() {
+		return
+	}
+
+	rank := levelRank(level)
+	if !l.enabled(rank, fields, contextFields) {
+		return
+	}
+	if rates := l.sampling.Load(); rates != nil && !rates.keep(rank) {
+		return
 	}
 
+	merged := mergeFields(contextFields, fields)
+	if l.errorAggregator != nil && rank == levelRank(LevelError) && l.holdBack(message, merged) {
+		return
+	}
+
+	logMessage := l.newLogMessage(level, message, merged)
+	if !timestamp.IsZero() {
+		logMessage.Timestamp = timestamp.UTC().Format(time.RFC3339Nano)
+	}
+	if l.lintTemplates {
+		lintTemplate(templates.get(message), logMessage.Fields, fields)
+	}
+	if l.mutators != nil {
+		logMessage = l.mutate(logMessage)
+	}
 	if err := validateLogMessage(&logMessage); err != nil {
-		log.Printf("Validation failed for log message: %v", err)
-		log.Printf("Local log: %s - %s", logMessage.Level, logMessage.MessageTemplate)
+		selfLogf("Validation failed for log message: %v", err)
+		log.Printf("Local log: %s - %s", logMessage.Level, renderMessage(&logMessage))
+		l.dropped.Add(1)
+		return
+	}
+	if l.validators != nil && !l.validate(logMessage) {
 		return
 	}
 
-	l.logChan <- logMessage
+	l.enqueue(&logMessage)
 }
 
+// logAllocBudget is the allocation budget of Log for an event without fields: the formatted timestamp
+const logAllocBudget = 1
+
 func main() {
+	// With a subcommand the binary is the seqlog command line tool, see cliUsage
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	seqURL := "http://localhost:5341/api/events/raw" // SEQ server URL
 	apiKey := "YourAPIKey"                           // SEQ server API key
 
-	logger := NewSEQLogger(seqURL, apiKey, 100) // Buffer size of 100 for the log channel
+	logger, err := NewSEQLogger(seqURL, apiKey, 100) // Buffer size of 100 for the log channel
+	if err != nil {
+		log.Fatal(err)
+	}
 	// Example usage with more logs
 	logger.Log("Information", "Application started", map[string]interface{}{
 		"version": "1.0.0",
@@ -138,6 +609,6 @@ func main() {
 		},
 	})
 
-	// Allow some time for the logs to be processed before exiting
-	time.Sleep(5 * time.Second)
+	// Send the queued logs before exiting
+	logger.Close()
 }
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// newQueueLogger creates a SEQLogger without a processing goroutine so tests can read its queue
+func newQueueLogger(bufferSize int, opts ...Option) *SEQLogger {
+	logger := &SEQLogger{
+		logChan:    make(chan LogMessage, bufferSize),
+		normalizer: newNormalizer(),
+		transport:  newPrivateTransport(),
+	}
+	for _, opt := range opts {
+		opt(logger)
+	}
+	return logger
+}
+
+// newTestLogger creates a logger with NewSEQLogger, failing the test if it can't
+func newTestLogger(t testing.TB, seqURL string, bufferSize int, opts ...Option) *SEQLogger {
+	t.Helper()
+	logger, err := NewSEQLogger(seqURL, "", bufferSize, opts...)
+	if err != nil {
+		t.Fatalf("Failed to create the logger: %v", err)
+	}
+	return logger
+}
+
+// newDiscardLogger creates a SEQLogger whose queued messages are discarded instead of sent
+func newDiscardLogger(bufferSize int) *SEQLogger {
+	logger := newQueueLogger(bufferSize)
+	go func() {
+		for range logger.logChan {
+		}
+	}()
+	return logger
+}
+
+// benchmarkBatch builds a batch of n log messages carrying a few fields each
+func benchmarkBatch(n int) []LogMessage {
+	batch := make([]LogMessage, n)
+	for i := range batch {
+		batch[i] = LogMessage{
+			Timestamp:       time.Now().UTC().Format(time.RFC3339),
+			Level:           "Information",
+			MessageTemplate: "Processed order {OrderId}",
+			Fields: map[string]interface{}{
+				"OrderId":  i,
+				"customer": "12345",
+				"duration": "120ms",
+			},
+		}
+	}
+	return batch
+}
+
+func TestLogAllocationBudget(t *testing.T) {
+	logger := newDiscardLogger(1024)
+	defer close(logger.logChan)
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		logger.Log("Information", "Application started", nil)
+	})
+	if allocs > logAllocBudget {
+		t.Errorf("Log allocated %v times per field-less event, budget is %v", allocs, logAllocBudget)
+	}
+}
+
+func BenchmarkLog(b *testing.B) {
+	logger := newDiscardLogger(1024)
+	defer close(logger.logChan)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.Log("Information", "Application started", nil)
+	}
+}
+
+func BenchmarkLogWithFields(b *testing.B) {
+	logger := newDiscardLogger(1024)
+	defer close(logger.logChan)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.Log("Information", "Processed order {OrderId}", map[string]interface{}{
+			"OrderId":  i,
+			"customer": "12345",
+		})
+	}
+}
+
+func BenchmarkEncodeSingle(b *testing.B) {
+	batch := []LogMessage{{
+		Timestamp:       time.Now().UTC().Format(time.RFC3339),
+		Level:           "Information",
+		MessageTemplate: "Application started",
+	}}
+	buf := getEncodeBuffer()
+	defer putEncodeBuffer(buf)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		if err := (RawEncoder{}).Encode(buf, batch); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncodeBatch(b *testing.B) {
+	batch := benchmarkBatch(defaultBatchSize)
+	buf := getEncodeBuffer()
+	defer putEncodeBuffer(buf)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		if err := (RawEncoder{}).Encode(buf, batch); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.SetBytes(int64(buf.Len()))
+}
+
+func BenchmarkFillBatch(b *testing.B) {
+	logChan := make(chan LogMessage, defaultBatchSize)
+	source := benchmarkBatch(defaultBatchSize)
+	batch := make([]LogMessage, 0, defaultBatchSize)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, logMessage := range source {
+			logChan <- logMessage
+		}
+		batch = fillBatch(logChan, batch[:0], defaultBatchSize, 0)
+		if len(batch) != defaultBatchSize {
+			b.Fatalf("Expected batch of %d, got %d", defaultBatchSize, len(batch))
+		}
+	}
+}
+
+func TestWriteJSONStringMatchesEncodingJSON(t *testing.T) {
+	inputs := []string{
+		"",
+		"Application started",
+		`quote " and backslash \`,
+		"line\nbreak\ttab\rreturn\x00\x1f",
+		"<html> & friends",
+		"unicode \u00e9 \u2713 \u2028 \u2029",
+		"invalid \xff utf-8",
+	}
+
+	for _, input := range inputs {
+		buf := getEncodeBuffer()
+		writeJSONString(&buf.Buffer, input)
+		got := buf.String()
+		putEncodeBuffer(buf)
+
+		want, err := json.Marshal(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != string(want) {
+			t.Errorf("writeJSONString(%q) = %s, want %s", input, got, want)
+		}
+	}
+}
+
+func TestEncodeFieldlessFastPath(t *testing.T) {
+	batch := []LogMessage{
+		{Timestamp: "2024-01-02T03:04:05Z", Level: "Information", MessageTemplate: "Application started"},
+		{Timestamp: "2024-01-02T03:04:06Z", Level: "Error", MessageTemplate: "Failed {Count}", Fields: map[string]interface{}{"Count": 3}},
+	}
+	buf := getEncodeBuffer()
+	defer putEncodeBuffer(buf)
+
+	if err := (RawEncoder{}).Encode(buf, batch); err != nil {
+		t.Fatal(err)
+	}
+
+	var payload struct {
+		Events []struct {
+			Timestamp       string
+			Level           string
+			MessageTemplate string
+			Properties      map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatalf("Encoded batch is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if len(payload.Events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(payload.Events))
+	}
+	if e := payload.Events[0]; e.Level != "Information" || e.MessageTemplate != "Application started" || e.Properties != nil {
+		t.Errorf("Unexpected field-less event %+v", e)
+	}
+	if e := payload.Events[1]; e.Level != "Error" || e.Properties["Count"] != float64(3) {
+		t.Errorf("Unexpected event with fields %+v", e)
+	}
+}
+
+func BenchmarkEncodeFieldlessBatch(b *testing.B) {
+	batch := make([]LogMessage, defaultBatchSize)
+	for i := range batch {
+		batch[i] = LogMessage{
+			Timestamp:       time.Now().UTC().Format(time.RFC3339),
+			Level:           "Information",
+			MessageTemplate: "Application started",
+		}
+	}
+	buf := getEncodeBuffer()
+	defer putEncodeBuffer(buf)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		if err := (RawEncoder{}).Encode(buf, batch); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.SetBytes(int64(buf.Len()))
+}
+
+func TestRawEncoderCustomMarshal(t *testing.T) {
+	calls := 0
+	encoder := RawEncoder{Marshal: func(v interface{}) ([]byte, error) {
+		calls++
+		return json.Marshal(v)
+	}}
+	batch := benchmarkBatch(3)
+
+	var out bytes.Buffer
+	if err := encoder.Encode(&out, batch); err != nil {
+		t.Fatal(err)
+	}
+	if calls != len(batch) {
+		t.Errorf("Expected Marshal to be called %d times, got %d", len(batch), calls)
+	}
+	if !json.Valid(out.Bytes()) {
+		t.Errorf("Encoded batch is not valid JSON: %s", out.String())
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// memoryBudget bounds the estimated size of the events waiting in the queue
+type memoryBudget struct {
+	maxBytes int64
+	queued   atomic.Int64
+	shedding atomic.Bool // set while events are shed, so only the first is reported
+}
+
+// WithMemoryBudget bounds the queue by the estimated serialized size of its events as
+// well as by their count, so a burst of huge events can't balloon process memory.
+// Events that would take the queue past maxBytes go to the spill file, with WithSpill,
+// and are otherwise shed and counted as dropped. An event is always accepted by an
+// empty queue, however large.
+func WithMemoryBudget(maxBytes int64) Option {
+	return func(l *SEQLogger) {
+		l.memoryBudget = &memoryBudget{maxBytes: maxBytes}
+	}
+}
+
+// QueuedBytes returns the estimated size of the events waiting to be sent, or 0
+// without WithMemoryBudget
+func (l *SEQLogger) QueuedBytes() int64 {
+	if b := l.pipeline().memoryBudget; b != nil {
+		return b.queued.Load()
+	}
+	return 0
+}
+
+// reserve counts logMessage against the budget, reporting false, and counting
+// nothing, if it doesn't fit
+func (b *memoryBudget) reserve(logMessage *LogMessage) bool {
+	size := estimateSize(logMessage)
+	if queued := b.queued.Add(size); queued > b.maxBytes && queued != size {
+		b.queued.Add(-size)
+		return false
+	}
+	logMessage.size = size
+	if b.shedding.Load() {
+		b.shedding.Store(false)
+	}
+	return true
+}
+
+// release returns the budget of events taken off the queue
+func (b *memoryBudget) release(batch []LogMessage) {
+	var size int64
+	for i := range batch {
+		size += batch[i].size
+	}
+	if size > 0 {
+		b.queued.Add(-size)
+	}
+}
+
+// shed drops an event that didn't fit the budget
+func (l *SEQLogger) shed() {
+	l.dropped.Add(1)
+	if l.memoryBudget.shedding.CompareAndSwap(false, true) {
+		selfLogf("Shedding events: the queue holds %d bytes of its %d byte budget", l.memoryBudget.queued.Load(), l.memoryBudget.maxBytes)
+	}
+}
+
+// estimateSize approximates the serialized size of an event in bytes
+func estimateSize(logMessage *LogMessage) int64 {
+	size := int64(64 + len(logMessage.Timestamp) + len(logMessage.Level) + len(logMessage.MessageTemplate))
+	for key, value := range logMessage.Fields {
+		size += int64(len(key)+4) + estimateValueSize(value, 0)
+	}
+	for _, r := range logMessage.Renderings {
+		size += int64(len(r.Rendering) + 4)
+	}
+	return size
+}
+
+// maxEstimateDepth caps how deeply estimateValueSize descends; normalized values are
+// already depth limited, so it only guards against values built by hand
+const maxEstimateDepth = 16
+
+// estimateValueSize approximates the serialized size of a normalized property value
+func estimateValueSize(value interface{}, depth int) int64 {
+	switch v := value.(type) {
+	case nil, bool:
+		return 5
+	case string:
+		return int64(len(v) + 2)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return 8
+	case map[string]interface{}:
+		size := int64(2)
+		if depth < maxEstimateDepth {
+			for key, element := range v {
+				size += int64(len(key)+4) + estimateValueSize(element, depth+1)
+			}
+		}
+		return size
+	case []interface{}:
+		size := int64(2)
+		if depth < maxEstimateDepth {
+			for _, element := range v {
+				size += 1 + estimateValueSize(element, depth+1)
+			}
+		}
+		return size
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.String:
+		return int64(rv.Len() + 2)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return int64(2 + 8*rv.Len())
+	}
+	return 16
+}
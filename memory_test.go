@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMemoryBudgetShedsLargeEvents(t *testing.T) {
+	logger := newQueueLogger(100, WithMemoryBudget(4096))
+	payload := strings.Repeat("x", 1500)
+
+	for i := 0; i < 5; i++ {
+		logger.Log(LevelInformation, "Upload {Payload}", map[string]interface{}{"Payload": payload})
+	}
+	logger.Log(LevelInformation, "Small", nil)
+
+	if depth := logger.QueueDepth(); depth != 3 {
+		t.Errorf("Expected two large events and the small one within the budget, got %d events", depth)
+	}
+	if dropped := logger.dropped.Load(); dropped != 3 {
+		t.Errorf("Expected three events shed, got %d", dropped)
+	}
+	if queued := logger.QueuedBytes(); queued <= 3000 || queued > 4096 {
+		t.Errorf("Expected the queued bytes within the budget, got %d", queued)
+	}
+
+	batch := []LogMessage{<-logger.logChan, <-logger.logChan, <-logger.logChan}
+	logger.memoryBudget.release(batch)
+	if queued := logger.QueuedBytes(); queued != 0 {
+		t.Errorf("Expected the budget to be released, got %d bytes", queued)
+	}
+}
+
+func TestMemoryBudgetAcceptsOversizedEventIntoEmptyQueue(t *testing.T) {
+	logger := newQueueLogger(10, WithMemoryBudget(100))
+	logger.Log(LevelInformation, "Dump {Data}", map[string]interface{}{"Data": strings.Repeat("y", 1000)})
+	logger.Log(LevelInformation, "Dump {Data}", map[string]interface{}{"Data": "z"})
+
+	if depth := logger.QueueDepth(); depth != 1 {
+		t.Errorf("Expected only the first event, into the empty queue, got %d", depth)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+// Properties of the events logged by Count and Gauge
+const (
+	MetricNameProperty  = "MetricName"
+	MetricKindProperty  = "MetricKind"
+	MetricValueProperty = "MetricValue"
+)
+
+// Metric kinds
+const (
+	MetricCounter = "counter"
+	MetricGauge   = "gauge"
+)
+
+// The templates of metric events never change, so each kind shares one event type
+// and SEQ signals and dashboards can select them with e.g. MetricName = 'queue.depth'
+const (
+	counterTemplate = "Counter {" + MetricNameProperty + "} incremented by {" + MetricValueProperty + "}"
+	gaugeTemplate   = "Gauge {" + MetricNameProperty + "} is {" + MetricValueProperty + "}"
+)
+
+// Count logs an Information event adding delta to the counter name, such as
+// "orders.created"; fields, e.g. the dimensions the counter is broken down by, are
+// added to the event. SEQ charts a counter with sum(MetricValue).
+func (l *SEQLogger) Count(name string, delta float64, fields map[string]interface{}) {
+	l.logMetric(counterTemplate, MetricCounter, name, delta, fields)
+}
+
+// Gauge logs an Information event recording the current value of the gauge name, such
+// as "queue.depth". SEQ charts a gauge with mean(MetricValue) or max(MetricValue).
+func (l *SEQLogger) Gauge(name string, value float64) {
+	l.logMetric(gaugeTemplate, MetricGauge, name, value, nil)
+}
+
+// logMetric logs a metric event; the metric's own properties take precedence over fields
+func (l *SEQLogger) logMetric(template, kind, name string, value float64, fields map[string]interface{}) {
+	metric := make(map[string]interface{}, len(fields)+3)
+	for key, v := range fields {
+		metric[key] = v
+	}
+	metric[MetricNameProperty] = name
+	metric[MetricKindProperty] = kind
+	metric[MetricValueProperty] = value
+	l.Log(LevelInformation, template, metric)
+}
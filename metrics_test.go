@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestMetricEventsShareASchema(t *testing.T) {
+	logger := newQueueLogger(10)
+	logger.Count("orders.created", 2, map[string]interface{}{"Region": "eu", MetricValueProperty: "ignored"})
+	logger.Gauge("queue.depth", 17)
+	logger.Gauge("queue.depth", 3)
+
+	counter := <-logger.logChan
+	if counter.MessageTemplate != counterTemplate || counter.Level != LevelInformation {
+		t.Errorf("Unexpected counter event %+v", counter)
+	}
+	if counter.Fields[MetricNameProperty] != "orders.created" || counter.Fields[MetricKindProperty] != MetricCounter ||
+		counter.Fields[MetricValueProperty] != float64(2) || counter.Fields["Region"] != "eu" {
+		t.Errorf("Unexpected counter properties %v", counter.Fields)
+	}
+
+	first, second := <-logger.logChan, <-logger.logChan
+	if first.Fields[MetricKindProperty] != MetricGauge || first.Fields[MetricValueProperty] != float64(17) {
+		t.Errorf("Unexpected gauge properties %v", first.Fields)
+	}
+	if first.EventID != second.EventID || first.EventID == counter.EventID {
+		t.Errorf("Expected one event type per metric kind, got %x, %x and %x", counter.EventID, first.EventID, second.EventID)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import "time"
+
+// LogFunc is a step of the Log path, see Middleware
+type LogFunc func(level, message string, fields map[string]interface{})
+
+// Middleware wraps the Log path with a cross-cutting concern, like HTTP middleware:
+// it may change the level, template or fields before calling next, call next more
+// than once, or not at all to drop the event.
+type Middleware func(next LogFunc) LogFunc
+
+// WithMiddleware wraps every Log, through any logger of the pipeline, with middleware.
+// The first middleware is the outermost, called before the others, and every one of
+// them sees events before the level filters and sampling.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(l *SEQLogger) {
+		l.middleware = append(l.middleware, middleware...)
+	}
+}
+
+// logThroughMiddleware runs an event through the middleware chain, ending at logEvent
+func (l *SEQLogger) logThroughMiddleware(contextFields map[string]interface{}, timestamp time.Time, level, message string, fields map[string]interface{}) {
+	next := LogFunc(func(level, message string, fields map[string]interface{}) {
+		l.logEvent(contextFields, timestamp, level, message, fields)
+	})
+	for i := len(l.middleware) - 1; i >= 0; i-- {
+		next = l.middleware[i](next)
+	}
+	next(level, message, fields)
+}
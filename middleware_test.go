@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareRunsInOrder(t *testing.T) {
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next LogFunc) LogFunc {
+			return func(level, message string, fields map[string]interface{}) {
+				order = append(order, name)
+				next(level, message, fields)
+			}
+		}
+	}
+	dropHealthChecks := func(next LogFunc) LogFunc {
+		return func(level, message string, fields map[string]interface{}) {
+			if !strings.HasPrefix(message, "Health check") {
+				next(level, message, fields)
+			}
+		}
+	}
+	tag := func(next LogFunc) LogFunc {
+		return func(level, message string, fields map[string]interface{}) {
+			next(level, message, withField(fields, "Tagged", true))
+		}
+	}
+	logger := newQueueLogger(10, WithMiddleware(trace("outer"), trace("inner")), WithMiddleware(dropHealthChecks, tag))
+
+	logger.Named("api").Log(LevelInformation, "Health check passed", nil)
+	logger.Log(LevelInformation, "Order placed", nil)
+
+	if strings.Join(order, ",") != "outer,inner,outer,inner" {
+		t.Errorf("Unexpected middleware order %v", order)
+	}
+	if len(logger.logChan) != 1 {
+		t.Fatalf("Expected the health check to be dropped, got %d events", len(logger.logChan))
+	}
+	if placed := <-logger.logChan; placed.Fields["Tagged"] != true {
+		t.Errorf("Expected the middleware's field, got %v", placed.Fields)
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// The receivers decode MessagePack, the encoding of the Fluentd forward protocol,
+// with this minimal codec rather than a dependency. Maps decode to
+// map[string]interface{}, integers to int64 or uint64 and binary data to []byte.
+
+// maxMsgpackLength bounds the length of a single string, binary, array or map so a
+// corrupt or hostile length prefix can't allocate unbounded memory
+const maxMsgpackLength = 64 << 20
+
+// msgpackExt is a MessagePack extension value, such as Fluentd's EventTime
+type msgpackExt struct {
+	typ  int8
+	data []byte
+}
+
+// msgpackReader decodes MessagePack values from a stream
+type msgpackReader struct {
+	r   io.Reader
+	buf [8]byte
+}
+
+// decode reads the next value
+func (d *msgpackReader) decode() (interface{}, error) {
+	b, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b&0xf0 == 0x80:
+		return d.decodeMap(int(b & 0x0f))
+	case b&0xf0 == 0x90:
+		return d.decodeArray(int(b & 0x0f))
+	case b&0xe0 == 0xa0:
+		return d.str(int(b & 0x1f))
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.length(1 << (b - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return d.bytes(n)
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.length(1 << (b - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.ext(n)
+	case 0xca:
+		v, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(v))), err
+	case 0xcb:
+		v, err := d.uint(8)
+		return math.Float64frombits(v), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := d.uint(1 << (b - 0xcc))
+		if v <= math.MaxInt64 {
+			return int64(v), err
+		}
+		return v, err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (b - 0xd0)
+		v, err := d.uint(size)
+		// Sign-extend from the value's width
+		shift := 64 - 8*size
+		return int64(v<<shift) >> shift, err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(1 << (b - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.length(1 << (b - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(n)
+	case 0xdc, 0xdd:
+		n, err := d.length(2 << (b - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(n)
+	case 0xde, 0xdf:
+		n, err := d.length(2 << (b - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(n)
+	}
+	return nil, fmt.Errorf("invalid MessagePack type byte 0x%02x", b)
+}
+
+func (d *msgpackReader) byte() (byte, error) {
+	if _, err := io.ReadFull(d.r, d.buf[:1]); err != nil {
+		return 0, err
+	}
+	return d.buf[0], nil
+}
+
+// uint reads a big-endian unsigned integer of size bytes
+func (d *msgpackReader) uint(size int) (uint64, error) {
+	if _, err := io.ReadFull(d.r, d.buf[:size]); err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	var v uint64
+	for _, b := range d.buf[:size] {
+		v = v<<8 | uint64(b)
+	}
+	return v, nil
+}
+
+// length reads a length prefix of size bytes
+func (d *msgpackReader) length(size int) (int, error) {
+	n, err := d.uint(size)
+	if err != nil {
+		return 0, err
+	}
+	if n > maxMsgpackLength {
+		return 0, fmt.Errorf("MessagePack length %d exceeds the limit of %d", n, maxMsgpackLength)
+	}
+	return int(n), nil
+}
+
+func (d *msgpackReader) bytes(n int) ([]byte, error) {
+	data := make([]byte, n)
+	if _, err := io.ReadFull(d.r, data); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return data, nil
+}
+
+func (d *msgpackReader) str(n int) (string, error) {
+	data, err := d.bytes(n)
+	return string(data), err
+}
+
+func (d *msgpackReader) ext(n int) (msgpackExt, error) {
+	typ, err := d.byte()
+	if err != nil {
+		return msgpackExt{}, unexpectedEOF(err)
+	}
+	data, err := d.bytes(n)
+	return msgpackExt{typ: int8(typ), data: data}, err
+}
+
+func (d *msgpackReader) decodeArray(n int) ([]interface{}, error) {
+	// Grow with the data actually read rather than trusting the length prefix
+	array := make([]interface{}, 0, min(n, 1024))
+	for i := 0; i < n; i++ {
+		v, err := d.decode()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		array = append(array, v)
+	}
+	return array, nil
+}
+
+func (d *msgpackReader) decodeMap(n int) (map[string]interface{}, error) {
+	m := make(map[string]interface{}, min(n, 1024))
+	for i := 0; i < n; i++ {
+		k, err := d.decode()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		v, err := d.decode()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		key, ok := k.(string)
+		if !ok {
+			key = fmt.Sprint(k)
+		}
+		m[key] = v
+	}
+	return m, nil
+}
+
+// unexpectedEOF turns io.EOF in the middle of a value into io.ErrUnexpectedEOF
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// appendMsgpack appends the MessagePack encoding of v, which is built from the types
+// decode returns along with int and uint, to buf
+func appendMsgpack(buf []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, 0xc0)
+	case bool:
+		if v {
+			return append(buf, 0xc3)
+		}
+		return append(buf, 0xc2)
+	case int:
+		return appendMsgpackInt(buf, int64(v))
+	case int64:
+		return appendMsgpackInt(buf, v)
+	case uint:
+		return appendMsgpackUint(buf, uint64(v))
+	case uint64:
+		return appendMsgpackUint(buf, v)
+	case float64:
+		return binary.BigEndian.AppendUint64(append(buf, 0xcb), math.Float64bits(v))
+	case string:
+		buf = appendMsgpackHeader(buf, len(v), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		return append(buf, v...)
+	case []byte:
+		buf = appendMsgpackHeader(buf, len(v), 0, 0, 0xc4, 0xc5, 0xc6)
+		return append(buf, v...)
+	case msgpackExt:
+		buf = appendMsgpackHeader(buf, len(v.data), 0, 0, 0xc7, 0xc8, 0xc9)
+		return append(append(buf, byte(v.typ)), v.data...)
+	case []interface{}:
+		buf = appendMsgpackHeader(buf, len(v), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, e := range v {
+			buf = appendMsgpack(buf, e)
+		}
+		return buf
+	case map[string]interface{}:
+		buf = appendMsgpackHeader(buf, len(v), 0x80, 16, 0, 0xde, 0xdf)
+		for k, e := range v {
+			buf = appendMsgpack(appendMsgpack(buf, k), e)
+		}
+		return buf
+	}
+	return appendMsgpack(buf, fmt.Sprint(v))
+}
+
+func appendMsgpackInt(buf []byte, v int64) []byte {
+	if v >= 0 {
+		return appendMsgpackUint(buf, uint64(v))
+	}
+	if v >= -32 {
+		return append(buf, byte(v))
+	}
+	return binary.BigEndian.AppendUint64(append(buf, 0xd3), uint64(v))
+}
+
+func appendMsgpackUint(buf []byte, v uint64) []byte {
+	if v <= 0x7f {
+		return append(buf, byte(v))
+	}
+	return binary.BigEndian.AppendUint64(append(buf, 0xcf), v)
+}
+
+// appendMsgpackHeader appends the type and length of a value of n elements: the fix
+// type when n is below fixLimit, otherwise the smallest of the 8-, 16- and 32-bit length
+// forms; arrays and maps have no 8-bit form and pass 0 for it
+func appendMsgpackHeader(buf []byte, n int, fix byte, fixLimit int, type8, type16, type32 byte) []byte {
+	switch {
+	case n < fixLimit:
+		return append(buf, fix|byte(n))
+	case n <= math.MaxUint8 && type8 != 0:
+		return append(buf, type8, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, type16), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(buf, type32), uint32(n))
+}
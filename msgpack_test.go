@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	values := []interface{}{
+		nil, true, false,
+		int64(0), int64(127), int64(128), int64(-1), int64(-33), int64(1 << 40),
+		uint64(1 << 63), 1.5,
+		"", "short", strings.Repeat("x", 40), strings.Repeat("y", 300), strings.Repeat("z", 70000),
+		[]byte{1, 2, 3},
+		msgpackExt{typ: 0, data: []byte{0, 0, 0, 1, 0, 0, 0, 2}},
+		[]interface{}{"a", int64(1), []interface{}{nil}},
+		map[string]interface{}{"log": "line", "nested": map[string]interface{}{"n": int64(20)}},
+		make([]interface{}, 20),
+	}
+
+	for _, want := range values {
+		data := appendMsgpack(nil, want)
+		got, err := (&msgpackReader{r: bytes.NewReader(data)}).decode()
+		if err != nil {
+			t.Errorf("Failed to decode %v: %v", want, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Round trip of %v gave %v", want, got)
+		}
+	}
+}
+
+func TestMsgpackRejectsTruncatedAndOversizedValues(t *testing.T) {
+	inputs := [][]byte{
+		{0x92, 0x01},                   // array of 2 with a single element
+		{0xa5, 'a', 'b'},               // string of 5 with 2 bytes
+		{0xdb, 0xff, 0xff, 0xff, 0xff}, // 4GB string
+		{0xc1},                         // never used type byte
+	}
+	for _, input := range inputs {
+		if _, err := (&msgpackReader{r: bytes.NewReader(input)}).decode(); err == nil {
+			t.Errorf("Expected % x to be rejected", input)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+// Mutator rewrites events after they are built and before they are queued, e.g. to
+// downgrade known-noisy errors to Warning or rename legacy property keys during a migration
+type Mutator interface {
+	Mutate(logMessage *LogMessage)
+}
+
+// MutatorFunc adapts a function to the Mutator interface
+type MutatorFunc func(logMessage *LogMessage)
+
+// Mutate calls f(logMessage)
+func (f MutatorFunc) Mutate(logMessage *LogMessage) {
+	f(logMessage)
+}
+
+// WithMutator rewrites every event with mutator, after the mutators added before it.
+// Mutators may change the level, template and properties; a changed template gets
+// its event type and renderings recomputed. Events were already filtered by level.
+func WithMutator(mutator Mutator) Option {
+	return func(l *SEQLogger) {
+		l.mutators = append(l.mutators, mutator)
+	}
+}
+
+// mutate runs the mutators on a copy of the event's properties, so they can't change
+// the maps passed to Log. The event is taken and returned by value so that, without
+// mutators, log doesn't move it to the heap.
+func (l *SEQLogger) mutate(event LogMessage) LogMessage {
+	logMessage := &event
+	template := logMessage.MessageTemplate
+	logMessage.Fields = copyFields(logMessage.Fields)
+	if logMessage.Fields == nil {
+		logMessage.Fields = make(map[string]interface{})
+	}
+	for _, mutator := range l.mutators {
+		mutator.Mutate(logMessage)
+	}
+	if logMessage.MessageTemplate != template {
+		parsed := templates.get(logMessage.MessageTemplate)
+		logMessage.EventID = parsed.eventID
+		logMessage.Renderings = parsed.renderings(logMessage.Fields)
+	}
+	return event
+}
@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestMutatorsRewriteEvents(t *testing.T) {
+	downgrade := MutatorFunc(func(logMessage *LogMessage) {
+		if logMessage.Level == LevelError && logMessage.MessageTemplate == "Connection reset by {Peer}" {
+			logMessage.Level = LevelWarning
+		}
+	})
+	rename := MutatorFunc(func(logMessage *LogMessage) {
+		if user, ok := logMessage.Fields["user_id"]; ok {
+			delete(logMessage.Fields, "user_id")
+			logMessage.Fields["UserId"] = user
+			logMessage.MessageTemplate = "Login by {UserId}"
+		}
+	})
+	logger := newQueueLogger(10, WithMutator(downgrade), WithMutator(rename))
+
+	fields := map[string]interface{}{"user_id": 5}
+	logger.Log(LevelError, "Connection reset by {Peer}", map[string]interface{}{"Peer": "10.0.0.1"})
+	logger.Log(LevelInformation, "Login by {user_id}", fields)
+
+	if reset := <-logger.logChan; reset.Level != LevelWarning {
+		t.Errorf("Expected the error to be downgraded, got %s", reset.Level)
+	}
+	login := <-logger.logChan
+	if login.Fields["UserId"] != 5 || login.MessageTemplate != "Login by {UserId}" {
+		t.Errorf("Expected the property to be renamed, got %+v", login)
+	}
+	if login.EventID != templates.get("Login by {UserId}").eventID {
+		t.Errorf("Expected the event type of the new template, got %x", login.EventID)
+	}
+	if _, ok := fields["user_id"]; !ok {
+		t.Errorf("Expected the caller's fields to be left alone, got %v", fields)
+	}
+}
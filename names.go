@@ -0,0 +1,123 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// sanitizePropertyNames returns fields with keys that are safe to send as Seq property names.
+// Keys starting with '@' are escaped as '@@' per CLEF rules so they can't collide with
+// reserved fields, whitespace and control characters are replaced with '_', and keys that
+// differ only in case get a numeric suffix. The caller's map is never modified.
+func sanitizePropertyNames(fields map[string]interface{}) map[string]interface{} {
+	if !needsNameSanitizing(fields) {
+		return fields
+	}
+
+	// Keys that are already valid keep their names; sanitized keys are placed after them
+	var valid, invalid []string
+	for key := range fields {
+		if validPropertyName(key) {
+			valid = append(valid, key)
+		} else {
+			invalid = append(invalid, key)
+		}
+	}
+	sort.Strings(valid)
+	sort.Strings(invalid)
+
+	sanitized := make(map[string]interface{}, len(fields))
+	seen := make(map[string]struct{}, len(fields))
+	for _, key := range append(valid, invalid...) {
+		sanitized[uniquePropertyName(sanitizePropertyName(key), seen)] = fields[key]
+	}
+	return sanitized
+}
+
+// needsNameSanitizing reports whether any key of fields is invalid or collides with another ignoring case
+func needsNameSanitizing(fields map[string]interface{}) bool {
+	for key := range fields {
+		if !validPropertyName(key) {
+			return true
+		}
+	}
+
+	if len(fields) <= smallFieldCount {
+		return hasCaseCollision(fields)
+	}
+
+	lowered := make(map[string]struct{}, len(fields))
+	for key := range fields {
+		lower := strings.ToLower(key)
+		if _, ok := lowered[lower]; ok {
+			return true
+		}
+		lowered[lower] = struct{}{}
+	}
+	return false
+}
+
+// smallFieldCount is the field count up to which case collisions are found by pairwise comparison
+const smallFieldCount = 8
+
+// hasCaseCollision compares the keys of fields pairwise without allocating
+func hasCaseCollision(fields map[string]interface{}) bool {
+	var keys [smallFieldCount]string
+	n := 0
+	for key := range fields {
+		for _, other := range keys[:n] {
+			if strings.EqualFold(key, other) {
+				return true
+			}
+		}
+		keys[n] = key
+		n++
+	}
+	return false
+}
+
+// validPropertyName reports whether key can be sent unchanged
+func validPropertyName(key string) bool {
+	if key == "" || key[0] == '@' {
+		return false
+	}
+	for _, r := range key {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// sanitizePropertyName escapes a leading '@' and replaces whitespace and control characters in key
+func sanitizePropertyName(key string) string {
+	if key == "" {
+		return "_"
+	}
+
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, key)
+	if name[0] == '@' {
+		name = "@" + name
+	}
+	return name
+}
+
+// uniquePropertyName appends a numeric suffix to name until it no longer matches a seen name ignoring case
+func uniquePropertyName(name string, seen map[string]struct{}) string {
+	unique := name
+	for i := 2; ; i++ {
+		lower := strings.ToLower(unique)
+		if _, ok := seen[lower]; !ok {
+			seen[lower] = struct{}{}
+			return unique
+		}
+		unique = name + "_" + strconv.Itoa(i)
+	}
+}
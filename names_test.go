@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestSanitizePropertyNames(t *testing.T) {
+	fields := map[string]interface{}{
+		"@timestamp": "user value",
+		"user id":    1,
+		"UserId":     2,
+		"userid":     3,
+		"":           4,
+		"tab\there":  5,
+	}
+
+	got := sanitizePropertyNames(fields)
+
+	want := map[string]interface{}{
+		"@@timestamp": "user value",
+		"UserId":      2,
+		"userid_2":    3,
+		"user_id":     1,
+		"_":           4,
+		"tab_here":    5,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d properties, got %#v", len(want), got)
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("Property %q = %#v, want %#v (all: %#v)", key, got[key], value, got)
+		}
+	}
+	if _, ok := fields["@timestamp"]; !ok {
+		t.Errorf("Expected caller's map to be left untouched")
+	}
+}
+
+func TestSanitizePropertyNamesKeepsValidMap(t *testing.T) {
+	fields := map[string]interface{}{"version": "1.0.0", "UserId": 1}
+	if got := sanitizePropertyNames(fields); !sameMap(got, fields) {
+		t.Errorf("Expected the original map to be reused when all names are valid")
+	}
+}
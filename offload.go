@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// OffloadStore keeps the payloads WithOffload takes out of events. Store saves data,
+// whose SHA-256 hash is sum in hex, and returns a URL where it can be fetched.
+type OffloadStore interface {
+	Store(sum string, data []byte) (string, error)
+}
+
+// OffloadFunc adapts a function, e.g. one uploading to S3, to an OffloadStore
+type OffloadFunc func(sum string, data []byte) (string, error)
+
+// Store calls f
+func (f OffloadFunc) Store(sum string, data []byte) (string, error) {
+	return f(sum, data)
+}
+
+// FileStore is an OffloadStore saving payloads to a directory, as <hash>.bin
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a FileStore for dir, which is created when the first payload is stored
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+// Store writes data to the directory and returns its file:// URL. A payload stored
+// before, with the same hash, is not written again.
+func (s *FileStore) Store(sum string, data []byte) (string, error) {
+	path, err := filepath.Abs(filepath.Join(s.dir, sum+".bin"))
+	if err != nil {
+		return "", err
+	}
+	location := (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+	if _, err := os.Stat(path); err == nil {
+		return location, nil
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", fmt.Errorf("Failed to create offload directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, sum+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("Failed to create offload file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("Failed to write offload file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("Failed to write offload file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("Failed to write offload file: %w", err)
+	}
+	return location, nil
+}
+
+// offloader moves property values of at least threshold bytes to a store
+type offloader struct {
+	store     OffloadStore
+	threshold int
+}
+
+// WithOffload moves string, []byte and io.Reader properties of at least threshold
+// bytes, such as request bodies or stack dumps, to store and logs in their place
+// their Url, Length and SHA256 hash, keeping events small while the full data stays
+// available. Payloads are stored on the goroutine calling Log; one that fails to be
+// stored is reported through the self log and logged as it would be without offloading.
+func WithOffload(store OffloadStore, threshold int) Option {
+	return func(l *SEQLogger) {
+		l.offloader = &offloader{store: store, threshold: threshold}
+	}
+}
+
+// fields returns fields with the large values replaced by references to the store,
+// copying fields only if one is
+func (o *offloader) fields(fields map[string]interface{}) map[string]interface{} {
+	offloaded := fields
+	for key, value := range fields {
+		replaced, ok := o.value(key, value)
+		if !ok {
+			continue
+		}
+		if sameMap(offloaded, fields) {
+			offloaded = copyFields(fields)
+		}
+		offloaded[key] = replaced
+	}
+	return offloaded
+}
+
+// value offloads a single property value, reporting whether it was replaced
+func (o *offloader) value(key string, value interface{}) (interface{}, bool) {
+	var data []byte
+	spent := false // a reader read here is replaced by what it held whatever happens
+	switch v := value.(type) {
+	case string:
+		if len(v) < o.threshold {
+			return nil, false
+		}
+		data = []byte(v)
+	case []byte:
+		if len(v) < o.threshold {
+			return nil, false
+		}
+		data = v
+	case io.Reader:
+		if isNilPointer(v) {
+			return nil, false
+		}
+		read, err := io.ReadAll(v)
+		if err != nil {
+			selfLogf("Failed to read property %s to offload: %v", key, err)
+		}
+		if len(read) < o.threshold || err != nil {
+			return read, true
+		}
+		data, spent = read, true
+	default:
+		return nil, false
+	}
+
+	hash := sha256.Sum256(data)
+	sum := hex.EncodeToString(hash[:])
+	location, err := o.store.Store(sum, data)
+	if err != nil {
+		selfLogf("Failed to offload property %s: %v", key, err)
+		return data, spent
+	}
+	return map[string]interface{}{"Url": location, "Length": int64(len(data)), "SHA256": sum}, true
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestOffloadMovesLargeValuesToStore(t *testing.T) {
+	dir := t.TempDir()
+	logger := newQueueLogger(1, WithOffload(NewFileStore(dir), 16))
+
+	body := strings.Repeat("x", 64)
+	logger.Log(LevelInformation, "Request {Path} failed", map[string]interface{}{
+		"Path":  "/orders",
+		"Body":  body,
+		"Stack": strings.NewReader(body),
+	})
+
+	logMessage := <-logger.logChan
+	if logMessage.Fields["Path"] != "/orders" {
+		t.Errorf("Expected small values to stay, got %v", logMessage.Fields)
+	}
+	reference, ok := logMessage.Fields["Body"].(map[string]interface{})
+	if !ok || reference["Length"] != int64(64) || !strings.HasPrefix(reference["Url"].(string), "file://") {
+		t.Fatalf("Expected a reference to the offloaded body, got %#v", logMessage.Fields["Body"])
+	}
+	if stack := logMessage.Fields["Stack"].(map[string]interface{}); stack["SHA256"] != reference["SHA256"] {
+		t.Errorf("Expected equal payloads to share a hash, got %#v", stack)
+	}
+
+	stored, err := os.ReadFile(dir + "/" + reference["SHA256"].(string) + ".bin")
+	if err != nil || string(stored) != body {
+		t.Errorf("Expected the body in the store, got %q, %v", stored, err)
+	}
+}
+
+func TestOffloadFailureKeepsValue(t *testing.T) {
+	failing := OffloadFunc(func(sum string, data []byte) (string, error) {
+		return "", errors.New("bucket unavailable")
+	})
+	logger := newQueueLogger(1, WithOffload(failing, 4), WithMaxStringLength(8))
+	logger.Log(LevelInformation, "Dump", map[string]interface{}{"Dump": "0123456789"})
+
+	if logMessage := <-logger.logChan; logMessage.Fields["Dump"] != "01234…" || logMessage.Fields["Dump"+TruncatedSuffix] != true {
+		t.Errorf("Expected the value to be logged as without offloading, got %v", logMessage.Fields)
+	}
+}
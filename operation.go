@@ -0,0 +1,107 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Properties of the events logged by an Operation
+const (
+	// ElapsedProperty carries the duration of an operation in milliseconds
+	ElapsedProperty = "Elapsed"
+	// OutcomeProperty is OutcomeCompleted or OutcomeAbandoned
+	OutcomeProperty = "Outcome"
+	// ErrorProperty carries the error an operation was abandoned with
+	ErrorProperty = "Error"
+)
+
+// Outcomes of an Operation
+const (
+	OutcomeCompleted = "completed"
+	OutcomeAbandoned = "abandoned"
+)
+
+// Operation times a unit of work started with BeginOperation and logs how it ended,
+// as SerilogTimings does for .NET. The events share the template's properties, so
+// the start and the outcome of an operation can be found together in SEQ.
+type Operation struct {
+	logger   *SEQLogger
+	template string
+	started  time.Time
+	ended    atomic.Bool
+}
+
+// BeginOperation logs "<template> started" and returns an Operation timing it; args
+// are the values of the template's properties, in the order they appear:
+//
+//	op := logger.BeginOperation("Import {File}", file)
+//	if err := importFile(file); err != nil {
+//		op.Abandon(err)
+//		return err
+//	}
+//	op.Complete()
+func (l *SEQLogger) BeginOperation(template string, args ...interface{}) *Operation {
+	op := &Operation{
+		logger:   l.ForContext(templateArgs(template, args)),
+		template: template,
+		started:  time.Now(),
+	}
+	op.logger.Log(LevelInformation, template+" started", nil)
+	return op
+}
+
+// Logger returns the child logger the operation's events are logged through, to log
+// other events carrying the operation's properties
+func (o *Operation) Logger() *SEQLogger {
+	return o.logger
+}
+
+// Complete logs "<template> completed in <Elapsed> ms" at Information. Only the first
+// call to Complete or Abandon logs.
+func (o *Operation) Complete() {
+	o.end(LevelInformation, OutcomeCompleted, nil)
+}
+
+// Abandon logs "<template> abandoned in <Elapsed> ms" at Warning, with err, if it
+// isn't nil, as the Error property. Only the first call to Complete or Abandon logs.
+func (o *Operation) Abandon(err error) {
+	var fields map[string]interface{}
+	if err != nil {
+		fields = map[string]interface{}{ErrorProperty: err}
+	}
+	o.end(LevelWarning, OutcomeAbandoned, fields)
+}
+
+// end logs the outcome of the operation once
+func (o *Operation) end(level, outcome string, fields map[string]interface{}) {
+	if !o.ended.CompareAndSwap(false, true) {
+		return
+	}
+	elapsed := time.Since(o.started)
+	fields = withField(fields, OutcomeProperty, outcome)
+	fields = withField(fields, ElapsedProperty, float64(elapsed)/float64(time.Millisecond))
+	o.logger.Log(level, o.template+" {"+OutcomeProperty+"} in {"+ElapsedProperty+":0.0} ms", fields)
+}
+
+// templateArgs binds args to the distinct properties of template in order of
+// appearance; args beyond the properties are reported and dropped
+func templateArgs(template string, args []interface{}) map[string]interface{} {
+	if len(args) == 0 {
+		return nil
+	}
+	fields := make(map[string]interface{}, len(args))
+	for _, name := range templates.get(template).properties {
+		if len(args) == 0 {
+			break
+		}
+		if _, ok := fields[name]; ok {
+			continue
+		}
+		fields[name] = args[0]
+		args = args[1:]
+	}
+	if len(args) > 0 {
+		selfLogf("Template %q has fewer properties than the %d values given", template, len(fields)+len(args))
+	}
+	return fields
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOperationLogsStartAndCompletion(t *testing.T) {
+	logger := newQueueLogger(10)
+	op := logger.BeginOperation("Import {File} for {Tenant}", "orders.csv", "acme")
+
+	started := <-logger.logChan
+	if started.MessageTemplate != "Import {File} for {Tenant} started" || started.Fields["File"] != "orders.csv" || started.Fields["Tenant"] != "acme" {
+		t.Errorf("Unexpected start event %+v", started)
+	}
+
+	op.Logger().Log(LevelDebug, "Read {Rows} rows", map[string]interface{}{"Rows": 12})
+	if inner := <-logger.logChan; inner.Fields["File"] != "orders.csv" {
+		t.Errorf("Expected events logged through the operation to carry its properties, got %v", inner.Fields)
+	}
+
+	op.Complete()
+	op.Abandon(errors.New("too late"))
+	completed := <-logger.logChan
+	if completed.MessageTemplate != "Import {File} for {Tenant} {Outcome} in {Elapsed:0.0} ms" || completed.Level != LevelInformation {
+		t.Errorf("Unexpected completion event %+v", completed)
+	}
+	if completed.Fields[OutcomeProperty] != OutcomeCompleted || completed.Fields["File"] != "orders.csv" {
+		t.Errorf("Unexpected completion properties %v", completed.Fields)
+	}
+	if elapsed, ok := completed.Fields[ElapsedProperty].(float64); !ok || elapsed < 0 {
+		t.Errorf("Expected the elapsed milliseconds, got %#v", completed.Fields[ElapsedProperty])
+	}
+	if len(logger.logChan) != 0 {
+		t.Errorf("Expected only the first outcome to be logged, got %d more events", len(logger.logChan))
+	}
+}
+
+func TestOperationAbandon(t *testing.T) {
+	logger := newQueueLogger(10)
+	op := logger.BeginOperation("Import {File}", "orders.csv", "extra")
+	<-logger.logChan
+
+	op.Abandon(errors.New("disk full"))
+	abandoned := <-logger.logChan
+	if abandoned.Level != LevelWarning || abandoned.Fields[OutcomeProperty] != OutcomeAbandoned {
+		t.Errorf("Unexpected abandon event %+v", abandoned)
+	}
+	if abandoned.Fields[ErrorProperty] != "disk full" {
+		t.Errorf("Expected the error, got %#v", abandoned.Fields[ErrorProperty])
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Option configures a SEQLogger at construction time
+type Option func(*SEQLogger)
+
+// WithEncoder replaces the default RawEncoder used to serialize batches
+func WithEncoder(encoder Encoder) Option {
+	return func(l *SEQLogger) {
+		l.encoder = encoder
+	}
+}
+
+// WithMaxDepth sets how deeply nested field values, such as structs, are destructured
+func WithMaxDepth(depth int) Option {
+	return func(l *SEQLogger) {
+		l.normalizer.maxDepth = depth
+	}
+}
+
+// WithMaxProperties sets how many properties are kept per destructured struct, map or collection
+func WithMaxProperties(count int) Option {
+	return func(l *SEQLogger) {
+		l.normalizer.maxProperties = count
+	}
+}
+
+// WithMaxStringLength cuts strings longer than length bytes, at any depth, to length
+// bytes ending with an ellipsis, and adds a <key>_Truncated property set to true for
+// the top-level property holding each, so a single huge SQL text or payload dump
+// can't blow up a batch. Limits of 3 bytes or less leave no room for the ellipsis.
+func WithMaxStringLength(length int) Option {
+	return func(l *SEQLogger) {
+		l.normalizer.maxStringLength = length
+	}
+}
+
+// WithMaxBinaryLength sets the size up to which []byte and io.Reader values are base64
+// encoded into events; larger ones are recorded as their Length and SHA256 hash.
+// Readers are read to the end when the event is logged.
+func WithMaxBinaryLength(length int) Option {
+	return func(l *SEQLogger) {
+		l.normalizer.maxBinaryLength = length
+	}
+}
+
+// WithGlobalFields adds properties such as Application, Environment and Version to every event.
+// Fields passed to Log take precedence over global fields with the same name.
+func WithGlobalFields(fields map[string]interface{}) Option {
+	return func(l *SEQLogger) {
+		l.globalFields.update(func(globals map[string]interface{}) {
+			for key, value := range fields {
+				globals[key] = value
+			}
+		})
+	}
+}
+
+// WithMinLevel drops events less severe than level, e.g. LevelWarning
+func WithMinLevel(level string) Option {
+	return func(l *SEQLogger) {
+		l.minLevel.Store(int32(minLevelRank(level)))
+	}
+}
+
+// WithLevelOverride sets a different minimum level for events whose SourceContext
+// starts with prefix, e.g. "http.access" at Warning while "payments" logs at Debug
+func WithLevelOverride(prefix, level string) Option {
+	return func(l *SEQLogger) {
+		l.setLevelOverride(prefix, level)
+	}
+}
+
+// WithSampling keeps only the given fraction, between 0 and 1, of events at each level,
+// e.g. map[string]float64{LevelDebug: 0.1}; levels that aren't listed are always kept
+func WithSampling(rates map[string]float64) Option {
+	return func(l *SEQLogger) {
+		l.sampling.Store(newSamplingRates(rates))
+	}
+}
+
+// WithBatching sets the maximum number of events per request and how long to wait
+// for a batch to fill up; with a zero interval only already queued events are batched
+func WithBatching(size int, interval time.Duration) Option {
+	return func(l *SEQLogger) {
+		l.batchSize = size
+		l.batchInterval = interval
+	}
+}
+
+// WithRetry resends batches that failed with a network error, 429 or 5xx response
+// up to maxAttempts times in total, doubling the backoff between attempts up to maxBackoff
+func WithRetry(maxAttempts int, initialBackoff, maxBackoff time.Duration) Option {
+	return func(l *SEQLogger) {
+		l.retry = retryPolicy{
+			maxAttempts:    maxAttempts,
+			initialBackoff: initialBackoff,
+			maxBackoff:     maxBackoff,
+		}
+	}
+}
+
+// WithEnricher adds an Enricher run for every event, in the order the enrichers were added
+func WithEnricher(enricher Enricher) Option {
+	return func(l *SEQLogger) {
+		l.enrichers = append(l.enrichers, enricher)
+	}
+}
+
+// WithSink routes events at or above minLevel to sink in addition to the SEQ server,
+// e.g. Error and Fatal events to a file or an alerting webhook
+func WithSink(sink Sink, minLevel string) Option {
+	return func(l *SEQLogger) {
+		l.sinks = append(l.sinks, routedSink{sink: sink, minLevel: minLevelRank(minLevel)})
+	}
+}
+
+// WithSeqMinLevel sends only events at or above level to the SEQ server, while sinks
+// with a lower minimum level still receive the less severe events
+func WithSeqMinLevel(level string) Option {
+	return func(l *SEQLogger) {
+		l.seqMinLevel = minLevelRank(level)
+	}
+}
+
+// WithAuditDir sets the directory where Audit events are persisted until Seq confirms them
+func WithAuditDir(dir string) Option {
+	return func(l *SEQLogger) {
+		l.auditDir = dir
+	}
+}
+
+// WithWAL persists every event to a write-ahead log in dir before Log returns and
+// replays the events Seq never acknowledged when the logger is next created,
+// giving at-least-once delivery across crashes
+func WithWAL(dir string) Option {
+	return func(l *SEQLogger) {
+		l.walDir = dir
+	}
+}
+
+// WithEventTokens adds a unique EventToken property to every event even when neither
+// retries nor a WAL are enabled, e.g. when another process may replay the events
+func WithEventTokens() Option {
+	return func(l *SEQLogger) {
+		l.eventTokens = true
+	}
+}
+
+// WithSequenceNumbers adds a SequenceNumber property that increases by one with every
+// event the logger accepts, so events with identical timestamps can be strictly ordered
+// and gaps in the sequence reveal dropped events
+func WithSequenceNumbers() Option {
+	return func(l *SEQLogger) {
+		l.sequenceNumbers = true
+	}
+}
+
+// WithWatermark calls fn once when the queue fills past fraction of its capacity, e.g. 0.8,
+// so the application can shed load or start sampling before Log blocks. fn runs on the
+// goroutine calling Log and is called again only after the queue has drained below the mark.
+func WithWatermark(fraction float64, fn func(depth, capacity int)) Option {
+	return func(l *SEQLogger) {
+		l.watermarks = append(l.watermarks, &watermark{fraction: fraction, fn: fn})
+	}
+}
+
+// WithHealthEvents sends a Warning to Seq when the queue is fuller than threshold,
+// e.g. 0.8, or events have been dropped, at most once per interval (a minute when 0)
+func WithHealthEvents(threshold float64, interval time.Duration) Option {
+	return func(l *SEQLogger) {
+		if interval <= 0 {
+			interval = defaultHealthInterval
+		}
+		l.health = &healthMonitor{threshold: threshold, interval: interval}
+	}
+}
+
+// WithTransport sends through a Transport shared with other loggers instead of a
+// connection pool of the logger's own
+func WithTransport(transport *Transport) Option {
+	return func(l *SEQLogger) {
+		l.transport = transport
+	}
+}
+
+// WithTemplateLint reports through the self log, once per template and property,
+// placeholders with no matching property and fields passed to Log that no placeholder
+// uses. It is meant for development, where it catches broken structured logging early.
+func WithTemplateLint() Option {
+	return func(l *SEQLogger) {
+		l.lintTemplates = true
+	}
+}
+
+// WithFallback writes the batches that could not be delivered to Seq, once retries are
+// exhausted, to sink instead of the local log, e.g. NewWriterSink(os.Stderr, nil) for
+// CLEF lines that container log collectors still capture in a machine-parseable form
+func WithFallback(sink Sink) Option {
+	return func(l *SEQLogger) {
+		l.fallback = sink
+	}
+}
+
+// WithStderrFallback writes the batches that could not be delivered to stderr as CLEF JSON lines
+func WithStderrFallback() Option {
+	return WithFallback(NewWriterSink(os.Stderr, CLEFEncoder{}))
+}
+
+// WithSpill overflows events to a file at path, holding at most maxBytes, when the
+// queue is full instead of blocking Log; they are moved back into the queue as it
+// drains. Log blocks again only once the spill file is full too.
+func WithSpill(path string, maxBytes int64) Option {
+	return func(l *SEQLogger) {
+		l.spillPath = path
+		l.spillMaxBytes = maxBytes
+	}
+}
+
+// WithCompression compresses request bodies of at least threshold bytes with the named
+// algorithm, "gzip", "zstd" or "none"; a threshold <= 0 uses 1KB. NewSEQLogger returns a
+// *ConfigError for an unknown algorithm.
+func WithCompression(algorithm string, threshold int) Option {
+	return func(l *SEQLogger) {
+		c, err := newCompression(algorithm, threshold)
+		if err != nil {
+			l.optionErrs = append(l.optionErrs, &ConfigError{Setting: "WithCompression", Reason: fmt.Sprintf("algorithm %q is unknown", algorithm)})
+			return
+		}
+		l.compression = c
+	}
+}
+
+// WithAdaptivePacing limits ingestion requests to maxRate per second, halving the rate
+// each time the server answers 429 and recovering gradually as requests succeed, so a
+// rate-limited logger buffers events instead of spending quota on rejected requests
+func WithAdaptivePacing(maxRate float64) Option {
+	return func(l *SEQLogger) {
+		l.pacer = newPacer(maxRate)
+	}
+}
+
+// WithOnAuthError calls fn whenever the server answers 401 or 403, e.g. because the API
+// key expired. Such batches are not retried and go to the fallback straight away.
+func WithOnAuthError(fn func(err *AuthError)) Option {
+	return func(l *SEQLogger) {
+		l.onAuthError = fn
+	}
+}
+
+// WithBeforeSend calls fn with each ingestion request and its body, the encoded and
+// compressed batch, before it is sent, e.g. to sign it or add headers. An error from
+// fn fails the batch without retrying it.
+func WithBeforeSend(fn func(req *http.Request, body []byte) error) Option {
+	return func(l *SEQLogger) {
+		l.beforeSend = fn
+	}
+}
+
+// WithAfterSend calls fn after each ingestion request, retries included, with the
+// response or the error that prevented one, e.g. to audit deliveries or record
+// metrics. fn must not read or close the response body.
+func WithAfterSend(fn func(req *http.Request, resp *http.Response, err error)) Option {
+	return func(l *SEQLogger) {
+		l.afterSend = fn
+	}
+}
+
+// WithEndpoint skips endpoint negotiation for a base server URL and appends endpoint,
+// EndpointCLEF or EndpointRaw, to it instead
+func WithEndpoint(endpoint string) Option {
+	return func(l *SEQLogger) {
+		l.endpoint = endpoint
+	}
+}
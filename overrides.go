@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// SourceContextProperty is the property naming the component an event comes from
+const SourceContextProperty = "SourceContext"
+
+// levelOverride is the minimum level rank applied to one SourceContext prefix
+type levelOverride struct {
+	prefix   string
+	minLevel int
+}
+
+// levelOverrides holds the overrides sorted from the longest prefix to the shortest,
+// so the most specific match wins
+type levelOverrides []levelOverride
+
+// newLevelOverrides builds levelOverrides from a prefix to level map
+func newLevelOverrides(overrides map[string]string) *levelOverrides {
+	sorted := make(levelOverrides, 0, len(overrides))
+	for prefix, level := range overrides {
+		sorted = append(sorted, levelOverride{prefix: prefix, minLevel: minLevelRank(level)})
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i].prefix) > len(sorted[j].prefix) })
+	return &sorted
+}
+
+// minLevelFor returns the overridden minimum level for sourceContext, matching whole
+// dot-separated segments so "http" covers "http.access" but not "httpx"
+func (o levelOverrides) minLevelFor(sourceContext string) (int, bool) {
+	for _, override := range o {
+		if !strings.HasPrefix(sourceContext, override.prefix) {
+			continue
+		}
+		if len(sourceContext) == len(override.prefix) || sourceContext[len(override.prefix)] == '.' {
+			return override.minLevel, true
+		}
+	}
+	return 0, false
+}
+
+// setLevelOverride adds or replaces the override for prefix
+func (l *SEQLogger) setLevelOverride(prefix, level string) {
+	l.overridesMu.Lock()
+	defer l.overridesMu.Unlock()
+	overrides := l.currentOverrides()
+	overrides[prefix] = level
+	l.levelOverrides.Store(newLevelOverrides(overrides))
+}
+
+// replaceConfigOverrides swaps the level overrides that came from a configuration
+// file, keeping those set with WithLevelOverride or the debug handler
+func (l *SEQLogger) replaceConfigOverrides(configured map[string]string) {
+	l.overridesMu.Lock()
+	defer l.overridesMu.Unlock()
+	overrides := l.currentOverrides()
+	for prefix := range l.configOverrides {
+		delete(overrides, prefix)
+	}
+	for prefix, level := range configured {
+		overrides[prefix] = level
+	}
+	l.levelOverrides.Store(newLevelOverrides(overrides))
+	l.configOverrides = copyOverrides(configured)
+}
+
+// currentOverrides returns the level overrides in effect as a prefix to level map
+func (l *SEQLogger) currentOverrides() map[string]string {
+	overrides := make(map[string]string)
+	if current := l.levelOverrides.Load(); current != nil {
+		for _, override := range *current {
+			overrides[override.prefix] = levelNames[override.minLevel]
+		}
+	}
+	return overrides
+}
+
+// copyOverrides returns a copy of a prefix to level map
+func copyOverrides(overrides map[string]string) map[string]string {
+	copied := make(map[string]string, len(overrides))
+	for prefix, level := range overrides {
+		copied[prefix] = level
+	}
+	return copied
+}
+
+// enabled reports whether an event at rank passes the minimum level, taking the level
+// overrides for the event's SourceContext and the server's level feedback into account
+func (l *SEQLogger) enabled(rank int, fields, contextFields map[string]interface{}) bool {
+	if int32(rank) < l.serverMinLevel.Load() {
+		return false
+	}
+	overrides := l.levelOverrides.Load()
+	if overrides == nil || len(*overrides) == 0 {
+		return int32(rank) >= l.minLevel.Load()
+	}
+
+	sourceContext, ok := fields[SourceContextProperty].(string)
+	if !ok {
+		sourceContext, ok = contextFields[SourceContextProperty].(string)
+	}
+	if !ok {
+		sourceContext, _ = l.globalFields.load()[SourceContextProperty].(string)
+	}
+	if minLevel, ok := overrides.minLevelFor(sourceContext); ok {
+		return rank >= minLevel
+	}
+	return int32(rank) >= l.minLevel.Load()
+}
+
+// IsEnabled reports whether an event at level logged through l would pass the minimum
+// level and the override for l's SourceContext, so callers can skip building fields
+// for events that would be dropped. Sampling, being random, is not taken into account.
+func (l *SEQLogger) IsEnabled(level string) bool {
+	root := l.pipeline()
+	if root.closed.Load() {
+		return false
+	}
+	return root.enabled(levelRank(level), nil, l.contextFields)
+}
+
+// Logger is the logging side of a SEQLogger and its child loggers, for code that logs
+// through whichever it is handed, or through a test double
+type Logger interface {
+	Log(level, message string, fields map[string]interface{})
+	LogCtx(ctx context.Context, level, message string, fields map[string]interface{})
+	Error(err error, message string, fields map[string]interface{})
+	IsEnabled(level string) bool
+}
+
+// IfEnabled calls fn with l when IsEnabled(level), e.g. to compute and log diagnostics
+// only when Debug events are kept
+func (l *SEQLogger) IfEnabled(level string, fn func(l Logger)) {
+	if l.IsEnabled(level) {
+		fn(l)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import "testing"
+
+func TestLevelOverridesBySourceContext(t *testing.T) {
+	logger := newQueueLogger(10,
+		WithMinLevel(LevelInformation),
+		WithLevelOverride("http.access", LevelWarning),
+		WithLevelOverride("payments", LevelDebug),
+	)
+
+	tests := []struct {
+		sourceContext string
+		level         string
+		want          bool
+	}{
+		{"http.access", LevelInformation, false},
+		{"http.access.v2", LevelWarning, true},
+		{"http.accessor", LevelInformation, true},
+		{"payments", LevelDebug, true},
+		{"payments.worker", LevelDebug, true},
+		{"payments.worker", LevelVerbose, false},
+		{"inventory", LevelDebug, false},
+		{"", LevelInformation, true},
+	}
+
+	for _, test := range tests {
+		fields := map[string]interface{}{SourceContextProperty: test.sourceContext}
+		if got := logger.enabled(levelRank(test.level), fields, nil); got != test.want {
+			t.Errorf("enabled(%s, %q) = %v, want %v", test.level, test.sourceContext, got, test.want)
+		}
+	}
+}
+
+func TestLevelOverrideFromGlobalSourceContext(t *testing.T) {
+	logger := newQueueLogger(1,
+		WithGlobalFields(map[string]interface{}{SourceContextProperty: "payments"}),
+		WithMinLevel(LevelWarning),
+		WithLevelOverride("payments", LevelDebug),
+	)
+
+	logger.Log(LevelDebug, "Charging card", nil)
+	if len(logger.logChan) != 1 {
+		t.Errorf("Expected the override to apply to the global SourceContext")
+	}
+}
+
+func TestIsEnabledFollowsChildSourceContext(t *testing.T) {
+	logger := newQueueLogger(1, WithMinLevel(LevelInformation), WithLevelOverride("payments", LevelDebug))
+
+	if logger.IsEnabled(LevelDebug) || !logger.IsEnabled(LevelWarning) {
+		t.Error("Expected the root to follow the minimum level")
+	}
+	payments := logger.Named("payments")
+	if !payments.IsEnabled(LevelDebug) || payments.IsEnabled(LevelVerbose) {
+		t.Error("Expected the child to follow its SourceContext's override")
+	}
+
+	called := false
+	logger.IfEnabled(LevelDebug, func(Logger) { called = true })
+	if called {
+		t.Error("Expected IfEnabled to skip a filtered level")
+	}
+	payments.IfEnabled(LevelDebug, func(l Logger) {
+		called = l == payments
+	})
+	if !called {
+		t.Error("Expected IfEnabled to call fn with the logger")
+	}
+
+	logger.closed.Store(true)
+	if logger.IsEnabled(LevelFatal) {
+		t.Error("Expected nothing to be enabled once the logger is closed")
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// minPacingRate is the slowest a pacer throttles to, in requests per second
+const minPacingRate = 0.1
+
+// pacer is a token bucket limiting ingestion requests per second. The rate is tuned by
+// the server's feedback: it halves on each 429 and recovers additively with each
+// accepted request, up to maxRate. While the rate is low, events wait in the queue
+// rather than burning quota on requests that will be rejected.
+type pacer struct {
+	mu      sync.Mutex
+	rate    float64
+	maxRate float64
+	tokens  float64
+	last    time.Time
+}
+
+// newPacer creates a pacer allowing up to maxRate requests per second
+func newPacer(maxRate float64) *pacer {
+	return &pacer{rate: maxRate, maxRate: maxRate, tokens: 1, last: time.Now()}
+}
+
+// wait takes a token, waiting for one to become available
+func (p *pacer) wait(ctx context.Context) error {
+	for {
+		delay := p.take(time.Now())
+		if delay <= 0 {
+			return nil
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// take takes a token if one is available, otherwise it returns how long until one is
+func (p *pacer) take(now time.Time) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Refill at the current rate, allowing a burst of up to one second's worth
+	burst := p.rate
+	if burst < 1 {
+		burst = 1
+	}
+	p.tokens += now.Sub(p.last).Seconds() * p.rate
+	if p.tokens > burst {
+		p.tokens = burst
+	}
+	p.last = now
+
+	if p.tokens >= 1 {
+		p.tokens--
+		return 0
+	}
+	return time.Duration((1 - p.tokens) / p.rate * float64(time.Second))
+}
+
+// throttled halves the rate after the server rejected a request with 429
+func (p *pacer) throttled() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rate /= 2
+	if p.rate < minPacingRate {
+		p.rate = minPacingRate
+	}
+}
+
+// accepted raises the rate by a twentieth of maxRate after a successful request
+func (p *pacer) accepted() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rate += p.maxRate / 20
+	if p.rate > p.maxRate {
+		p.rate = p.maxRate
+	}
+}
+
+// currentRate returns the rate in requests per second
+func (p *pacer) currentRate() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.rate
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPacerAdaptsToRateLimiting(t *testing.T) {
+	p := newPacer(8)
+	p.throttled()
+	p.throttled()
+	if rate := p.currentRate(); rate != 2 {
+		t.Fatalf("Expected two 429s to quarter the rate to 2, got %v", rate)
+	}
+
+	for i := 0; i < 100; i++ {
+		p.accepted()
+	}
+	if rate := p.currentRate(); rate != 8 {
+		t.Errorf("Expected the rate to recover to its maximum of 8, got %v", rate)
+	}
+
+	for i := 0; i < 100; i++ {
+		p.throttled()
+	}
+	if rate := p.currentRate(); rate != minPacingRate {
+		t.Errorf("Expected the rate to bottom out at %v, got %v", minPacingRate, rate)
+	}
+}
+
+func TestPacerSpacesRequests(t *testing.T) {
+	p := newPacer(2)
+	now := p.last
+
+	if delay := p.take(now); delay != 0 {
+		t.Fatalf("Expected the first request to go immediately, waited %v", delay)
+	}
+	if delay := p.take(now); delay != 500*time.Millisecond {
+		t.Errorf("Expected to wait 500ms for the next token at 2 requests per second, got %v", delay)
+	}
+	if delay := p.take(now.Add(500 * time.Millisecond)); delay != 0 {
+		t.Errorf("Expected a token after 500ms, got a wait of %v", delay)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// pipeMapping names the keys seqlog pipe reads an event's timestamp, level and message
+// from. Each is a comma-separated list of candidates, the first one present wins.
+type pipeMapping struct {
+	timestampKeys string
+	levelKeys     string
+	messageKeys   string
+	defaultLevel  string
+}
+
+// register adds the mapping flags of seqlog pipe to fs
+func (m *pipeMapping) register(fs *flag.FlagSet) {
+	fs.StringVar(&m.timestampKeys, "timestamp-key", "@t,timestamp,time,ts", "keys holding the timestamp, RFC 3339 or Unix seconds or milliseconds")
+	fs.StringVar(&m.levelKeys, "level-key", "@l,level,lvl,severity", "keys holding the level")
+	fs.StringVar(&m.messageKeys, "message-key", "@m,message,msg,log", "keys holding the message")
+	fs.StringVar(&m.defaultLevel, "level", LevelInformation, "level of events without one")
+}
+
+// defaultPipeMapping returns the mapping of the pipe subcommand with its default flags
+func defaultPipeMapping() pipeMapping {
+	var mapping pipeMapping
+	mapping.register(flag.NewFlagSet("", flag.ContinueOnError))
+	return mapping
+}
+
+// runPipe forwards every line of stdin as an event until stdin is closed, then flushes.
+// JSON objects are mapped with mapping and any other line is sent as the message.
+func runPipe(ctx context.Context, options *cliOptions, mapping *pipeMapping, stdin io.Reader) error {
+	logger, closeLogger, err := options.daemonLogger(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeLogger()
+
+	err = scanLines(stdin, func(line int, text []byte) {
+		event := mapping.event(text)
+		logger.LogAt(event.timestamp, event.level, event.template, event.fields)
+	})
+	if err != nil {
+		return err
+	}
+	return logger.Flush(ctx)
+}
+
+// event maps a line to an event, JSON objects with object and any other line as the message
+func (m *pipeMapping) event(line []byte) ingestEvent {
+	var object map[string]interface{}
+	if json.Unmarshal(line, &object) != nil || object == nil {
+		return ingestEvent{level: m.defaultLevel, template: escapeTemplate(strings.TrimSpace(string(line)))}
+	}
+	event := m.object(object)
+	if event.template == "" {
+		// Seq needs a message; the properties still carry the whole line
+		event.template = escapeTemplate(strings.TrimSpace(string(line)))
+	}
+	return event
+}
+
+// object maps a record to an event, leaving the template empty when the record has no
+// message. The mapped keys are removed from the properties, unless their values can't
+// be used, e.g. a timestamp that doesn't parse.
+func (m *pipeMapping) object(object map[string]interface{}) ingestEvent {
+	event := ingestEvent{level: m.defaultLevel}
+	if key, value, ok := firstKey(object, m.timestampKeys); ok {
+		if t, ok := parsePipeTimestamp(value); ok {
+			event.timestamp = t
+			delete(object, key)
+		}
+	}
+	if key, value, ok := firstKey(object, m.levelKeys); ok {
+		if level, ok := value.(string); ok && level != "" {
+			event.level = canonicalLevel(level)
+			delete(object, key)
+		}
+	}
+	if key, value, ok := firstKey(object, m.messageKeys); ok {
+		if message, ok := value.(string); ok && message != "" {
+			event.template = escapeTemplate(message)
+			delete(object, key)
+		}
+	}
+	event.fields = object
+	return event
+}
+
+// firstKey returns the first of the comma-separated keys present in object
+func firstKey(object map[string]interface{}, keys string) (string, interface{}, bool) {
+	for _, key := range strings.Split(keys, ",") {
+		key = strings.TrimSpace(key)
+		if value, ok := object[key]; ok && key != "" {
+			return key, value, true
+		}
+	}
+	return "", nil, false
+}
+
+// parsePipeTimestamp reads an RFC 3339 string or a Unix time in seconds, or in
+// milliseconds for values too large to be seconds
+func parsePipeTimestamp(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return t, err == nil
+	case float64:
+		if v <= 0 || math.IsInf(v, 0) {
+			return time.Time{}, false
+		}
+		if v >= 1e12 {
+			return time.UnixMilli(int64(v)), true
+		}
+		sec, frac := math.Modf(v)
+		return time.Unix(int64(sec), int64(frac*1e9)), true
+	}
+	return time.Time{}, false
+}
+
+// canonicalLevel maps level aliases such as "warn" or "ERR" to SEQ's level names
+// and leaves unknown levels as they are
+func canonicalLevel(level string) string {
+	if rank, ok := knownLevelRank(level); ok {
+		return levelNames[rank]
+	}
+	return level
+}
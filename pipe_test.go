@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newPipeMapping returns the mapping of seqlog pipe parsed from args
+func newPipeMapping(t *testing.T, args ...string) *pipeMapping {
+	t.Helper()
+	var mapping pipeMapping
+	fs := flag.NewFlagSet("pipe", flag.ContinueOnError)
+	mapping.register(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return &mapping
+}
+
+func TestPipeMapsDefaultKeys(t *testing.T) {
+	event := newPipeMapping(t).event([]byte(`{"time": "2024-01-02T03:04:05Z", "level": "warn", "msg": "Cache {miss}", "key": "user:7"}`))
+
+	if !event.timestamp.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) || event.level != LevelWarning || event.template != "Cache {{miss}}" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	if len(event.fields) != 1 || event.fields["key"] != "user:7" {
+		t.Errorf("Expected only the unmapped key as a property, got %v", event.fields)
+	}
+}
+
+func TestPipeMapsConfiguredKeys(t *testing.T) {
+	mapping := newPipeMapping(t, "-timestamp-key", "when", "-level-key", "sev", "-message-key", "text", "-level", "Debug")
+
+	event := mapping.event([]byte(`{"when": 1704164645, "text": "Started", "level": "Error"}`))
+	if !event.timestamp.Equal(time.Unix(1704164645, 0)) || event.level != LevelDebug || event.template != "Started" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	if event.fields["level"] != "Error" {
+		t.Errorf("Expected the unmapped level key to stay a property, got %v", event.fields)
+	}
+
+	if millis := mapping.event([]byte(`{"when": 1704164645123, "text": "x"}`)); !millis.timestamp.Equal(time.UnixMilli(1704164645123)) {
+		t.Errorf("Expected a millisecond timestamp, got %v", millis.timestamp)
+	}
+}
+
+func TestPipeForwardsPlainText(t *testing.T) {
+	event := newPipeMapping(t).event([]byte("panic: {oops}\n"))
+	if event.template != "panic: {{oops}}" || event.level != LevelInformation || event.fields != nil {
+		t.Errorf("Unexpected event %+v", event)
+	}
+}
+
+func TestCLIPipe(t *testing.T) {
+	seq := newSeqRecorder(t)
+	stdin := strings.NewReader("{\"msg\": \"Request served\", \"status\": 200}\nplain text line\n")
+
+	var stderr bytes.Buffer
+	if code := runCLI(context.Background(), []string{"pipe", "-server", seq.URL + EndpointRaw}, stdin, &bytes.Buffer{}, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	received := seq.received()
+	if !strings.Contains(received, "Request served") || !strings.Contains(received, `"status":200`) || !strings.Contains(received, "plain text line") {
+		t.Errorf("Expected both lines to be forwarded, got %s", received)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// maxPooledBufferSize is the largest buffer capacity returned to the pool; bigger buffers are left to the GC
+const maxPooledBufferSize = 1 << 20
+
+// encodeBuffer pairs a reusable bytes.Buffer with a json.Encoder writing into it
+// and the event and properties maps reused by RawEncoder for events with fields
+type encodeBuffer struct {
+	bytes.Buffer
+	enc        *json.Encoder
+	event      map[string]interface{}
+	properties map[string]interface{}
+}
+
+// encodeBufferPool shares encode buffers across batches and loggers to cut GC pressure
+var encodeBufferPool = sync.Pool{
+	New: func() interface{} {
+		b := &encodeBuffer{event: make(map[string]interface{}, 4), properties: make(map[string]interface{})}
+		b.enc = json.NewEncoder(&b.Buffer)
+		return b
+	},
+}
+
+// getEncodeBuffer takes an empty encode buffer from the pool
+func getEncodeBuffer() *encodeBuffer {
+	b := encodeBufferPool.Get().(*encodeBuffer)
+	b.Reset()
+	return b
+}
+
+// putEncodeBuffer returns an encode buffer to the pool unless it has grown too large
+func putEncodeBuffer(b *encodeBuffer) {
+	if b.Cap() > maxPooledBufferSize {
+		return
+	}
+	// Drop references to the last event's fields so the pool doesn't keep them alive
+	clear(b.event)
+	clear(b.properties)
+	encodeBufferPool.Put(b)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"time"
+)
+
+// pressureFlushTimeout bounds the early flush started under memory pressure
+const pressureFlushTimeout = 10 * time.Second
+
+// memoryPressure flushes early and samples events while the process nears its memory limit
+type memoryPressure struct {
+	fraction float64
+	rates    *samplingRates // applied under pressure
+	saved    *samplingRates // the rates in force before, restored after
+	active   bool           // only touched by the finalizer goroutine running checkMemory
+}
+
+// WithMemoryPressure watches, after every garbage collection, how close the process
+// is to the memory limit set with debug.SetMemoryLimit or GOMEMLIMIT. Once it uses more
+// than fraction of it, queued events are flushed straight away and new ones sampled
+// with sampling, as with WithSampling, favoring delivery over buffering; the previous
+// sampling returns once usage falls back below fraction. Without a memory limit it
+// does nothing.
+func WithMemoryPressure(fraction float64, sampling map[string]float64) Option {
+	return func(l *SEQLogger) {
+		l.pressure = &memoryPressure{fraction: fraction, rates: newSamplingRates(sampling)}
+	}
+}
+
+// gcSentinel is the object whose finalizer watchMemory sets. The pointer and size keep
+// it out of the tiny allocator, which packs small pointer-free objects into shared
+// blocks whose finalizers may never run.
+type gcSentinel struct {
+	_ [16]byte
+	p *int
+}
+
+// watchMemory calls checkMemory after every garbage collection until the logger is
+// closed, through the finalizer of an object dropped as soon as it is set
+func (l *SEQLogger) watchMemory() {
+	runtime.SetFinalizer(&gcSentinel{}, func(*gcSentinel) {
+		if l.closed.Load() {
+			return
+		}
+		l.checkMemory()
+		l.watchMemory()
+	})
+}
+
+// memorySamples are the runtime metrics whose difference is the memory counted against the limit
+var memorySamples = []string{"/memory/classes/total:bytes", "/memory/classes/heap/released:bytes"}
+
+// checkMemory reads the memory in use and reacts to the pressure it puts on the limit
+func (l *SEQLogger) checkMemory() {
+	limit := debug.SetMemoryLimit(-1)
+	if limit <= 0 || limit == math.MaxInt64 {
+		return
+	}
+	samples := make([]metrics.Sample, len(memorySamples))
+	for i, name := range memorySamples {
+		samples[i].Name = name
+	}
+	metrics.Read(samples)
+	if samples[0].Value.Kind() != metrics.KindUint64 || samples[1].Value.Kind() != metrics.KindUint64 {
+		return
+	}
+	l.memoryUsage(samples[0].Value.Uint64()-samples[1].Value.Uint64(), uint64(limit))
+}
+
+// memoryUsage starts or ends the pressure response as used crosses fraction of limit
+func (l *SEQLogger) memoryUsage(used, limit uint64) {
+	p := l.pressure
+	pressed := float64(used) > p.fraction*float64(limit)
+	switch {
+	case pressed && !p.active:
+		p.active = true
+		p.saved = l.sampling.Swap(p.rates)
+		selfLogf("Memory use of %d bytes is near the %d byte limit, flushing and sampling events", used, limit)
+		l.goWorker("pressure", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), pressureFlushTimeout)
+			defer cancel()
+			l.Flush(ctx)
+		})
+	case !pressed && p.active:
+		p.active = false
+		// Sampling changed since, e.g. by a configuration reload, is left alone
+		l.sampling.CompareAndSwap(p.rates, p.saved)
+	}
+}
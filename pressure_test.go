@@ -0,0 +1,91 @@
+package main
+
+import (
+	"math"
+	"runtime"
+	"runtime/debug"
+	"testing"
+	"time"
+)
+
+func TestMemoryPressureFlushesAndSamples(t *testing.T) {
+	logger := newQueueLogger(10, WithMemoryPressure(0.8, map[string]float64{LevelDebug: 0, LevelInformation: 0.1}))
+	normal := newSamplingRates(map[string]float64{LevelDebug: 0.5})
+	logger.sampling.Store(normal)
+
+	logger.memoryUsage(700, 1000)
+	if logger.sampling.Load() != normal {
+		t.Fatal("Expected no change below the threshold")
+	}
+
+	logger.memoryUsage(900, 1000)
+	if rates := logger.sampling.Load(); rates[levelRank(LevelDebug)] != 0 || rates[levelRank(LevelInformation)] != 0.1 {
+		t.Errorf("Expected the pressure sampling, got %v", rates)
+	}
+	select {
+	case marker := <-logger.logChan:
+		if marker.flush == nil {
+			t.Errorf("Expected a flush marker, got %+v", marker)
+		}
+		marker.flush <- nil
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected an early flush")
+	}
+
+	logger.memoryUsage(950, 1000)
+	logger.memoryUsage(500, 1000)
+	if logger.sampling.Load() != normal {
+		t.Error("Expected the previous sampling to be restored")
+	}
+	if len(logger.logChan) != 0 {
+		t.Errorf("Expected a single flush while under pressure, got %d more markers", len(logger.logChan))
+	}
+}
+
+func TestCheckMemoryReadsTheLimit(t *testing.T) {
+	logger := newQueueLogger(10, WithMemoryPressure(0.5, nil))
+	previous := debug.SetMemoryLimit(math.MaxInt64)
+	defer debug.SetMemoryLimit(previous)
+
+	logger.checkMemory()
+	if logger.pressure.active {
+		t.Fatal("Expected no pressure without a memory limit")
+	}
+
+	debug.SetMemoryLimit(1 << 20)
+	logger.checkMemory()
+	debug.SetMemoryLimit(math.MaxInt64)
+	if !logger.pressure.active {
+		t.Fatal("Expected pressure past half of a 1MB limit")
+	}
+	marker := <-logger.logChan
+	marker.flush <- nil
+}
+
+// tinyNeighbour keeps an object allocated next to a watchMemory sentinel reachable
+var tinyNeighbour *int
+
+func TestWatchMemoryChecksAfterGarbageCollection(t *testing.T) {
+	logger := newQueueLogger(10, WithMemoryPressure(0.5, nil))
+	previous := debug.SetMemoryLimit(1 << 20)
+	defer debug.SetMemoryLimit(previous)
+	defer logger.closed.Store(true)
+
+	// A small object allocated alongside the sentinel stays reachable, which would keep a
+	// sentinel packed into the same tiny block from ever being finalized
+	logger.watchMemory()
+	tinyNeighbour = new(int)
+	defer func() { tinyNeighbour = nil }()
+	deadline := time.After(5 * time.Second)
+	for {
+		runtime.GC()
+		select {
+		case marker := <-logger.logChan:
+			marker.flush <- nil
+			return
+		case <-deadline:
+			t.Fatal("Expected a garbage collection to check the memory limit")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// WithPrewarm connects to the SEQ server in the background as the logger is created, so
+// the first batch finds a pooled connection and doesn't pay for DNS, TCP and TLS, which
+// matters for short-lived jobs. The connection stays pooled while the transport keeps
+// idle connections, 90 seconds by default. A server URL without an endpoint is
+// already connected to when the endpoint is negotiated.
+func WithPrewarm() Option {
+	return func(l *SEQLogger) {
+		l.prewarm = true
+	}
+}
+
+// prewarmConnection makes a HEAD request to the ingestion endpoint, whatever the
+// answer, to leave a connection in the transport's pool
+func (l *SEQLogger) prewarmConnection() {
+	ctx, cancel := context.WithTimeout(context.Background(), negotiationTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, l.seqURL, nil)
+	if err != nil {
+		selfLogf("Failed to prewarm the connection: %v", err)
+		return
+	}
+	l.setHeaders(req)
+	if err := l.authorize(ctx, req); err != nil {
+		selfLogf("Failed to prewarm the connection: %v", err)
+		return
+	}
+	resp, err := l.transport.client.Do(req)
+	if err != nil {
+		selfLogf("Failed to prewarm the connection: %v", err)
+		return
+	}
+	// Drain the body so the connection goes back to the pool
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// TruncatedSuffix names the companion property, <key>_Truncated, set to true on an
+// event whose <key> string, or a string nested in <key>, was cut to the limit of
+// WithMaxStringLength
+const TruncatedSuffix = "_Truncated"
+
+const (
+	// defaultMaxBinaryLength is the default size up to which binary values are base64 encoded into an event
+	defaultMaxBinaryLength = 1024
+	// defaultMaxDepth is the default nesting depth up to which field values are destructured
+	defaultMaxDepth = 10
+	// defaultMaxProperties is the default number of properties kept per nested object or collection
+	defaultMaxProperties = 100
+	// depthLimitMarker replaces values nested deeper than the configured maximum depth
+	depthLimitMarker = "<max depth>"
+	// cycleMarker replaces values that refer back to a container they are nested in
+	cycleMarker = "<cycle>"
+	// truncationMarker ends a string cut to the maximum string length, counting toward it
+	truncationMarker = "…"
+)
+
+// normalizer converts field values into forms that serialize sensibly,
+// destructuring structs within its depth and breadth limits
+type normalizer struct {
+	maxDepth        int
+	maxProperties   int
+	maxStringLength int // in bytes, 0 for no limit
+	maxBinaryLength int // in bytes, see binary
+}
+
+// newNormalizer creates a normalizer with the default limits
+func newNormalizer() normalizer {
+	return normalizer{
+		maxDepth:        defaultMaxDepth,
+		maxProperties:   defaultMaxProperties,
+		maxBinaryLength: defaultMaxBinaryLength,
+	}
+}
+
+// fields returns fields with every value normalized.
+// The caller's map is never modified; a copy is made only when a value has to change.
+func (n normalizer) fields(fields map[string]interface{}) map[string]interface{} {
+	if len(fields) == 0 {
+		return fields
+	}
+
+	var stack [8]pathEntry
+	w := walker{normalizer: n, path: stack[:0]}
+	w.enter(reflect.ValueOf(fields))
+	return w.mapAt(fields, 0, false)
+}
+
+// capString cuts s to maxStringLength bytes, the ellipsis marking the cut included, and
+// records the cut for the top-level property being walked
+func (w *walker) capString(s string) (string, bool) {
+	if w.maxStringLength <= 0 || len(s) <= w.maxStringLength {
+		return s, false
+	}
+	w.cut = true
+	if w.maxStringLength <= len(truncationMarker) {
+		return truncateString(s, w.maxStringLength), true
+	}
+	return truncateString(s, w.maxStringLength-len(truncationMarker)) + truncationMarker, true
+}
+
+// truncateString returns the longest prefix of s of at most max bytes that doesn't
+// split a UTF-8 sequence
+func truncateString(s string, max int) string {
+	cut := max
+	for cut > 0 && cut < len(s) && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut]
+}
+
+// pathEntry identifies a container on the path being walked; the type keeps a struct
+// and its first field, which share an address, apart
+type pathEntry struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// walker normalizes one set of fields, tracking the maps, slices and pointers on the
+// current path so that circular references are replaced with cycleMarker
+type walker struct {
+	normalizer
+	path []pathEntry
+	cut  bool // a string of the current top-level property was cut, see capString
+}
+
+// enter pushes the container rv onto the path, reporting false if it is already on it
+func (w *walker) enter(rv reflect.Value) bool {
+	entry := pathEntry{ptr: rv.Pointer(), typ: rv.Type()}
+	for _, e := range w.path {
+		if e == entry {
+			return false
+		}
+	}
+	w.path = append(w.path, entry)
+	return true
+}
+
+// leave pops the most recently entered container off the path
+func (w *walker) leave() {
+	w.path = w.path[:len(w.path)-1]
+}
+
+// mapAt normalizes the values of a map found at depth, applying the breadth limit when limit is set
+func (w *walker) mapAt(fields map[string]interface{}, depth int, limit bool) map[string]interface{} {
+	if limit && len(fields) > w.maxProperties {
+		return w.truncatedMap(fields, depth)
+	}
+
+	normalized := fields
+	for key, value := range fields {
+		if depth == 0 {
+			w.cut = false
+		}
+		converted, changed := w.value(value, depth)
+		if !changed {
+			continue
+		}
+		if sameMap(normalized, fields) {
+			normalized = make(map[string]interface{}, len(fields))
+			for k, v := range fields {
+				normalized[k] = v
+			}
+		}
+		normalized[key] = converted
+		if depth == 0 && w.cut {
+			normalized[key+TruncatedSuffix] = true
+		}
+	}
+	return normalized
+}
+
+// truncatedMap copies the first maxProperties entries of fields in key order, normalizing their values
+func (w *walker) truncatedMap(fields map[string]interface{}, depth int) map[string]interface{} {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	truncated := make(map[string]interface{}, w.maxProperties)
+	for _, key := range keys[:w.maxProperties] {
+		truncated[key], _ = w.value(fields[key], depth)
+	}
+	return truncated
+}
+
+// value converts a single property value found at depth, reporting whether it changed.
+// Strings are sanitized, see sanitizeString, and cut to maxStringLength.
+// Times, durations, byte slices and readers get dedicated representations, json.Marshaler
+// values are left for encoding/json, errors become their message, fmt.Stringer
+// values their String() output and structs are destructured into maps.
+func (w *walker) value(value interface{}, depth int) (interface{}, bool) {
+	switch v := value.(type) {
+	case string:
+		sanitized, changed := sanitizeString(v)
+		capped, cut := w.capString(sanitized)
+		return capped, changed || cut
+	case nil, bool, int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64, float32, float64:
+		return value, false
+	case time.Time:
+		return v.Format(time.RFC3339Nano), true
+	case time.Duration:
+		return map[string]interface{}{
+			"Text":         v.String(),
+			"Milliseconds": float64(v) / float64(time.Millisecond),
+		}, true
+	case []byte:
+		return w.binary(v), true
+	case json.Marshaler:
+		return value, false
+	case error:
+		if isNilPointer(v) {
+			return nil, true
+		}
+		capped, _ := w.capString(callString("Error", v.Error))
+		return capped, true
+	case io.Reader:
+		if isNilPointer(v) {
+			return nil, true
+		}
+		return w.reader(v), true
+	case fmt.Stringer:
+		if isNilPointer(v) {
+			return nil, true
+		}
+		capped, _ := w.capString(callString("String", v.String))
+		return capped, true
+	case map[string]interface{}:
+		if depth >= w.maxDepth {
+			return depthLimitMarker, true
+		}
+		if !w.enter(reflect.ValueOf(v)) {
+			return cycleMarker, true
+		}
+		defer w.leave()
+		normalized := w.mapAt(v, depth+1, true)
+		return normalized, !sameMap(normalized, v)
+	case []interface{}:
+		if depth >= w.maxDepth {
+			return depthLimitMarker, true
+		}
+		if len(v) == 0 {
+			return value, false
+		}
+		if !w.enter(reflect.ValueOf(v)) {
+			return cycleMarker, true
+		}
+		defer w.leave()
+		return w.slice(v, depth+1)
+	}
+	return w.reflectValue(reflect.ValueOf(value), depth)
+}
+
+// slice converts the elements of values, copying the slice only when an element changes
+func (w *walker) slice(values []interface{}, depth int) (interface{}, bool) {
+	truncated := len(values) > w.maxProperties
+	if truncated {
+		values = values[:w.maxProperties]
+	}
+
+	var normalized []interface{}
+	for i, value := range values {
+		converted, changed := w.value(value, depth)
+		if !changed {
+			continue
+		}
+		if normalized == nil {
+			normalized = make([]interface{}, len(values))
+			copy(normalized, values)
+		}
+		normalized[i] = converted
+	}
+	if normalized == nil {
+		return values, truncated
+	}
+	return normalized, true
+}
+
+// binary base64 encodes b when it holds at most maxBinaryLength bytes, and otherwise
+// records only its Length and SHA256 hash, which identify the data without its bulk
+func (n normalizer) binary(b []byte) interface{} {
+	if len(b) <= n.maxBinaryLength {
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	sum := sha256.Sum256(b)
+	return map[string]interface{}{"Length": int64(len(b)), "SHA256": hex.EncodeToString(sum[:])}
+}
+
+// reader reads r to the end and represents what it read like binary. A read error
+// is recorded as Error next to the Length and hash of the data read before it.
+func (n normalizer) reader(r io.Reader) interface{} {
+	head, err := io.ReadAll(io.LimitReader(r, int64(n.maxBinaryLength)+1))
+	if err == nil && len(head) <= n.maxBinaryLength {
+		return base64.StdEncoding.EncodeToString(head)
+	}
+
+	hash := sha256.New()
+	hash.Write(head)
+	length := int64(len(head))
+	if err == nil {
+		var rest int64
+		rest, err = io.Copy(hash, r)
+		length += rest
+	}
+	summary := map[string]interface{}{"Length": length, "SHA256": hex.EncodeToString(hash.Sum(nil))}
+	if err != nil {
+		summary["Error"] = err.Error()
+	}
+	return summary
+}
+
+// callString invokes a String or Error method, turning a panic into a descriptive value
+func callString(method string, fn func() string) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = fmt.Sprintf("!(PANIC=%s method: %v)", method, r)
+		}
+	}()
+	return fn()
+}
+
+// isNilPointer reports whether value is a nil pointer stored in an interface
+func isNilPointer(value interface{}) bool {
+	v := reflect.ValueOf(value)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// sameMap reports whether a and b are the same map instance
+func sameMap(a, b map[string]interface{}) bool {
+	return reflect.ValueOf(a).UnsafePointer() == reflect.ValueOf(b).UnsafePointer()
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testStringer struct{ name string }
+
+func (s *testStringer) String() string { return "stringer:" + s.name }
+
+type testMarshaler struct{}
+
+func (testMarshaler) MarshalJSON() ([]byte, error) { return []byte(`"marshaled"`), nil }
+
+func (testMarshaler) String() string { return "not used" }
+
+type panickingStringer struct{}
+
+func (panickingStringer) String() string { panic("boom") }
+
+func TestNormalizeFields(t *testing.T) {
+	var nilStringer *testStringer
+	fields := map[string]interface{}{
+		"error":     errors.New("example error message"),
+		"stringer":  &testStringer{name: "a"},
+		"nil":       nilStringer,
+		"marshaler": testMarshaler{},
+		"panics":    panickingStringer{},
+		"plain":     42,
+		"nested": map[string]interface{}{
+			"error": errors.New("nested error"),
+		},
+		"list": []interface{}{errors.New("first"), "second"},
+	}
+
+	normalized := newNormalizer().fields(fields)
+
+	data, err := json.Marshal(normalized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"error":     "example error message",
+		"stringer":  "stringer:a",
+		"nil":       nil,
+		"marshaler": "marshaled",
+		"panics":    "!(PANIC=String method: boom)",
+		"plain":     float64(42),
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("Field %q = %#v, want %#v", key, got[key], value)
+		}
+	}
+	if nested := got["nested"].(map[string]interface{}); nested["error"] != "nested error" {
+		t.Errorf("Expected nested error message, got %#v", nested["error"])
+	}
+	if list := got["list"].([]interface{}); list[0] != "first" || list[1] != "second" {
+		t.Errorf("Expected list elements to be normalized, got %#v", list)
+	}
+	if _, ok := fields["error"].(error); !ok {
+		t.Errorf("Expected caller's map to be left untouched")
+	}
+}
+
+func TestNormalizeFieldsWithoutChangesKeepsMap(t *testing.T) {
+	fields := map[string]interface{}{"version": "1.0.0", "count": 3}
+	if normalized := newNormalizer().fields(fields); !sameMap(normalized, fields) {
+		t.Errorf("Expected the original map to be reused when nothing changes")
+	}
+}
+
+func TestNormalizeTimesDurationsAndBytes(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC)
+	long := bytes.Repeat([]byte{0xAB}, defaultMaxBinaryLength+10)
+
+	normalized := newNormalizer().fields(map[string]interface{}{
+		"at":      at,
+		"elapsed": 1500 * time.Millisecond,
+		"short":   []byte("hi"),
+		"long":    long,
+	})
+
+	if normalized["at"] != "2024-01-02T03:04:05.0000006Z" {
+		t.Errorf("Unexpected time representation %#v", normalized["at"])
+	}
+	elapsed := normalized["elapsed"].(map[string]interface{})
+	if elapsed["Text"] != "1.5s" || elapsed["Milliseconds"] != float64(1500) {
+		t.Errorf("Unexpected duration representation %#v", elapsed)
+	}
+	if normalized["short"] != "aGk=" {
+		t.Errorf("Unexpected bytes representation %#v", normalized["short"])
+	}
+	sum := sha256.Sum256(long)
+	want := map[string]interface{}{"Length": int64(len(long)), "SHA256": hex.EncodeToString(sum[:])}
+	if !reflect.DeepEqual(normalized["long"], want) {
+		t.Errorf("Expected a long byte slice to be recorded as its length and hash, got %#v", normalized["long"])
+	}
+}
+
+func TestNormalizeReaders(t *testing.T) {
+	n := newNormalizer()
+	n.maxBinaryLength = 4
+
+	normalized := n.fields(map[string]interface{}{
+		"short": strings.NewReader("hi"),
+		"long":  strings.NewReader("request body"),
+		"nil":   (*bytes.Reader)(nil),
+	})
+
+	if normalized["short"] != "aGk=" {
+		t.Errorf("Unexpected short reader representation %#v", normalized["short"])
+	}
+	sum := sha256.Sum256([]byte("request body"))
+	want := map[string]interface{}{"Length": int64(12), "SHA256": hex.EncodeToString(sum[:])}
+	if !reflect.DeepEqual(normalized["long"], want) {
+		t.Errorf("Unexpected long reader representation %#v", normalized["long"])
+	}
+	if normalized["nil"] != nil {
+		t.Errorf("Expected a nil reader to become nil, got %#v", normalized["nil"])
+	}
+}
+
+type testAddress struct {
+	Street string `json:"street"`
+	City   string
+	zip    string
+}
+
+type AuditInfo struct {
+	CreatedBy string
+}
+
+type testUser struct {
+	AuditInfo
+	ID       int            `json:"id"`
+	Name     string         `json:"name,omitempty"`
+	Password string         `json:"-"`
+	Address  *testAddress   `json:"address"`
+	Tags     []string       `json:"tags"`
+	Limits   map[string]int `json:"limits"`
+	Timeout  time.Duration  `json:"timeout"`
+}
+
+func TestNormalizeDestructuresStructs(t *testing.T) {
+	user := testUser{
+		AuditInfo: AuditInfo{CreatedBy: "admin"},
+		ID:        42,
+		Password:  "secret",
+		Address:   &testAddress{Street: "Main St", City: "Springfield", zip: "12345"},
+		Tags:      []string{"a", "b"},
+		Limits:    map[string]int{"daily": 10},
+		Timeout:   time.Second,
+	}
+
+	got := newNormalizer().fields(map[string]interface{}{"user": user})["user"].(map[string]interface{})
+
+	if got["id"] != 42 || got["CreatedBy"] != "admin" {
+		t.Errorf("Expected tagged and promoted fields, got %#v", got)
+	}
+	for _, key := range []string{"name", "Password", "AuditInfo"} {
+		if _, ok := got[key]; ok {
+			t.Errorf("Expected %q to be omitted, got %#v", key, got)
+		}
+	}
+	address := got["address"].(map[string]interface{})
+	if address["street"] != "Main St" || address["City"] != "Springfield" || len(address) != 2 {
+		t.Errorf("Unexpected destructured address %#v", address)
+	}
+	if tags := got["tags"].([]interface{}); len(tags) != 2 || tags[0] != "a" {
+		t.Errorf("Unexpected tags %#v", got["tags"])
+	}
+	if limits := got["limits"].(map[string]interface{}); limits["daily"] != 10 {
+		t.Errorf("Unexpected limits %#v", got["limits"])
+	}
+	if timeout := got["timeout"].(map[string]interface{}); timeout["Text"] != "1s" {
+		t.Errorf("Expected nested duration to be normalized, got %#v", got["timeout"])
+	}
+}
+
+func TestNormalizeDepthAndBreadthLimits(t *testing.T) {
+	n := normalizer{maxDepth: 1, maxProperties: 3}
+
+	nested := map[string]interface{}{
+		"level1": map[string]interface{}{
+			"level2": map[string]interface{}{"level3": "deep"},
+		},
+		"wide":   []interface{}{1, 2, 3, 4, 5},
+		"struct": testAddress{Street: "Main St", City: "Springfield"},
+	}
+
+	got := n.fields(nested)
+
+	level1 := got["level1"].(map[string]interface{})
+	if level1["level2"] != depthLimitMarker {
+		t.Errorf("Expected value beyond max depth to be replaced, got %#v", level1["level2"])
+	}
+	if wide := got["wide"].([]interface{}); len(wide) != 3 {
+		t.Errorf("Expected collection to be capped at 3 elements, got %#v", wide)
+	}
+
+	n.maxProperties = 1
+	if address := n.fields(nested)["struct"].(map[string]interface{}); len(address) != 1 || address["street"] != "Main St" {
+		t.Errorf("Expected struct to be capped at 1 property, got %#v", address)
+	}
+}
+
+func TestNormalizeTruncatesLongStrings(t *testing.T) {
+	n := newNormalizer()
+	n.maxStringLength = 7
+
+	fields := map[string]interface{}{
+		"Sql":    "SELECT * FROM orders",
+		"Name":   "héllo wörld",
+		"Short":  "ok",
+		"Nested": map[string]interface{}{"Text": "much longer than seven", "Id": 1},
+		"Order":  struct{ Note string }{Note: "leave at the door"},
+		"Error":  errors.New("connection refused"),
+	}
+	got := n.fields(fields)
+
+	if got["Sql"] != "SELE…" || got["Sql"+TruncatedSuffix] != true {
+		t.Errorf("Expected Sql to be truncated and marked, got %#v", got)
+	}
+	if got["Name"] != "hél…" {
+		t.Errorf("Expected truncation to keep whole UTF-8 sequences, got %q", got["Name"])
+	}
+	if got["Short"] != "ok" || got["Short"+TruncatedSuffix] != nil {
+		t.Errorf("Expected short strings to be left alone, got %#v", got)
+	}
+	if nested := got["Nested"].(map[string]interface{}); nested["Text"] != "much…" || got["Nested"+TruncatedSuffix] != true {
+		t.Errorf("Expected nested strings to be truncated and marked, got %#v", got)
+	}
+	if order := got["Order"].(map[string]interface{}); order["Note"] != "leav…" || got["Order"+TruncatedSuffix] != true {
+		t.Errorf("Expected struct fields to be truncated and marked, got %#v", got)
+	}
+	if got["Error"] != "conn…" {
+		t.Errorf("Expected error messages to be truncated, got %#v", got["Error"])
+	}
+	for key, value := range got {
+		if s, ok := value.(string); ok && len(s) > n.maxStringLength {
+			t.Errorf("Expected %s within %d bytes, got %d", key, n.maxStringLength, len(s))
+		}
+	}
+	if fields["Sql"] != "SELECT * FROM orders" || len(fields) != 6 {
+		t.Errorf("Expected the caller's fields to be left alone, got %#v", fields)
+	}
+
+	n.maxStringLength = 2
+	if got := n.fields(map[string]interface{}{"Sql": "SELECT"}); got["Sql"] != "SE" {
+		t.Errorf("Expected a limit too small for the ellipsis to cut without it, got %#v", got)
+	}
+}
+
+type testNode struct {
+	Name string
+	Next *testNode
+}
+
+func TestNormalizeReplacesCycles(t *testing.T) {
+	self := map[string]interface{}{"name": "self"}
+	self["self"] = self
+	list := []interface{}{"first", nil}
+	list[1] = list
+	node := &testNode{Name: "a"}
+	node.Next = &testNode{Name: "b", Next: node}
+	shared := map[string]interface{}{"shared": true}
+
+	fields := map[string]interface{}{
+		"map":    self,
+		"list":   list,
+		"node":   node,
+		"first":  shared,
+		"second": shared,
+	}
+	fields["fields"] = fields
+
+	got := newNormalizer().fields(fields)
+
+	if _, err := json.Marshal(got); err != nil {
+		t.Fatalf("Expected normalized cyclic fields to serialize, got %v", err)
+	}
+	if got["fields"] != cycleMarker {
+		t.Errorf("Expected top-level self reference to be replaced, got %#v", got["fields"])
+	}
+	if m := got["map"].(map[string]interface{}); m["self"] != cycleMarker || m["name"] != "self" {
+		t.Errorf("Unexpected cyclic map %#v", m)
+	}
+	if l := got["list"].([]interface{}); l[1] != cycleMarker {
+		t.Errorf("Unexpected cyclic list %#v", l)
+	}
+	b := got["node"].(map[string]interface{})["Next"].(map[string]interface{})
+	if b["Name"] != "b" || b["Next"] != cycleMarker {
+		t.Errorf("Unexpected cyclic struct %#v", b)
+	}
+	for _, key := range []string{"first", "second"} {
+		if m, ok := got[key].(map[string]interface{}); !ok || m["shared"] != true {
+			t.Errorf("Expected shared, non-cyclic map under %q to be kept, got %#v", key, got[key])
+		}
+	}
+}
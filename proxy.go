@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// maxProxyPayload bounds a request body accepted by the ingestion proxy, after decompression
+const maxProxyPayload = 10 << 20
+
+// IngestionHandler returns an http.Handler serving SEQ's ingestion API, /ingest/clef
+// and /api/events/raw, so co-located processes can send to the logger as if it were
+// the server. Their events share the logger's batching, retries and WAL or spill
+// buffering on the way upstream. A payload with an invalid event is rejected as a
+// whole, with SEQ's JSON error body naming the event's line for CLEF payloads.
+func (l *SEQLogger) IngestionHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(EndpointCLEF, func(w http.ResponseWriter, r *http.Request) {
+		l.serveIngestion(w, r, true)
+	})
+	mux.HandleFunc(EndpointRaw, func(w http.ResponseWriter, r *http.Request) {
+		// The raw endpoint takes CLEF too, when the client says so
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		_, clefQuery := r.URL.Query()["clef"]
+		l.serveIngestion(w, r, clefQuery || mediaType == (CLEFEncoder{}).ContentType())
+	})
+	return mux
+}
+
+// serveIngestion queues the events of an ingestion request, a CLEF payload or a raw
+// format {"Events": [...]} batch
+func (l *SEQLogger) serveIngestion(w http.ResponseWriter, r *http.Request, clef bool) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeIngestionError(w, http.StatusMethodNotAllowed, "Events must be POSTed")
+		return
+	}
+	if l.pipeline().closed.Load() {
+		writeIngestionError(w, http.StatusServiceUnavailable, "The logger is shutting down")
+		return
+	}
+
+	body, err := readProxyBody(w, r)
+	if err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeIngestionError(w, status, err.Error())
+		return
+	}
+
+	var events []ingestEvent
+	if clef {
+		events, err = parseCLEFPayload(body)
+	} else {
+		events, err = parseRawPayload(body)
+	}
+	if err != nil {
+		writeIngestionError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	for _, event := range events {
+		l.LogAt(event.timestamp, event.level, event.template, event.fields)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	io.WriteString(w, `{"MinimumLevelAccepted":null}`)
+}
+
+// readProxyBody reads a request body of at most maxProxyPayload bytes, decompressing
+// the gzip and zstd encodings the logger itself sends with WithCompression
+func readProxyBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	var body io.Reader = http.MaxBytesReader(w, r.Body, maxProxyPayload)
+	switch encoding := strings.ToLower(r.Header.Get("Content-Encoding")); encoding {
+	case "", "identity":
+	case "gzip":
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid gzip body: %v", err)
+		}
+		defer gz.Close()
+		body = gz
+	case "zstd":
+		zr, err := zstd.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid zstd body: %v", err)
+		}
+		defer zr.Close()
+		body = zr
+	default:
+		return nil, fmt.Errorf("Unsupported Content-Encoding %q", encoding)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, maxProxyPayload+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxProxyPayload {
+		return nil, &http.MaxBytesError{Limit: maxProxyPayload}
+	}
+	return data, nil
+}
+
+// parseCLEFPayload reads the events of a newline-delimited CLEF payload
+func parseCLEFPayload(body []byte) ([]ingestEvent, error) {
+	var events []ingestEvent
+	for i, line := range bytes.Split(body, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		event, err := parseIngestLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid event on line %d: %v", i+1, err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// parseRawPayload reads the events of a raw format {"Events": [...]} batch. Batch-level
+// Properties, as written by ProxyHoistingEncoder, are added to every event.
+func parseRawPayload(body []byte) ([]ingestEvent, error) {
+	var payload struct {
+		Properties map[string]interface{}   `json:"Properties"`
+		Events     []map[string]interface{} `json:"Events"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("Invalid raw events payload: %v", err)
+	}
+
+	events := make([]ingestEvent, 0, len(payload.Events))
+	for i, object := range payload.Events {
+		if _, ok := object["MessageTemplate"]; !ok {
+			return nil, fmt.Errorf("Invalid event %d: no message template", i+1)
+		}
+		event, err := parseIngestObject(object)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid event %d: %v", i+1, err)
+		}
+		if len(payload.Properties) > 0 {
+			event.fields = mergeFields(payload.Properties, event.fields)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// writeIngestionError answers with SEQ's {"Error": "..."} response body
+func writeIngestionError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"Error": message})
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// queuedTemplates reads n events from the logger's queue and returns their templates
+func queuedTemplates(t *testing.T, logger *SEQLogger, n int) []string {
+	t.Helper()
+	var templates []string
+	for i := 0; i < n; i++ {
+		select {
+		case logMessage := <-logger.logChan:
+			templates = append(templates, logMessage.MessageTemplate)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected %d events, got %v", n, templates)
+		}
+	}
+	return templates
+}
+
+func TestIngestionProxyAcceptsOwnPayloads(t *testing.T) {
+	upstream := newQueueLogger(10)
+	proxy := httptest.NewServer(upstream.IngestionHandler())
+	defer proxy.Close()
+
+	encoders := map[string]Encoder{"raw": RawEncoder{}, "clef": CLEFEncoder{}, "hoisted": ProxyHoistingEncoder{}}
+	for name, encoder := range encoders {
+		t.Run(name, func(t *testing.T) {
+			client := newSenderLogger(proxy.URL+EndpointRaw, WithEncoder(encoder), WithCompression("gzip", 1))
+			batch := benchmarkBatch(2)
+			if err := client.deliver(proxy.Client(), batch); err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < len(batch); i++ {
+				logMessage := <-upstream.logChan
+				if logMessage.MessageTemplate != "Processed order {OrderId}" || logMessage.Fields["OrderId"] != float64(i) || logMessage.Fields["customer"] != "12345" {
+					t.Errorf("Unexpected event %+v", logMessage)
+				}
+			}
+		})
+	}
+}
+
+func TestIngestionProxyCLEFEndpoint(t *testing.T) {
+	upstream := newQueueLogger(10)
+	proxy := httptest.NewServer(upstream.IngestionHandler())
+	defer proxy.Close()
+
+	body := "{\"@mt\": \"first\"}\n{\"@mt\": \"second\"}\n"
+	resp, err := http.Post(proxy.URL+EndpointCLEF, "application/vnd.serilog.clef", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", resp.StatusCode)
+	}
+	if got := queuedTemplates(t, upstream, 2); got[0] != "first" || got[1] != "second" {
+		t.Errorf("Unexpected events %v", got)
+	}
+}
+
+func TestIngestionProxyRejectsInvalidPayloads(t *testing.T) {
+	upstream := newQueueLogger(10)
+	proxy := httptest.NewServer(upstream.IngestionHandler())
+	defer proxy.Close()
+
+	// The client side of the proxy reads the rejection like it reads SEQ's
+	client := newSenderLogger(proxy.URL+EndpointCLEF, WithEncoder(CLEFEncoder{}), WithFallback(&memorySink{}))
+	batch := benchmarkBatch(3)
+	batch[1].Level = ""
+	batch[1].MessageTemplate = ""
+	if err := client.deliver(proxy.Client(), batch); err != nil {
+		t.Fatalf("Expected the valid events to be resent, got %v", err)
+	}
+	if got := queuedTemplates(t, upstream, 2); len(got) != 2 {
+		t.Errorf("Expected 2 events, got %v", got)
+	}
+	if len(upstream.logChan) != 0 {
+		t.Errorf("Expected the rejected payload not to be queued, %d events queued", len(upstream.logChan))
+	}
+
+	resp, err := http.Get(proxy.URL + EndpointRaw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNotPumped is returned by Pump and FlushNow on a logger created without WithPumpMode
+var ErrNotPumped = errors.New("logger isn't in pump mode")
+
+// defaultPumpInterval is how often Pump delivers without WithBatching's interval
+const defaultPumpInterval = 100 * time.Millisecond
+
+// pump is the delivery state of a logger in pump mode, taken by one caller at a time
+type pump struct {
+	mu       sync.Mutex
+	delivery *delivery
+	full     atomic.Bool // an event was dropped since the queue last had room
+}
+
+// WithPumpMode starts no goroutine for the logger: events wait in the queue until the
+// host delivers them, calling FlushNow, e.g. from its event loop, or Pump on a
+// goroutine of its own. It suits WASM, plugins and programs that audit their
+// goroutines. Flush and Close deliver on the calling goroutine too. As nothing makes
+// room in a full queue, an event logged then is dropped and counted in Stats, so
+// bufferSize should hold what is logged between two pumps. WithFairQueueing,
+// WithSpill, WithMemoryPressure, WithPrewarm and WithErrorAggregation need a
+// goroutine and can't be combined with it, nor can a bufferSize of 0.
+func WithPumpMode() Option {
+	return func(l *SEQLogger) {
+		l.pump = &pump{}
+	}
+}
+
+// Pump delivers the queued events every batch interval, as set by WithBatching or
+// 100ms by default, until ctx is done, returning its error, or the logger is closed.
+// Delivery errors are kept for the next Flush or FlushNow.
+func (l *SEQLogger) Pump(ctx context.Context) error {
+	l = l.pipeline()
+	if l.pump == nil {
+		return ErrNotPumped
+	}
+	interval := l.batchInterval
+	if interval <= 0 {
+		interval = defaultPumpInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if l.closed.Load() {
+			return nil
+		}
+		l.pump.mu.Lock()
+		l.pumpQueued(len(l.pump.delivery.queue) + len(l.express))
+		l.pump.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-l.done:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// FlushNow delivers the events queued before the call to the SEQ server and sinks on
+// the calling goroutine, and returns the delivery errors seen since the previous
+// flush joined into one error, as Flush does. It returns ErrClosed once the logger
+// is closed.
+func (l *SEQLogger) FlushNow() error {
+	l = l.pipeline()
+	if l.pump == nil {
+		return ErrNotPumped
+	}
+	l.pump.mu.Lock()
+	defer l.pump.mu.Unlock()
+	if l.closed.Load() {
+		return ErrClosed
+	}
+
+	l.pumpQueued(len(l.pump.delivery.queue) + len(l.express))
+	return l.takeFlushErrors()
+}
+
+// pumpQueued delivers up to limit queued messages, or every message left when limit
+// is negative, holding pump.mu
+func (l *SEQLogger) pumpQueued(limit int) {
+	d := l.pump.delivery
+	for limit != 0 {
+		logMessage, ok := l.pollEvent(d.queue)
+		if !ok {
+			break
+		}
+		taken := l.process(d, logMessage, 0)
+		if limit > 0 {
+			limit = max(limit-taken, 0)
+		}
+	}
+	l.pump.full.Store(false)
+}
+
+// pollEvent returns the next message waiting in the express lane or queue, without
+// waiting for one
+func (l *SEQLogger) pollEvent(queue chan LogMessage) (LogMessage, bool) {
+	if l.express != nil {
+		select {
+		case logMessage := <-l.express:
+			return logMessage, true
+		default:
+		}
+	}
+	select {
+	case logMessage, ok := <-queue:
+		return logMessage, ok
+	default:
+		return LogMessage{}, false
+	}
+}
+
+// pumpEnqueue queues logMessage without waiting for room, dropping it on a full queue
+func (l *SEQLogger) pumpEnqueue(logMessage *LogMessage) {
+	select {
+	case l.logChan <- *logMessage:
+		return
+	default:
+	}
+	l.dropped.Add(1)
+	if l.memoryBudget != nil {
+		l.memoryBudget.queued.Add(-logMessage.size)
+	}
+	if l.pump.full.CompareAndSwap(false, true) {
+		selfLogf("Dropping events: the queue of %d events is full until the next pump", cap(l.logChan))
+	}
+}
+
+// pumpResize delivers what is left in the queue being replaced by queue, with Log
+// calls held back by closeMu
+func (l *SEQLogger) pumpResize(queue chan LogMessage) {
+	l.pump.mu.Lock()
+	defer l.pump.mu.Unlock()
+	l.pumpQueued(-1)
+	l.pump.delivery.queue = queue
+}
+
+// closePump delivers the events left in the closed queue, as processLogs does when
+// it ends
+func (l *SEQLogger) closePump() {
+	l.pump.mu.Lock()
+	defer l.pump.mu.Unlock()
+	l.pumpQueued(-1)
+	if l.wal != nil {
+		l.wal.close()
+	}
+	close(l.done)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPumpModeStartsNoGoroutine(t *testing.T) {
+	server := newSeqRecorder(t)
+	sink := &memorySink{}
+	logger := newTestLogger(t, server.URL+EndpointRaw, 10, WithPumpMode(), WithSink(sink, ""))
+	for i := 0; i < 3; i++ {
+		logger.Log(LevelInformation, "Order placed", nil)
+	}
+	// A worker would show in the goroutine profile labelled with the logger's number
+	time.Sleep(20 * time.Millisecond)
+	var profile bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&profile, 1); err != nil {
+		t.Fatalf("Failed to write the goroutine profile: %v", err)
+	}
+	if label := `"logger":"` + strconv.FormatUint(logger.instance, 10) + `"`; strings.Contains(profile.String(), label) {
+		t.Errorf("Expected no goroutine started, got %s", profile.String())
+	}
+	if len(sink.templates) != 0 {
+		t.Fatal("Expected nothing delivered before the host pumps")
+	}
+
+	if err := logger.FlushNow(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	if len(sink.templates) != 3 || strings.Count(server.received(), "Order placed") != 3 {
+		t.Errorf("Expected 3 events delivered, got %v", sink.templates)
+	}
+
+	logger.Named("Billing").Log(LevelWarning, "Stock low", nil)
+	logger.Close()
+	if len(sink.templates) != 4 {
+		t.Errorf("Expected Close to deliver the last event, got %v", sink.templates)
+	}
+	if err := logger.FlushNow(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
+
+func TestPumpModeDropsOnFullQueue(t *testing.T) {
+	server := newSeqRecorder(t)
+	sink := &memorySink{}
+	logger := newTestLogger(t, server.URL+EndpointRaw, 2, WithPumpMode(), WithSink(sink, ""))
+	defer logger.Close()
+
+	for i := 0; i < 3; i++ {
+		logger.Log(LevelInformation, "Order placed", nil)
+	}
+	if dropped := logger.Stats().Dropped; dropped != 1 {
+		t.Errorf("Expected 1 event dropped, got %d", dropped)
+	}
+	if err := logger.Resize(5); err != nil {
+		t.Fatalf("Failed to resize: %v", err)
+	}
+	if len(sink.templates) != 2 {
+		t.Errorf("Expected the resize to deliver the queued events, got %v", sink.templates)
+	}
+	for i := 0; i < 5; i++ {
+		logger.Log(LevelInformation, "Stock low", nil)
+	}
+	if err := logger.Flush(context.Background()); err != nil || len(sink.templates) != 7 {
+		t.Errorf("Expected 7 events delivered, got %v: %v", sink.templates, err)
+	}
+}
+
+func TestPumpDeliversUntilClosed(t *testing.T) {
+	server := newSeqRecorder(t)
+	logger := newTestLogger(t, server.URL+EndpointRaw, 10, WithPumpMode(), WithBatching(10, 5*time.Millisecond))
+	pumped := make(chan error, 1)
+	go func() { pumped <- logger.Pump(context.Background()) }()
+
+	logger.Log(LevelInformation, "Order placed", nil)
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(server.received(), "Order placed") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !strings.Contains(server.received(), "Order placed") {
+		t.Error("Expected Pump to deliver the event")
+	}
+
+	logger.Close()
+	select {
+	case err := <-pumped:
+		if err != nil {
+			t.Errorf("Expected Pump to end without error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Pump to return once the logger is closed")
+	}
+}
+
+func TestPumpModeRejectsGoroutineOptions(t *testing.T) {
+	_, err := NewSEQLogger("http://localhost:5341"+EndpointRaw, "", 10, WithPumpMode(), WithPrewarm(), WithSpill(t.TempDir()+"/spill", 1<<20))
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || configErr.Setting != "WithPumpMode" || !strings.Contains(err.Error(), "WithSpill") {
+		t.Errorf("Expected WithPumpMode rejected with WithPrewarm and WithSpill, got %v", err)
+	}
+
+	logger := newTestLogger(t, "http://localhost:5341"+EndpointRaw, 10)
+	defer logger.Close()
+	if err := logger.FlushNow(); !errors.Is(err, ErrNotPumped) {
+		t.Errorf("Expected ErrNotPumped, got %v", err)
+	}
+	if err := logger.Pump(context.Background()); !errors.Is(err, ErrNotPumped) {
+		t.Errorf("Expected ErrNotPumped, got %v", err)
+	}
+}
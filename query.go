@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryClient reads events and signals back from SEQ's HTTP API, e.g. to verify
+// ingestion or to build small dashboards and command line tools
+type QueryClient struct {
+	baseURL string
+	// settings is the logger whose transport, headers, authentication and retry policy
+	// the queries use
+	settings *SEQLogger
+}
+
+// Event is an event returned by SEQ's query API
+type Event struct {
+	ID                    string `json:"Id"`
+	Timestamp             string `json:"Timestamp"`
+	Level                 string `json:"Level"`
+	Exception             string `json:"Exception"`
+	RenderedMessage       string `json:"RenderedMessage"`
+	MessageTemplateTokens []struct {
+		Text         string `json:"Text"`
+		PropertyName string `json:"PropertyName"`
+	} `json:"MessageTemplateTokens"`
+	Properties []EventProperty `json:"Properties"`
+}
+
+// EventProperty is one property of an Event
+type EventProperty struct {
+	Name  string      `json:"Name"`
+	Value interface{} `json:"Value"`
+}
+
+// Property returns the value of the named property and whether the event has it
+func (e *Event) Property(name string) (interface{}, bool) {
+	for _, p := range e.Properties {
+		if p.Name == name {
+			return p.Value, true
+		}
+	}
+	return nil, false
+}
+
+// MessageTemplate reassembles the event's message template from its tokens
+func (e *Event) MessageTemplate() string {
+	var b strings.Builder
+	for _, t := range e.MessageTemplateTokens {
+		if t.PropertyName != "" {
+			b.WriteString("{" + t.PropertyName + "}")
+			continue
+		}
+		b.WriteString(t.Text)
+	}
+	return b.String()
+}
+
+// Signal is a saved filter defined on the SEQ server
+type Signal struct {
+	ID          string `json:"Id"`
+	Title       string `json:"Title"`
+	Description string `json:"Description"`
+	Filters     []struct {
+		Description string `json:"Description"`
+		Filter      string `json:"Filter"`
+	} `json:"Filters"`
+}
+
+// NewQueryClient creates a QueryClient for the SEQ server at serverURL, e.g.
+// http://localhost:5341. A nil transport uses a private one. opts set up the requests
+// as they would a logger's: WithBasicAuth, WithTokenSource, WithHeader, WithUserAgent
+// and WithRetry apply, options about sending events are ignored.
+func NewQueryClient(serverURL, apiKey string, transport *Transport, opts ...Option) *QueryClient {
+	settings := &SEQLogger{apiKey: apiKey, transport: transport, retry: defaultRetryPolicy}
+	for _, opt := range opts {
+		opt(settings)
+	}
+	if settings.transport == nil {
+		settings.transport = newPrivateTransport()
+	}
+	return &QueryClient{baseURL: serverBaseURL(serverURL), settings: settings}
+}
+
+// Query returns a QueryClient reading from the logger's server with its API key,
+// transport, headers, authentication and retry policy
+func (l *SEQLogger) Query() *QueryClient {
+	l = l.pipeline()
+	return &QueryClient{baseURL: serverBaseURL(l.seqURL), settings: l}
+}
+
+// serverBaseURL strips a known ingestion endpoint from url, leaving the server's base URL
+func serverBaseURL(url string) string {
+	url = strings.TrimSuffix(url, "/")
+	for _, endpoint := range []string{EndpointCLEF, EndpointRaw} {
+		url = strings.TrimSuffix(url, endpoint)
+	}
+	return url
+}
+
+// Events returns at most count of the most recent events matching filter, newest first.
+// An empty filter matches every event; count <= 0 leaves the limit to the server.
+func (c *QueryClient) Events(ctx context.Context, filter string, count int) ([]Event, error) {
+	return c.eventsAfter(ctx, filter, count, "")
+}
+
+// eventsAfter is Events paging back: it returns the events that come after the one
+// with Id afterID, that is older ones, or the most recent when afterID is empty
+func (c *QueryClient) eventsAfter(ctx context.Context, filter string, count int, afterID string) ([]Event, error) {
+	query := url.Values{"render": {"true"}}
+	if filter != "" {
+		query.Set("filter", filter)
+	}
+	if count > 0 {
+		query.Set("count", strconv.Itoa(count))
+	}
+	if afterID != "" {
+		query.Set("afterId", afterID)
+	}
+
+	var events []Event
+	if err := c.get(ctx, "/api/events", query, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Signals lists the signals shared on the server
+func (c *QueryClient) Signals(ctx context.Context) ([]Signal, error) {
+	var signals []Signal
+	if err := c.get(ctx, "/api/signals", url.Values{"shared": {"true"}}, &signals); err != nil {
+		return nil, err
+	}
+	return signals, nil
+}
+
+// get decodes the JSON response to a GET request for path into v, retrying network
+// errors, 429 and 5xx responses as the logger's retry policy does for ingestion
+func (c *QueryClient) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	retry := c.settings.retry
+	backoff := retry.initialBackoff
+	for attempt := 1; ; attempt++ {
+		err := c.getOnce(ctx, path, query, v)
+		if err == nil {
+			return nil
+		}
+		if !err.retryable || attempt >= retry.maxAttempts {
+			return err
+		}
+
+		wait := backoff
+		if err.retryAfter > 0 {
+			wait = err.retryAfter
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		backoff = retry.next(backoff)
+	}
+}
+
+// queryError is a failed query request and whether sending it again may help
+type queryError struct {
+	deliveryError
+	retryAfter time.Duration // asked for by a Retry-After header
+}
+
+// getOnce makes a single query request
+func (c *QueryClient) getOnce(ctx context.Context, path string, query url.Values, v interface{}) *queryError {
+	failed := func(retryable bool, cause error, format string, args ...interface{}) *queryError {
+		return &queryError{deliveryError: deliveryError{msg: fmt.Sprintf(format, args...), retryable: retryable, cause: cause}}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return failed(false, err, "Failed to create query request: %v", err)
+	}
+	c.settings.setHeaders(req)
+	if err := c.settings.authorize(ctx, req); err != nil {
+		return failed(true, err, "%v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.settings.transport.client.Do(req)
+	if err != nil {
+		return failed(ctx.Err() == nil, err, "Failed to query SEQ server: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		queryErr := failed(retryable, nil, "SEQ server responded with %v. Response: %s", resp.Status, body)
+		queryErr.retryAfter, _ = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		return queryErr
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return failed(false, err, "Failed to decode SEQ query response: %v", err)
+	}
+	return nil
+}
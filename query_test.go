@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestQueryClientEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/events" || r.URL.Query().Get("filter") != "OrderId = 7" || r.URL.Query().Get("count") != "10" {
+			t.Errorf("Unexpected query %s", r.URL)
+		}
+		if r.Header.Get("X-Seq-ApiKey") != "key" {
+			t.Errorf("Expected the API key to be sent, got %q", r.Header.Get("X-Seq-ApiKey"))
+		}
+		w.Write([]byte(`[{"Id": "event-1", "Level": "Information", "RenderedMessage": "Order 7 placed",
+			"MessageTemplateTokens": [{"Text": "Order "}, {"PropertyName": "OrderId"}, {"Text": " placed"}],
+			"Properties": [{"Name": "OrderId", "Value": 7}]}]`))
+	}))
+	defer server.Close()
+
+	events, err := NewQueryClient(server.URL+EndpointRaw, "key", nil).Events(context.Background(), "OrderId = 7", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+	e := events[0]
+	if e.ID != "event-1" || e.RenderedMessage != "Order 7 placed" || e.MessageTemplate() != "Order {OrderId} placed" {
+		t.Errorf("Unexpected event %+v", e)
+	}
+	if v, ok := e.Property("OrderId"); !ok || v != float64(7) {
+		t.Errorf("Expected OrderId 7, got %v", v)
+	}
+}
+
+func TestQueryClientSignals(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"Id": "signal-1", "Title": "Errors", "Filters": [{"Filter": "@Level = 'Error'"}]}]`))
+	}))
+	defer server.Close()
+
+	signals, err := NewQueryClient(server.URL, "", nil).Signals(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 1 || signals[0].Title != "Errors" || signals[0].Filters[0].Filter != "@Level = 'Error'" {
+		t.Errorf("Unexpected signals %+v", signals)
+	}
+}
+
+func TestQueryClientReportsServerErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"Error": "Syntax error"}`))
+	}))
+	defer server.Close()
+
+	if _, err := NewQueryClient(server.URL, "", nil).Events(context.Background(), "(", 1); err == nil {
+		t.Error("Expected a 400 response to be reported")
+	}
+}
+
+func TestQueryClientSharesLoggerRequestSettings(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if user, password, ok := r.BasicAuth(); !ok || user != "proxy" || password != "secret" {
+			t.Errorf("Expected the proxy credentials, got %q %q", user, password)
+		}
+		if r.Header.Get("X-Tenant") != "shop" || r.Header.Get("X-Seq-ApiKey") != "key" || r.Header.Get("User-Agent") == "" {
+			t.Errorf("Expected the logger's headers, got %v", r.Header)
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	logger := newTestLogger(t, server.URL+EndpointRaw, 1, WithBasicAuth("proxy", "secret"),
+		WithHeader("X-Tenant", "shop"), WithRetry(2, time.Millisecond, time.Millisecond))
+	logger.apiKey = "key"
+	defer logger.Close()
+
+	if _, err := logger.Named("Billing").Query().Events(context.Background(), "", 1); err != nil {
+		t.Fatalf("Expected the query to succeed on retry, got %v", err)
+	}
+	if n := attempts.Load(); n != 2 {
+		t.Errorf("Expected 2 attempts, got %d", n)
+	}
+}
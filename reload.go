@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ReloadConfig applies the minimum level, level overrides, sampling rates, global properties
+// and enrichers of a configuration file to the running logger. Buffered events are kept;
+// settings that need a new logger, such as the server URL or batching, are ignored.
+// The minimum level, level overrides, sampling, global properties and enrichers set in
+// code are kept, or restored once the file no longer replaces them.
+func (l *SEQLogger) ReloadConfig(path string) error {
+	l = l.pipeline()
+	config, err := LoadConfigFile(path)
+	if err != nil {
+		return err
+	}
+	if err := validateSampling(config.Sampling); err != nil {
+		return err
+	}
+	if err := validateOverrides(config.Overrides); err != nil {
+		return err
+	}
+	enrichers, err := configEnrichers(config.Enrichers)
+	if err != nil {
+		return err
+	}
+
+	if config.MinLevel != "" {
+		if _, ok := knownLevelRank(config.MinLevel); !ok {
+			return fmt.Errorf("unknown minLevel %q", config.MinLevel)
+		}
+	}
+
+	l.reloadMu.Lock()
+	defer l.reloadMu.Unlock()
+	l.replaceConfigMinLevel(config.MinLevel)
+	l.replaceConfigOverrides(config.Overrides)
+	l.replaceConfigSampling(config.Sampling)
+	l.replaceConfigFields(config.Properties)
+	l.replaceConfigEnrichers(enrichers)
+
+	return nil
+}
+
+// replaceConfigMinLevel sets the minimum level the configuration file asks for or, once
+// it leaves it out, restores the one it replaced unless it was changed since
+func (l *SEQLogger) replaceConfigMinLevel(level string) {
+	if level == "" {
+		if l.configMinLevel != "" {
+			rank, _ := knownLevelRank(l.configMinLevel)
+			l.minLevel.CompareAndSwap(int32(rank), l.codeMinLevel)
+		}
+		l.configMinLevel = ""
+		return
+	}
+	rank, _ := knownLevelRank(level)
+	previous := l.minLevel.Swap(int32(rank))
+	if l.configMinLevel == "" {
+		l.codeMinLevel = previous
+	}
+	l.configMinLevel = level
+}
+
+// replaceConfigSampling sets the sampling the configuration file asks for or, once it
+// leaves it out, restores the sampling it replaced unless it was changed since
+func (l *SEQLogger) replaceConfigSampling(sampling map[string]float64) {
+	if len(sampling) == 0 {
+		if l.configSampling != nil {
+			l.sampling.CompareAndSwap(l.configSampling, l.codeSampling)
+		}
+		l.configSampling = nil
+		return
+	}
+	rates := newSamplingRates(sampling)
+	previous := l.sampling.Swap(rates)
+	if l.configSampling == nil {
+		l.codeSampling = previous
+	}
+	l.configSampling = rates
+}
+
+// replaceConfigEnrichers swaps the enrichers that came from a configuration file,
+// keeping those added with WithEnricher and their order
+func (l *SEQLogger) replaceConfigEnrichers(configured []Enricher) {
+	current := l.enrichers
+	if reloaded := l.reloadedEnrichers.Load(); reloaded != nil {
+		current = *reloaded
+	}
+	enrichers := make([]Enricher, 0, len(current)+len(configured))
+	for _, enricher := range current {
+		if _, ok := enricher.(configEnricher); !ok {
+			enrichers = append(enrichers, enricher)
+		}
+	}
+	enrichers = append(enrichers, configured...)
+	l.reloadedEnrichers.Store(&enrichers)
+}
+
+// ReopenFiles reopens the files the sinks and the fallback write to, so that after
+// logrotate moves them away new events go to new files at the same paths
+func (l *SEQLogger) ReopenFiles() error {
+	l = l.pipeline()
+	sinks := make([]Sink, 0, len(l.sinks)+1)
+	for _, s := range l.sinks {
+		sinks = append(sinks, s.sink)
+	}
+	if l.fallback != nil {
+		sinks = append(sinks, l.fallback)
+	}
+
+	var errs []error
+	for _, sink := range sinks {
+		if reopener, ok := sink.(Reopener); ok {
+			if err := reopener.Reopen(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// hangup does what SIGHUP asks for: reopen the files, then reload path if it isn't empty
+func (l *SEQLogger) hangup(path string) {
+	if err := l.ReopenFiles(); err != nil {
+		selfLogf("Failed to reopen files: %v", err)
+	}
+	if path == "" {
+		return
+	}
+	if err := l.ReloadConfig(path); err != nil {
+		selfLogf("Failed to reload config file: %v", err)
+	}
+}
+
+// replaceConfigFields swaps the global fields that came from a configuration file,
+// leaving fields set with SetGlobalField alone
+func (l *SEQLogger) replaceConfigFields(properties map[string]interface{}) {
+	l.globalFields.update(func(globals map[string]interface{}) {
+		for key := range l.configFields {
+			delete(globals, key)
+		}
+		for key, value := range properties {
+			globals[key] = value
+		}
+		l.configFields = copyFields(properties)
+	})
+}
+
+// defaultWatchInterval is how often WatchConfigFile checks the file without a positive interval
+const defaultWatchInterval = 5 * time.Second
+
+// WatchConfigFile reloads the configuration file whenever it changes on disk, checked
+// every interval, or 5s when interval isn't positive, or when the process receives
+// SIGHUP, until ctx is done. SIGHUP also reopens the logger's files, as with
+// ReloadOnHangup. On js and wasip1, which have no signals, it only polls.
+// Reload errors are logged and the previous settings stay in effect.
+func (l *SEQLogger) WatchConfigFile(ctx context.Context, path string, interval time.Duration) {
+	l = l.pipeline()
+	hangup, stop := notifyHangup()
+	defer stop()
+
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastModified := configModTime(path)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			l.hangup(path)
+			continue
+		case <-ticker.C:
+			modified := configModTime(path)
+			if modified.Equal(lastModified) {
+				continue
+			}
+			lastModified = modified
+		}
+
+		if err := l.ReloadConfig(path); err != nil {
+			selfLogf("Failed to reload config file: %v", err)
+		}
+	}
+}
+
+// configModTime returns the modification time of path, or the zero time if it can't be read
+func configModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,35 @@
+//go:build !js && !wasip1
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ReloadOnHangup reopens the logger's files and, when path isn't empty, reloads the
+// configuration file whenever the process receives SIGHUP, until ctx is done. This
+// lets logrotate and configuration pushes reach a long running shipper without a
+// restart. Errors are logged and the previous files and settings stay in effect.
+func (l *SEQLogger) ReloadOnHangup(ctx context.Context, path string) {
+	hangup, stop := notifyHangup()
+	defer stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			l.hangup(path)
+		}
+	}
+}
+
+// notifyHangup relays SIGHUP to the returned channel until stop is called
+func notifyHangup() (hangup <-chan os.Signal, stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	return signals, func() { signal.Stop(signals) }
+}
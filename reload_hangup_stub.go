@@ -0,0 +1,19 @@
+//go:build js || wasip1
+
+package main
+
+import (
+	"context"
+	"os"
+)
+
+// ReloadOnHangup waits for ctx to be done: js and wasip1 have no SIGHUP to react to.
+// Elsewhere it reopens the logger's files and reloads path on every SIGHUP.
+func (l *SEQLogger) ReloadOnHangup(ctx context.Context, path string) {
+	<-ctx.Done()
+}
+
+// notifyHangup returns a channel that never receives, as there are no signals here
+func notifyHangup() (hangup <-chan os.Signal, stop func()) {
+	return nil, func() {}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestReloadConfigAppliesRuntimeSettings(t *testing.T) {
+	path := writeConfigFile(t, "seqlogger.yaml", `
+serverUrl: http://localhost:5341/api/events/raw
+minLevel: Debug
+properties:
+  Application: billing
+  Region: eu
+`)
+	logger := newQueueLogger(10)
+	if err := logger.ReloadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	logger.SetGlobalField("Role", "leader")
+
+	if err := os.WriteFile(path, []byte(`
+serverUrl: http://localhost:5341/api/events/raw
+minLevel: Warning
+sampling:
+  Warning: 0
+properties:
+  Application: billing-v2
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.ReloadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Log(LevelInformation, "Filtered by min level", nil)
+	logger.Log(LevelWarning, "Sampled out", nil)
+	logger.Log(LevelError, "Kept", nil)
+
+	if len(logger.logChan) != 1 {
+		t.Fatalf("Expected only the Error event to be queued, got %d events", len(logger.logChan))
+	}
+	kept := <-logger.logChan
+	if kept.Fields["Application"] != "billing-v2" || kept.Fields["Role"] != "leader" {
+		t.Errorf("Unexpected fields after reload %#v", kept.Fields)
+	}
+	if _, ok := kept.Fields["Region"]; ok {
+		t.Errorf("Expected Region removed from the config to be gone, got %#v", kept.Fields)
+	}
+}
+
+func TestReloadConfigKeepsSettingsOnError(t *testing.T) {
+	path := writeConfigFile(t, "seqlogger.yaml", "serverUrl: http://localhost\nminLevel: Error")
+	logger := newQueueLogger(1)
+	if err := logger.ReloadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+
+	os.WriteFile(path, []byte("serverUrl: http://localhost\nminLevel: Loud"), 0o600)
+	if err := logger.ReloadConfig(path); err == nil {
+		t.Fatal("Expected an error for an unknown level")
+	}
+	if logger.minLevel.Load() != int32(levelRank(LevelError)) {
+		t.Errorf("Expected the previous min level to stay in effect")
+	}
+}
+
+func TestWatchConfigFileReloadsOnChange(t *testing.T) {
+	path := writeConfigFile(t, "seqlogger.yaml", "serverUrl: http://localhost\nminLevel: Debug")
+	logger := newQueueLogger(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go logger.WatchConfigFile(ctx, path, 10*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+	os.WriteFile(path, []byte("serverUrl: http://localhost\nminLevel: Fatal"), 0o600)
+	os.Chtimes(path, time.Now().Add(time.Second), time.Now().Add(time.Second))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for logger.minLevel.Load() != int32(levelRank(LevelFatal)) {
+		if time.Now().After(deadline) {
+			t.Fatal("Config was not reloaded after the file changed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatchConfigFileWithoutInterval(t *testing.T) {
+	path := writeConfigFile(t, "seqlogger.yaml", "serverUrl: http://localhost")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// Returns at once instead of panicking in time.NewTicker
+	newQueueLogger(1).WatchConfigFile(ctx, path, 0)
+}
+
+func TestReloadConfigReplacesConfigEnrichers(t *testing.T) {
+	path := writeConfigFile(t, "seqlogger.yaml", "serverUrl: http://localhost\nenrichers: [hostname]")
+	config, err := LoadConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := config.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts = append(opts, WithEnricher(EnricherFunc(func(fields map[string]interface{}) {
+		fields["Component"] = "billing"
+	})))
+	logger := newQueueLogger(1, opts...)
+
+	os.WriteFile(path, []byte("serverUrl: http://localhost\nenrichers: [runtime]"), 0o600)
+	if err := logger.ReloadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	logger.Log(LevelInformation, "Reloaded", nil)
+
+	fields := (<-logger.logChan).Fields
+	if _, ok := fields["MachineName"]; ok {
+		t.Errorf("Expected the hostname enricher removed from the config to be gone, got %v", fields)
+	}
+	if fields["GoVersion"] == nil || fields["Component"] != "billing" {
+		t.Errorf("Expected the runtime enricher and the one added in code, got %v", fields)
+	}
+}
+
+func TestReloadConfigKeepsOverridesSetInCode(t *testing.T) {
+	path := writeConfigFile(t, "seqlogger.yaml", `
+serverUrl: http://localhost:5341/api/events/raw
+overrides:
+  payments: Error
+  http: Warning
+`)
+	logger := newTestLogger(t, "http://localhost:5341/api/events/raw", 1, WithLevelOverride("billing", LevelWarning))
+	defer logger.Close()
+	if err := logger.ReloadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	if overrides := logger.currentOverrides(); len(overrides) != 3 {
+		t.Errorf("Expected the config overrides added to the one set in code, got %v", overrides)
+	}
+
+	if err := os.WriteFile(path, []byte("serverUrl: http://localhost:5341/api/events/raw\noverrides:\n  http: Debug\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.ReloadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+
+	overrides := logger.currentOverrides()
+	if len(overrides) != 2 || overrides["billing"] != LevelWarning || overrides["http"] != LevelDebug {
+		t.Errorf("Expected the override set in code kept and the config ones replaced, got %v", overrides)
+	}
+}
+
+func TestReloadConfigKeepsMinLevelAndSamplingSetInCode(t *testing.T) {
+	path := writeConfigFile(t, "seqlogger.yaml", "serverUrl: http://localhost:5341/api/events/raw")
+	logger := newTestLogger(t, "http://localhost:5341/api/events/raw", 1,
+		WithMinLevel(LevelWarning), WithSampling(map[string]float64{LevelDebug: 0.5}))
+	defer logger.Close()
+	sampling := logger.sampling.Load()
+
+	if err := logger.ReloadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	if logger.minLevel.Load() != int32(levelRank(LevelWarning)) || logger.sampling.Load() != sampling {
+		t.Errorf("Expected a file without them to keep the min level and sampling set in code, got %d and %v",
+			logger.minLevel.Load(), logger.sampling.Load())
+	}
+
+	if err := os.WriteFile(path, []byte("serverUrl: http://localhost:5341/api/events/raw\nminLevel: Error\nsampling:\n  Information: 0.1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.ReloadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	if logger.minLevel.Load() != int32(levelRank(LevelError)) || logger.sampling.Load() == sampling {
+		t.Fatal("Expected the file's min level and sampling to apply")
+	}
+
+	if err := os.WriteFile(path, []byte("serverUrl: http://localhost:5341/api/events/raw\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.ReloadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	if logger.minLevel.Load() != int32(levelRank(LevelWarning)) || logger.sampling.Load() != sampling {
+		t.Errorf("Expected the min level and sampling set in code restored, got %d and %v",
+			logger.minLevel.Load(), logger.sampling.Load())
+	}
+}
+
+func TestVetsWithoutSignals(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Vetting for other platforms runs the go command")
+	}
+	gocmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("No go command to vet with")
+	}
+	for _, target := range [][2]string{{"js", "wasm"}, {"wasip1", "wasm"}} {
+		cmd := exec.Command(gocmd, "vet", ".")
+		cmd.Env = append(os.Environ(), "GOOS="+target[0], "GOARCH="+target[1])
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("Expected the package to vet for %s/%s, got %v\n%s", target[0], target[1], err, output)
+		}
+	}
+}
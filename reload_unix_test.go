@@ -0,0 +1,67 @@
+//go:build unix
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReloadOnHangupReopensFiles(t *testing.T) {
+	dir := t.TempDir()
+	sinkPath := filepath.Join(dir, "events.clef")
+	path := writeConfigFile(t, "seqlogger.yaml", `
+serverUrl: http://localhost:5341/api/events/raw
+minLevel: Debug
+sinks:
+  - type: file
+    path: `+sinkPath+`
+`)
+	config, err := LoadConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := config.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := newQueueLogger(1, opts...)
+	sink := logger.sinks[0].sink.(*FileSink)
+	defer sink.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go logger.ReloadOnHangup(ctx, path)
+	time.Sleep(50 * time.Millisecond)
+
+	// As logrotate does: move the file away, rewrite the config, then send SIGHUP
+	if err := os.Rename(sinkPath, sinkPath+".1"); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(path, []byte("serverUrl: http://localhost:5341/api/events/raw\nminLevel: Error"), 0o600)
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for logger.minLevel.Load() != int32(levelRank(LevelError)) {
+		if time.Now().After(deadline) {
+			t.Fatal("Config was not reloaded on SIGHUP")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	sink.Emit([]LogMessage{{Timestamp: "2024-01-02T03:04:05Z", Level: LevelError, MessageTemplate: "After rotation"}})
+
+	data, err := os.ReadFile(sinkPath)
+	if err != nil {
+		t.Fatalf("Expected the sink file to be reopened at its path: %v", err)
+	}
+	if !strings.Contains(string(data), "After rotation") {
+		t.Errorf("Expected new events in the reopened file, got %s", data)
+	}
+}
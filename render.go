@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// renderMessage renders the event's template with its property values, e.g.
+// "User 42 logged in", for local output where Seq isn't there to do it.
+// Placeholders without a matching property keep their raw text.
+func renderMessage(logMessage *LogMessage) string {
+	parsed := templates.get(logMessage.MessageTemplate)
+	if len(parsed.properties) == 0 {
+		return logMessage.MessageTemplate
+	}
+
+	var b strings.Builder
+	for _, token := range parsed.tokens {
+		if token.Property == "" {
+			b.WriteString(token.Text)
+			continue
+		}
+		value, ok := logMessage.Fields[token.Property]
+		if !ok {
+			b.WriteString(token.Text)
+			continue
+		}
+		writeAligned(&b, renderedValue(logMessage, token, value), token.Alignment)
+	}
+	return b.String()
+}
+
+// renderedValue formats one property value: with the rendering computed from the raw
+// value when the placeholder has a format, as is for strings and as JSON otherwise
+func renderedValue(logMessage *LogMessage, token templateToken, value interface{}) string {
+	if token.Format != "" {
+		for _, rendering := range logMessage.Renderings {
+			if rendering.Property == token.Property && rendering.Format == token.Format {
+				return rendering.Rendering
+			}
+		}
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	if data, err := json.Marshal(value); err == nil {
+		return string(data)
+	}
+	return fmt.Sprint(value)
+}
+
+// writeAligned writes s padded to the placeholder's alignment: right-aligned for a
+// positive width, left-aligned for a negative one
+func writeAligned(b *strings.Builder, s, alignment string) {
+	width, _ := strconv.Atoi(alignment)
+	pad := width
+	if pad < 0 {
+		pad = -pad
+	}
+	pad -= len([]rune(s))
+
+	if width > 0 && pad > 0 {
+		b.WriteString(strings.Repeat(" ", pad))
+	}
+	b.WriteString(s)
+	if width < 0 && pad > 0 {
+		b.WriteString(strings.Repeat(" ", pad))
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestRenderMessage(t *testing.T) {
+	logger := newQueueLogger(1)
+	logger.Log(LevelInformation, "User {UserId} logged in from {Country,-4}| took {Elapsed:000} ms, {Missing} {{braces}}",
+		map[string]interface{}{
+			"UserId":  42,
+			"Country": "NL",
+			"Elapsed": 7,
+		})
+	logMessage := <-logger.logChan
+
+	want := "User 42 logged in from NL  | took 007 ms, {Missing} {braces}"
+	if got := renderMessage(&logMessage); got != want {
+		t.Errorf("renderMessage() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderMessageStructuredValue(t *testing.T) {
+	logMessage := LogMessage{
+		MessageTemplate: "Cart {@Cart} checked out",
+		Fields:          map[string]interface{}{"Cart": map[string]interface{}{"Items": 3}},
+	}
+	if got := renderMessage(&logMessage); got != `Cart {"Items":3} checked out` {
+		t.Errorf("renderMessage() = %q", got)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import "fmt"
+
+// Resize replaces the queue with one of capacity events, so an operator can give
+// the logger more room during an incident, or take it back, without restarting.
+// No event is dropped: the processing goroutine delivers the events already queued
+// before it moves on to the new queue, even when there are more of them than fit in
+// it. Log calls wait while the resize waits for room in the old queue. In pump mode
+// the caller delivers the events already queued itself, see WithPumpMode.
+func (l *SEQLogger) Resize(capacity int) error {
+	l = l.pipeline()
+	if capacity < 1 {
+		return fmt.Errorf("queue capacity must be at least 1, got %d", capacity)
+	}
+
+	l.closeMu.Lock()
+	defer l.closeMu.Unlock()
+	if l.closed.Load() {
+		return ErrClosed
+	}
+	queue := make(chan LogMessage, capacity)
+	if l.pump != nil {
+		l.pumpResize(queue)
+	} else {
+		// The marker is the last message of the old queue and points processLogs to the new one
+		l.logChan <- LogMessage{resized: queue}
+	}
+	l.logChan = queue
+	l.queue.Store(&queue)
+	return nil
+}
+
+// currentQueue returns logChan for callers that don't hold closeMu
+func (l *SEQLogger) currentQueue() chan LogMessage {
+	if queue := l.queue.Load(); queue != nil {
+		return *queue
+	}
+	return l.logChan
+}
+
+// requeue sends events on logChan from a goroutine other than a Log call, holding
+// closeMu for reading so Resize can't swap the queue underneath it
+func (l *SEQLogger) requeue(events []LogMessage) {
+	l.closeMu.RLock()
+	defer l.closeMu.RUnlock()
+	for _, logMessage := range events {
+		l.logChan <- logMessage
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestResizeKeepsQueuedEventsInOrder(t *testing.T) {
+	server := newSeqRecorder(t)
+	sink := &memorySink{}
+	logger := newTestLogger(t, server.URL+EndpointRaw, 10, WithSink(sink, ""))
+
+	var want []string
+	for i := 0; i < 8; i++ {
+		want = append(want, fmt.Sprintf("Before %d", i))
+		logger.Log(LevelInformation, want[i], nil)
+	}
+	if err := logger.Resize(2); err != nil {
+		t.Fatalf("Failed to shrink the queue: %v", err)
+	}
+	if capacity := logger.QueueCapacity(); capacity != 2 {
+		t.Errorf("Expected a capacity of 2, got %d", capacity)
+	}
+	if err := logger.Named("Billing").Resize(50); err != nil {
+		t.Fatalf("Failed to grow the queue: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		want = append(want, fmt.Sprintf("After %d", i))
+		logger.Log(LevelInformation, want[len(want)-1], nil)
+	}
+	logger.Close()
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if got := strings.Join(sink.templates, ", "); got != strings.Join(want, ", ") {
+		t.Errorf("Expected every event in order, got %s", got)
+	}
+}
+
+func TestResizeRejectsBadCapacityAndClosedLogger(t *testing.T) {
+	server := newSeqRecorder(t)
+	logger := newTestLogger(t, server.URL+EndpointRaw, 10)
+	if err := logger.Resize(0); err == nil {
+		t.Error("Expected a capacity of 0 to be rejected")
+	}
+	logger.Close()
+	if err := logger.Resize(20); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import "math/rand"
+
+// samplingRates holds the fraction of events kept per level rank
+type samplingRates [levelCount]float64
+
+// newSamplingRates builds samplingRates from level names; unlisted levels keep every event
+func newSamplingRates(rates map[string]float64) *samplingRates {
+	sampling := &samplingRates{}
+	for i := range sampling {
+		sampling[i] = 1
+	}
+	for level, rate := range rates {
+		sampling[levelRank(level)] = rate
+	}
+	return sampling
+}
+
+// keep decides whether an event at the given level rank is sampled in
+func (s *samplingRates) keep(rank int) bool {
+	rate := s[rank]
+	return rate >= 1 || rand.Float64() < rate
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// sanitizeString replaces invalid UTF-8 in s with U+FFFD and escapes control
+// characters other than tab, newline and carriage return as \xNN, or \u00NN for the
+// C1 range, so a stray binary value can neither make SEQ reject a batch nor inject
+// terminal escapes into sinks that print events as text. It reports whether s changed
+// and allocates only then.
+func sanitizeString(s string) (string, bool) {
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if isControl(rune(c)) {
+				break
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if (r == utf8.RuneError && size == 1) || isControl(r) {
+			break
+		}
+		i += size
+	}
+	if i == len(s) {
+		return s, false
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+	b.WriteString(s[:i])
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			b.WriteRune(utf8.RuneError)
+		case r < utf8.RuneSelf && isControl(r):
+			b.WriteString(`\x`)
+			b.WriteByte(hexDigits[r>>4])
+			b.WriteByte(hexDigits[r&0xF])
+		case isControl(r):
+			b.WriteString(`\u00`)
+			b.WriteByte(hexDigits[r>>4])
+			b.WriteByte(hexDigits[r&0xF])
+		default:
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return b.String(), true
+}
+
+// isControl reports whether r is a C0 or C1 control character, or DEL, that
+// sanitizeString escapes; tab, newline and carriage return are kept as they are
+func isControl(r rune) bool {
+	switch r {
+	case '\t', '\n', '\r':
+		return false
+	}
+	return r < 0x20 || (r >= 0x7f && r <= 0x9f)
+}
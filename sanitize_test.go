@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestSanitizeString(t *testing.T) {
+	cases := map[string]string{
+		"plain text":         "plain text",
+		"line\nbreak\ttab\r": "line\nbreak\ttab\r",
+		"bell\x07":           `bell\x07`,
+		"\x1b[31mred":        `\x1b[31mred`,
+		"bad\xffbyte":        "bad�byte",
+		"c1\u009bcsi":        `c1\u009bcsi`,
+		"héllo ✓":            "héllo ✓",
+	}
+	for input, want := range cases {
+		got, changed := sanitizeString(input)
+		if got != want || changed != (input != want) {
+			t.Errorf("sanitizeString(%q) = %q, %v, want %q", input, got, changed, want)
+		}
+	}
+}
+
+func TestLogSanitizesTemplateAndProperties(t *testing.T) {
+	logger := newQueueLogger(1)
+	logger.Log(LevelInformation, "Read {File}\x00", map[string]interface{}{
+		"File":  "report\xfe.csv",
+		"Lines": []string{"ok", "\x1b[2J"},
+	})
+
+	logMessage := <-logger.logChan
+	if logMessage.MessageTemplate != `Read {File}\x00` {
+		t.Errorf("Unexpected template %q", logMessage.MessageTemplate)
+	}
+	if logMessage.Fields["File"] != "report�.csv" {
+		t.Errorf("Unexpected File %q", logMessage.Fields["File"])
+	}
+	if lines := logMessage.Fields["Lines"].([]interface{}); lines[1] != `\x1b[2J` {
+		t.Errorf("Unexpected Lines %#v", lines)
+	}
+}
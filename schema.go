@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventTypeProperty carries the Go type name of an event logged with Emit
+const EventTypeProperty = "EventType"
+
+// eventSchema is a struct type registered with RegisterEvent
+type eventSchema struct {
+	name     string
+	level    string
+	template string
+	fields   []schemaField
+}
+
+// schemaField is an exported field of a registered struct and the property it becomes
+type schemaField struct {
+	index    int
+	property string
+	required bool
+}
+
+// eventSchemas maps the struct types registered with a logger to their schemas
+type eventSchemas struct {
+	mu      sync.RWMutex
+	schemas map[reflect.Type]*eventSchema
+}
+
+// EventValidationError is returned by Emit for an event that doesn't match its
+// schema, and by RegisterEvent for a struct that can't be one. Field is empty when
+// the problem isn't with a single field.
+type EventValidationError struct {
+	Event  string
+	Field  string
+	Reason string
+}
+
+func (e *EventValidationError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("Invalid event %s: %s", e.Event, e.Reason)
+	}
+	return fmt.Sprintf("Invalid event %s: field %s %s", e.Event, e.Field, e.Reason)
+}
+
+// RegisterEvent registers the struct type of event as a schema logged at level with
+// template, so values of it can be logged with Emit. Each exported field becomes a
+// property named by its seq tag, `seq:"UserId,required"`, or else by the field; a
+// "-" tag leaves the field out and "required" makes Emit reject its zero value.
+// Every property of template must be a field, and fields must hold values SEQ can
+// store, so channels and functions are rejected.
+func (l *SEQLogger) RegisterEvent(event interface{}, level, template string) error {
+	t := reflect.TypeOf(event)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return &EventValidationError{Event: fmt.Sprint(t), Reason: "is not a struct"}
+	}
+	if _, ok := knownLevelRank(level); !ok {
+		return &EventValidationError{Event: t.Name(), Reason: fmt.Sprintf("has unknown level %q", level)}
+	}
+
+	schema := &eventSchema{name: t.Name(), level: level, template: template}
+	properties := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("seq")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		if !loggableType(field.Type) {
+			return &EventValidationError{Event: schema.name, Field: field.Name, Reason: fmt.Sprintf("has type %s, which can't be logged", field.Type)}
+		}
+		schema.fields = append(schema.fields, schemaField{index: i, property: name, required: options == "required"})
+		properties[name] = true
+	}
+	for _, name := range templates.get(template).properties {
+		if !properties[name] {
+			return &EventValidationError{Event: schema.name, Reason: fmt.Sprintf("has no field for template property {%s}", name)}
+		}
+	}
+
+	root := l.pipeline()
+	root.schemas.mu.Lock()
+	defer root.schemas.mu.Unlock()
+	if root.schemas.schemas == nil {
+		root.schemas.schemas = make(map[reflect.Type]*eventSchema)
+	}
+	root.schemas.schemas[t] = schema
+	return nil
+}
+
+// Emit logs event, a value of a type registered with RegisterEvent, with its fields
+// as properties and its type name as EventType. It returns an *EventValidationError,
+// and logs nothing, when the type isn't registered or a required field is empty.
+func (l *SEQLogger) Emit(event interface{}) error {
+	v := reflect.ValueOf(event)
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return &EventValidationError{Event: fmt.Sprint(reflect.TypeOf(event)), Reason: "is not a struct"}
+	}
+	root := l.pipeline()
+	root.schemas.mu.RLock()
+	schema, ok := root.schemas.schemas[v.Type()]
+	root.schemas.mu.RUnlock()
+	if !ok {
+		return &EventValidationError{Event: fmt.Sprint(v.Type()), Reason: "is not registered"}
+	}
+
+	fields := make(map[string]interface{}, len(schema.fields)+1)
+	for _, field := range schema.fields {
+		value := v.Field(field.index)
+		if field.required && value.IsZero() {
+			return &EventValidationError{Event: schema.name, Field: v.Type().Field(field.index).Name, Reason: "is required"}
+		}
+		fields[field.property] = value.Interface()
+	}
+	fields[EventTypeProperty] = schema.name
+	l.Log(schema.level, schema.template, fields)
+	return nil
+}
+
+// loggableType reports whether values of t can be encoded as a property
+func loggableType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+		return false
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return loggableType(t.Elem())
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && loggableType(t.Elem())
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			return true
+		}
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).IsExported() && !loggableType(t.Field(i).Type) {
+				return false
+			}
+		}
+	}
+	return true
+}
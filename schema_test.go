@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+type userDeleted struct {
+	UserID    string `seq:"UserId,required"`
+	DeletedBy string
+	Reason    string `seq:"-"`
+	internal  int
+}
+
+func TestEmitLogsRegisteredEvent(t *testing.T) {
+	logger := newQueueLogger(1)
+	if err := logger.RegisterEvent(userDeleted{}, LevelWarning, "User {UserId} deleted by {DeletedBy}"); err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	if err := logger.Emit(&userDeleted{UserID: "u-7", DeletedBy: "admin", Reason: "spam"}); err != nil {
+		t.Fatalf("Failed to emit: %v", err)
+	}
+
+	logMessage := <-logger.logChan
+	if logMessage.Level != LevelWarning || logMessage.Fields["UserId"] != "u-7" || logMessage.Fields["DeletedBy"] != "admin" || logMessage.Fields[EventTypeProperty] != "userDeleted" {
+		t.Errorf("Unexpected event %+v", logMessage)
+	}
+	if _, ok := logMessage.Fields["Reason"]; ok {
+		t.Errorf("Expected the untagged field to be left out, got %v", logMessage.Fields)
+	}
+}
+
+func TestEmitRejectsInvalidEvents(t *testing.T) {
+	logger := newQueueLogger(1)
+	if err := logger.RegisterEvent(userDeleted{}, LevelInformation, "User {UserId} deleted"); err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	var invalid *EventValidationError
+	if err := logger.Emit(userDeleted{DeletedBy: "admin"}); !errors.As(err, &invalid) || invalid.Field != "UserID" {
+		t.Errorf("Expected a missing required field, got %v", err)
+	}
+	if err := logger.Emit(struct{ Name string }{"x"}); !errors.As(err, &invalid) {
+		t.Errorf("Expected an unregistered type to be rejected, got %v", err)
+	}
+	if err := logger.Emit(nil); !errors.As(err, &invalid) {
+		t.Errorf("Expected nil to be rejected, got %v", err)
+	}
+	if len(logger.logChan) != 0 {
+		t.Errorf("Expected nothing logged, got %d events", len(logger.logChan))
+	}
+}
+
+func TestRegisterEventRejectsBadSchemas(t *testing.T) {
+	logger := newQueueLogger(1)
+	cases := map[string]struct {
+		event    interface{}
+		level    string
+		template string
+	}{
+		"missing property": {userDeleted{}, LevelInformation, "User {UserId} deleted at {When}"},
+		"unknown level":    {userDeleted{}, "Loud", "User {UserId} deleted"},
+		"not a struct":     {"UserDeleted", LevelInformation, "User deleted"},
+		"func field":       {struct{ Callback func() }{}, LevelInformation, "Called"},
+	}
+	for name, c := range cases {
+		var invalid *EventValidationError
+		if err := logger.RegisterEvent(c.event, c.level, c.template); !errors.As(err, &invalid) {
+			t.Errorf("%s: expected an *EventValidationError, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync/atomic"
+	"time"
+)
+
+// Properties of the events logged through a Scope
+const (
+	ScopeIDProperty       = "ScopeId"
+	ParentScopeIDProperty = "ParentScopeId"
+	ScopeNameProperty     = "ScopeName"
+)
+
+// Scope is a named span of activity started with BeginScope. Every event logged
+// through its Logger carries its ScopeId, and scopes begun from that logger record it
+// as their ParentScopeId, so SEQ can show the events of an activity, and its nesting,
+// with a query such as ScopeId = '...'.
+type Scope struct {
+	logger  *SEQLogger
+	started time.Time
+	ended   atomic.Bool
+}
+
+// BeginScope logs "Scope {ScopeName} started" and returns a Scope whose Logger adds
+// fields, the ScopeId and the scope name to its events
+func (l *SEQLogger) BeginScope(name string, fields map[string]interface{}) *Scope {
+	scopeFields := make(map[string]interface{}, len(fields)+3)
+	for key, value := range fields {
+		scopeFields[key] = value
+	}
+	if parent, ok := l.contextFields[ScopeIDProperty]; ok {
+		scopeFields[ParentScopeIDProperty] = parent
+	}
+	scopeFields[ScopeIDProperty] = newScopeID()
+	scopeFields[ScopeNameProperty] = name
+
+	s := &Scope{logger: l.ForContext(scopeFields), started: time.Now()}
+	s.logger.Log(LevelInformation, "Scope {"+ScopeNameProperty+"} started", nil)
+	return s
+}
+
+// Logger returns the child logger stamping the scope's properties on its events
+func (s *Scope) Logger() *SEQLogger {
+	return s.logger
+}
+
+// ID returns the scope's ScopeId
+func (s *Scope) ID() string {
+	id, _ := s.logger.contextFields[ScopeIDProperty].(string)
+	return id
+}
+
+// End logs "Scope {ScopeName} ended after {Elapsed} ms", the time since the scope
+// began in milliseconds. Only the first call logs.
+func (s *Scope) End() {
+	if !s.ended.CompareAndSwap(false, true) {
+		return
+	}
+	elapsed := float64(time.Since(s.started)) / float64(time.Millisecond)
+	s.logger.Log(LevelInformation, "Scope {"+ScopeNameProperty+"} ended after {"+ElapsedProperty+":0.0} ms",
+		map[string]interface{}{ElapsedProperty: elapsed})
+}
+
+// newScopeID returns a random 64-bit scope id in hex
+func newScopeID() string {
+	var id [8]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
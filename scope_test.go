@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+func TestScopeLinksItsEvents(t *testing.T) {
+	logger := newQueueLogger(10)
+	checkout := logger.BeginScope("Checkout", map[string]interface{}{"CartId": 7})
+
+	started := <-logger.logChan
+	if started.MessageTemplate != "Scope {ScopeName} started" || started.Fields[ScopeNameProperty] != "Checkout" || started.Fields["CartId"] != 7 {
+		t.Errorf("Unexpected start event %+v", started)
+	}
+	id := checkout.ID()
+	if len(id) != 16 || started.Fields[ScopeIDProperty] != id {
+		t.Errorf("Expected the start event to carry the scope id %q, got %v", id, started.Fields)
+	}
+
+	payment := checkout.Logger().BeginScope("Payment", nil)
+	nested := <-logger.logChan
+	if nested.Fields[ParentScopeIDProperty] != id || nested.Fields[ScopeIDProperty] == id {
+		t.Errorf("Expected the nested scope to record its parent, got %v", nested.Fields)
+	}
+	if _, ok := started.Fields[ParentScopeIDProperty]; ok {
+		t.Errorf("Expected no parent for the outer scope, got %v", started.Fields)
+	}
+
+	payment.End()
+	checkout.Logger().Log(LevelWarning, "Card declined", nil)
+	checkout.End()
+	checkout.End()
+
+	if ended := <-logger.logChan; ended.Fields[ScopeIDProperty] != payment.ID() || ended.Fields[ElapsedProperty] == nil {
+		t.Errorf("Unexpected end event %+v", ended)
+	}
+	if inner := <-logger.logChan; inner.Fields[ScopeIDProperty] != id {
+		t.Errorf("Expected events logged through the scope to carry its id, got %v", inner.Fields)
+	}
+	if ended := <-logger.logChan; ended.MessageTemplate != "Scope {ScopeName} ended after {Elapsed:0.0} ms" || ended.Fields[ScopeIDProperty] != id {
+		t.Errorf("Unexpected end event %+v", ended)
+	}
+	if len(logger.logChan) != 0 {
+		t.Errorf("Expected a scope to end once, got %d more events", len(logger.logChan))
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"log"
+	"sync/atomic"
+)
+
+// selfLog receives the logger's diagnostics about itself, such as delivery failures
+// and template lint warnings; it writes to the standard logger unless replaced
+var selfLog atomic.Pointer[func(format string, args ...interface{})]
+
+// SetSelfLog sends the logger's own diagnostics to fn instead of the standard log
+// package, e.g. to a test's t.Logf; nil restores the default
+func SetSelfLog(fn func(format string, args ...interface{})) {
+	if fn == nil {
+		selfLog.Store(nil)
+		return
+	}
+	selfLog.Store(&fn)
+}
+
+// selfLogf writes a diagnostic message to the self log
+func selfLogf(format string, args ...interface{}) {
+	if fn := selfLog.Load(); fn != nil {
+		(*fn)(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
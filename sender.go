@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// retryPolicy controls how often and how patiently a failed batch is resent
+type retryPolicy struct {
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+// defaultRetryPolicy sends each batch once, without retrying
+var defaultRetryPolicy = retryPolicy{
+	maxAttempts:    1,
+	initialBackoff: 500 * time.Millisecond,
+	maxBackoff:     30 * time.Second,
+}
+
+// deliveryError describes why a batch could not be delivered and whether resending it may help
+type deliveryError struct {
+	msg       string
+	retryable bool
+	cause     error // the typed error behind msg, if any
+	// tokenRejected is set on a 401 to a bearer token that was dropped, see post
+	tokenRejected bool
+}
+
+func (e *deliveryError) Error() string {
+	return e.msg
+}
+
+func (e *deliveryError) Unwrap() error {
+	return e.cause
+}
+
+// AuthError is passed to the WithOnAuthError callback when the server rejects the API key
+type AuthError struct {
+	// StatusCode is 401 Unauthorized or 403 Forbidden
+	StatusCode int
+	// Response is the body of the server's response
+	Response string
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("SEQ server rejected the API key with %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Response)
+}
+
+// sendBatch posts an encoded batch to the SEQ server, retrying transient failures with
+// exponential backoff and falling back once the attempts are used up.
+// It returns the last error when the server did not accept the batch.
+func (l *SEQLogger) sendBatch(client *http.Client, data []byte, batch []LogMessage) error {
+	body, release := l.compressBody(data)
+	defer release()
+
+	backoff := l.retry.initialBackoff
+	for attempt := 1; ; attempt++ {
+		err := l.post(context.Background(), client, body)
+		if err == nil {
+			l.deliveryStats.recordDelivery(batch, time.Now())
+			return nil
+		}
+		if !err.retryable || attempt >= l.retry.maxAttempts {
+			selfLogf("%v", err)
+			// deliver resends the rest of a batch rejected for a single malformed event
+			if _, ok := l.malformedEvent(err, len(batch)); !ok {
+				l.fallBack(batch)
+			}
+			return err
+		}
+
+		time.Sleep(backoff)
+		backoff = l.retry.next(backoff)
+	}
+}
+
+// next returns the backoff following backoff, doubling it up to maxBackoff
+func (p retryPolicy) next(backoff time.Duration) time.Duration {
+	backoff *= 2
+	if backoff > p.maxBackoff {
+		backoff = p.maxBackoff
+	}
+	return backoff
+}
+
+// maxRetryAfter caps the pause requested by a Retry-After header, guarding against
+// misconfigured proxies asking for hours
+const maxRetryAfter = 5 * time.Minute
+
+// parseRetryAfter reads a Retry-After header given as seconds or as an HTTP date
+func parseRetryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(strings.TrimSpace(header)); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(header); err == nil {
+		delay = at.Sub(now)
+	} else {
+		return 0, false
+	}
+
+	if delay <= 0 {
+		return 0, false
+	}
+	if delay > maxRetryAfter {
+		delay = maxRetryAfter
+	}
+	return delay, true
+}
+
+// pause holds back every request to the server for delay, as asked by a Retry-After header
+func (l *SEQLogger) pause(delay time.Duration) {
+	until := time.Now().Add(delay).UnixNano()
+	for {
+		current := l.pausedUntil.Load()
+		if current >= until || l.pausedUntil.CompareAndSwap(current, until) {
+			return
+		}
+	}
+}
+
+// waitPause waits for a pause requested by the server to end
+func (l *SEQLogger) waitPause(ctx context.Context) error {
+	remaining := time.Until(time.Unix(0, l.pausedUntil.Load()))
+	if remaining <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// post makes a single ingestion request; network errors, 429 and 5xx responses are retryable.
+// A Retry-After header on a 429 or 503 response holds back every request for that long.
+// A 401 to a bearer token of WithTokenSource is posted again right away with a new token.
+func (l *SEQLogger) post(ctx context.Context, client *http.Client, body requestBody) *deliveryError {
+	err := l.postOnce(ctx, client, body, l.tokens != nil)
+	if err != nil && err.tokenRejected {
+		err = l.postOnce(ctx, client, body, false)
+	}
+	return err
+}
+
+// postOnce is post without the second attempt; refresh reports whether a rejected
+// bearer token is to be refreshed for one, in which case the 401 isn't surfaced
+func (l *SEQLogger) postOnce(ctx context.Context, client *http.Client, body requestBody, refresh bool) *deliveryError {
+	if err := l.waitPause(ctx); err != nil {
+		return &deliveryError{msg: fmt.Sprintf("Failed to send log message: %v", err)}
+	}
+	if l.pacer != nil {
+		if err := l.pacer.wait(ctx); err != nil {
+			return &deliveryError{msg: fmt.Sprintf("Failed to send log message: %v", err)}
+		}
+	}
+
+	release, err := l.transport.acquire(ctx)
+	if err != nil {
+		return &deliveryError{msg: fmt.Sprintf("Failed to send log message: %v", err)}
+	}
+	defer release()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", l.seqURL, bytes.NewReader(body.data))
+	if err != nil {
+		return &deliveryError{msg: fmt.Sprintf("Failed to create HTTP request: %v", err)}
+	}
+	l.setHeaders(req)
+	if err := l.authorize(ctx, req); err != nil {
+		return &deliveryError{msg: err.Error(), retryable: true}
+	}
+	req.Header.Set("Content-Type", l.encoder.ContentType())
+	if body.encoding != "" {
+		req.Header.Set("Content-Encoding", body.encoding)
+	}
+	if l.signer != nil {
+		l.signer.sign(req, body.data)
+	}
+	if l.beforeSend != nil {
+		if err := l.beforeSend(req, body.data); err != nil {
+			return &deliveryError{msg: fmt.Sprintf("Failed to prepare HTTP request: %v", err)}
+		}
+	}
+
+	resp, err := client.Do(req)
+	if l.afterSend != nil {
+		l.afterSend(req, resp, err)
+	}
+	if err != nil {
+		l.requestFailed(client)
+		return &deliveryError{msg: fmt.Sprintf("Failed to send log message: %v", err), retryable: true}
+	}
+	l.connectionFailures.Store(0)
+	defer resp.Body.Close()
+
+	// SEQ answers a successful ingestion with 201 Created
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		responseBody := getEncodeBuffer()
+		responseBody.ReadFrom(resp.Body)
+		defer putEncodeBuffer(responseBody)
+
+		if resp.StatusCode == http.StatusTooManyRequests && l.pacer != nil {
+			l.pacer.throttled()
+		}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+			if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				l.pause(delay)
+			}
+		}
+		if resp.StatusCode == http.StatusUnauthorized && l.tokens != nil {
+			l.tokens.invalidate()
+			if refresh {
+				return &deliveryError{msg: fmt.Sprintf("SEQ server responded with %v", resp.Status), tokenRejected: true}
+			}
+		}
+		if (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) && l.onAuthError != nil {
+			// Retrying won't help until the key is fixed, so surface it right away
+			l.onAuthError(&AuthError{StatusCode: resp.StatusCode, Response: responseBody.String()})
+		}
+		err := &deliveryError{
+			msg:       fmt.Sprintf("SEQ server responded with %v. Response: %v", resp.Status, responseBody.String()),
+			retryable: resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500,
+		}
+		if ingestionErr := parseIngestionError(resp.StatusCode, responseBody.Bytes()); ingestionErr != nil {
+			err.msg, err.cause = ingestionErr.Error(), ingestionErr
+		}
+		return err
+	}
+
+	if l.pacer != nil {
+		l.pacer.accepted()
+	}
+	if l.levelFeedback {
+		l.applyLevelFeedback(resp.Body)
+	}
+
+	// Drain the body so the connection can be reused for the next batch
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newSenderLogger creates a queue logger posting raw events to seqURL, sending once unless opts set WithRetry
+func newSenderLogger(seqURL string, opts ...Option) *SEQLogger {
+	logger := newQueueLogger(1, append([]Option{WithEncoder(RawEncoder{})}, opts...)...)
+	logger.seqURL = seqURL
+	if logger.retry.maxAttempts == 0 {
+		logger.retry = defaultRetryPolicy
+	}
+	return logger
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"0", 0, false},
+		{"soon", 0, false},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second, true},
+		{"86400", maxRetryAfter, true},
+	}
+
+	for _, test := range tests {
+		got, ok := parseRetryAfter(test.header, now)
+		if got != test.want || ok != test.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", test.header, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestRetryAfterPausesSender(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	logger := newSenderLogger(server.URL, WithRetry(2, time.Millisecond, time.Millisecond))
+	start := time.Now()
+	if err := logger.deliver(server.Client(), benchmarkBatch(1)); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("Expected the retry to wait for Retry-After, it came after %v", elapsed)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("Expected 2 requests, got %d", n)
+	}
+}
+
+func TestAuthErrorCallback(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	var authErr *AuthError
+	fallback := &memorySink{}
+	logger := newSenderLogger(server.URL,
+		WithRetry(5, time.Millisecond, time.Millisecond),
+		WithOnAuthError(func(err *AuthError) { authErr = err }),
+		WithFallback(fallback),
+	)
+
+	if err := logger.deliver(server.Client(), benchmarkBatch(2)); err == nil {
+		t.Fatal("Expected delivery to fail")
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("Expected no retries after a 401, got %d requests", n)
+	}
+	if authErr == nil || authErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected the callback to receive a 401 AuthError, got %v", authErr)
+	}
+	if len(fallback.templates) != 2 {
+		t.Errorf("Expected the batch to be dead-lettered to the fallback, got %v", fallback.templates)
+	}
+}
+
+func TestParseIngestionError(t *testing.T) {
+	err := parseIngestionError(http.StatusBadRequest, []byte(`{"Error": "Invalid JSON on line 3: the event has no timestamp"}`))
+	if err == nil || err.Line != 3 || err.Message != "Invalid JSON on line 3: the event has no timestamp" {
+		t.Errorf("Unexpected ingestion error %+v", err)
+	}
+	if err := parseIngestionError(http.StatusRequestEntityTooLarge, []byte(`{"Error": "The payload is too large"}`)); err == nil || err.Line != 0 {
+		t.Errorf("Expected a batch-level ingestion error, got %+v", err)
+	}
+	if err := parseIngestionError(http.StatusBadGateway, []byte("<html>Bad gateway</html>")); err != nil {
+		t.Errorf("Expected no ingestion error for a non-JSON body, got %+v", err)
+	}
+}
+
+func TestMalformedEventIsDroppedAndRestResent(t *testing.T) {
+	var accepted atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+		for i, line := range lines {
+			if strings.Contains(line, "Malformed") {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, `{"Error": "Invalid event on line %d"}`, i+1)
+				return
+			}
+		}
+		accepted.Add(int32(len(lines)))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	fallback := &memorySink{}
+	logger := newSenderLogger(server.URL, WithEncoder(CLEFEncoder{}), WithFallback(fallback))
+	batch := benchmarkBatch(3)
+	batch[1].MessageTemplate = "Malformed"
+
+	if err := logger.deliver(server.Client(), batch); err != nil {
+		t.Fatalf("Expected the rest of the batch to be delivered, got %v", err)
+	}
+	if n := accepted.Load(); n != 2 {
+		t.Errorf("Expected 2 events to be resent, got %d", n)
+	}
+	if len(fallback.templates) != 1 || fallback.templates[0] != "Malformed" {
+		t.Errorf("Expected only the malformed event to be dead-lettered, got %v", fallback.templates)
+	}
+	var ingestionErr *IngestionError
+	if err := errors.Join(logger.flushErrs...); !errors.As(err, &ingestionErr) || ingestionErr.Line != 2 {
+		t.Errorf("Expected the rejection to be reported as an IngestionError, got %v", err)
+	}
+}
+
+func TestUnencodableEventIsDroppedAndRestSent(t *testing.T) {
+	server := newSeqRecorder(t)
+	fallback := &memorySink{}
+	logger := newSenderLogger(server.URL, WithFallback(fallback))
+	batch := benchmarkBatch(4)
+	batch[2].MessageTemplate = "Ratio {Ratio}"
+	batch[2].Fields = map[string]interface{}{"Ratio": math.NaN()}
+
+	if err := logger.deliver(server.Client(), batch); err != nil {
+		t.Fatalf("Expected the rest of the batch to be delivered, got %v", err)
+	}
+	if received := server.received(); strings.Count(received, "MessageTemplate") != 3 || strings.Contains(received, "Ratio") {
+		t.Errorf("Expected the 3 encodable events delivered, got %s", received)
+	}
+	if len(fallback.templates) != 1 || fallback.templates[0] != "Ratio {Ratio}" || logger.dropped.Load() != 1 {
+		t.Errorf("Expected only the unencodable event dropped, got %v", fallback.templates)
+	}
+	if err := errors.Join(logger.flushErrs...); err == nil || !strings.Contains(err.Error(), "NaN") {
+		t.Errorf("Expected the encoding error reported to Flush, got %v", err)
+	}
+}
+
+func TestSendHooks(t *testing.T) {
+	var signature atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature.Store(r.Header.Get("X-Signature"))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	var bodyLength, status int
+	logger := newSenderLogger(server.URL,
+		WithBeforeSend(func(req *http.Request, body []byte) error {
+			bodyLength = len(body)
+			req.Header.Set("X-Signature", "signed")
+			return nil
+		}),
+		WithAfterSend(func(req *http.Request, resp *http.Response, err error) {
+			if err == nil {
+				status = resp.StatusCode
+			}
+		}),
+	)
+
+	if err := logger.deliver(server.Client(), benchmarkBatch(2)); err != nil {
+		t.Fatalf("Failed to deliver: %v", err)
+	}
+	if signature.Load() != "signed" || bodyLength == 0 || status != http.StatusCreated {
+		t.Errorf("Expected both hooks to run, got signature %v, body %d bytes, status %d", signature.Load(), bodyLength, status)
+	}
+}
+
+func TestBeforeSendErrorFailsBatch(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	logger := newSenderLogger(server.URL,
+		WithRetry(3, time.Millisecond, time.Millisecond),
+		WithFallback(&memorySink{}),
+		WithBeforeSend(func(req *http.Request, body []byte) error { return errors.New("no signing key") }),
+	)
+	if err := logger.deliver(server.Client(), benchmarkBatch(1)); err == nil || !strings.Contains(err.Error(), "no signing key") {
+		t.Errorf("Expected the hook's error, got %v", err)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("Expected no request, got %d", n)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// SessionIDProperty carries the id of the session started with StartSession
+const SessionIDProperty = "SessionId"
+
+// session is the usage session of a CLI or desktop application in progress
+type session struct {
+	id      string
+	started time.Time
+}
+
+// StartSession logs "Session {SessionId} started" with fields and stamps the new
+// SessionId on every event logged, through any logger of the pipeline, until
+// EndSession, so SEQ can group and measure an application's runs. A session already
+// in progress is ended first. It returns the SessionId.
+func (l *SEQLogger) StartSession(fields map[string]interface{}) string {
+	root := l.pipeline()
+	root.sessionMu.Lock()
+	defer root.sessionMu.Unlock()
+	root.endSession()
+
+	s := &session{id: newSessionID(), started: time.Now()}
+	root.session = s
+	root.SetGlobalField(SessionIDProperty, s.id)
+	l.Log(LevelInformation, "Session {"+SessionIDProperty+"} started", fields)
+	return s.id
+}
+
+// EndSession logs "Session {SessionId} ended after {Elapsed} ms" and stops stamping
+// the SessionId. It does nothing without a session in progress; Close ends one.
+func (l *SEQLogger) EndSession() {
+	root := l.pipeline()
+	root.sessionMu.Lock()
+	defer root.sessionMu.Unlock()
+	root.endSession()
+}
+
+// endSession ends the session in progress, if any; sessionMu is held
+func (l *SEQLogger) endSession() {
+	s := l.session
+	if s == nil {
+		return
+	}
+	l.session = nil
+	elapsed := float64(time.Since(s.started)) / float64(time.Millisecond)
+	l.Log(LevelInformation, "Session {"+SessionIDProperty+"} ended after {"+ElapsedProperty+":0.0} ms",
+		map[string]interface{}{ElapsedProperty: elapsed})
+	l.RemoveGlobalField(SessionIDProperty)
+}
+
+// newSessionID returns a random 128-bit session id in hex
+func newSessionID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
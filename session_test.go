@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestSessionStampsEventsUntilEnded(t *testing.T) {
+	logger := newQueueLogger(10)
+	id := logger.StartSession(map[string]interface{}{"Command": "sync"})
+
+	started := <-logger.logChan
+	if started.MessageTemplate != "Session {SessionId} started" || started.Fields[SessionIDProperty] != id || started.Fields["Command"] != "sync" {
+		t.Errorf("Unexpected start event %+v", started)
+	}
+
+	logger.Named("upload").Log(LevelInformation, "Uploaded {Count} files", map[string]interface{}{"Count": 3})
+	if inner := <-logger.logChan; inner.Fields[SessionIDProperty] != id {
+		t.Errorf("Expected events in the session to carry its id, got %v", inner.Fields)
+	}
+
+	logger.EndSession()
+	ended := <-logger.logChan
+	if ended.Fields[SessionIDProperty] != id || ended.Fields[ElapsedProperty] == nil {
+		t.Errorf("Unexpected end event %+v", ended)
+	}
+
+	logger.EndSession()
+	logger.Log(LevelInformation, "Idle", nil)
+	after := <-logger.logChan
+	if _, ok := after.Fields[SessionIDProperty]; ok || after.MessageTemplate != "Idle" {
+		t.Errorf("Expected the session to end once and stop stamping events, got %+v", after)
+	}
+}
+
+func TestStartSessionEndsPreviousSession(t *testing.T) {
+	logger := newQueueLogger(10)
+	first := logger.StartSession(nil)
+	second := logger.StartSession(nil)
+	<-logger.logChan
+
+	if ended := <-logger.logChan; ended.Fields[SessionIDProperty] != first || ended.Fields[ElapsedProperty] == nil {
+		t.Errorf("Expected the first session to end, got %+v", ended)
+	}
+	if started := <-logger.logChan; started.Fields[SessionIDProperty] != second || first == second {
+		t.Errorf("Expected a new session, got %+v", started)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ErrClosed is returned by operations on a logger that has been closed
+var ErrClosed = errors.New("logger is closed")
+
+// Close stops the logger after sending every queued event and waits for the
+// processing goroutine to finish, then closes the files opened by NewFromConfigFile.
+// It is safe to call more than once and concurrently with Log: events logged after
+// Close has started are discarded.
+func (l *SEQLogger) Close() {
+	l = l.pipeline()
+	l.EndSession()
+	if l.errorAggregator != nil {
+		l.flushErrorSummaries()
+	}
+	l.closeMu.Lock()
+	closing := !l.closed.Load()
+	l.closed.Store(true)
+	l.closeMu.Unlock()
+
+	if closing {
+		// Log can no longer send, so only the fair queue pump and the spill drainer
+		// may still be using logChan
+		if l.fair != nil {
+			l.closeFair()
+		}
+		if l.spill != nil {
+			l.closeSpill()
+		}
+		close(l.logChan)
+		if l.pump != nil {
+			l.closePump()
+		}
+	}
+	<-l.done
+	if closing {
+		// The last batch has gone out, so no sink writes to them anymore
+		closeFiles(l.files)
+	}
+}
+
+// enqueue writes logMessage to the WAL and queues it for processing, unless the logger is closed
+func (l *SEQLogger) enqueue(logMessage *LogMessage) error {
+	fair, err := l.enqueueLocked(logMessage)
+	if err != nil || !fair {
+		return err
+	}
+	// The fair queue pump needs closeMu to make room in a full sub-queue, so Log waits
+	// for room without holding it, and may find the logger closed meanwhile
+	if !l.fair.push(logMessage) {
+		if l.memoryBudget != nil {
+			l.memoryBudget.queued.Add(-logMessage.size)
+		}
+		return ErrClosed
+	}
+	return nil
+}
+
+// enqueueLocked is enqueue holding closeMu for reading. It reports whether logMessage
+// is left for the fair queue.
+func (l *SEQLogger) enqueueLocked(logMessage *LogMessage) (bool, error) {
+	l.closeMu.RLock()
+	defer l.closeMu.RUnlock()
+	if l.closed.Load() {
+		return false, ErrClosed
+	}
+
+	overBudget := l.memoryBudget != nil && !l.memoryBudget.reserve(logMessage)
+	if overBudget && l.spill == nil {
+		l.shed()
+		return false, nil
+	}
+
+	if l.wal != nil {
+		if err := l.wal.append(logMessage); err != nil {
+			selfLogf("Failed to write log message to WAL: %v", err)
+		}
+	}
+
+	if len(l.watermarks) > 0 {
+		l.checkWatermarks()
+	}
+
+	if !overBudget && l.expressEvent(logMessage) {
+		return false, nil
+	}
+	if overBudget {
+		// A WAL replays the event on the next start even if the spill file is full too
+		if !l.spill.push(logMessage) {
+			l.shed()
+		}
+		return false, nil
+	}
+	if l.fair != nil {
+		return true, nil
+	}
+	if l.pump != nil {
+		l.pumpEnqueue(logMessage)
+		return false, nil
+	}
+	if l.spill != nil {
+		select {
+		case l.logChan <- *logMessage:
+			return false, nil
+		default:
+		}
+		if l.spill.push(logMessage) {
+			if l.memoryBudget != nil {
+				l.memoryBudget.queued.Add(-logMessage.size)
+			}
+			return false, nil
+		}
+	}
+
+	l.logChan <- *logMessage
+	return false, nil
+}
+
+// HandleSignals closes the logger, flushing the queued events, when the process receives
+// SIGINT or SIGTERM, then raises the signal again so the process terminates as it
+// would have without the handler. It returns when ctx is done or the signal is handled.
+// Applications that handle these signals themselves should call Close from their own handler.
+func (l *SEQLogger) HandleSignals(ctx context.Context) {
+	l = l.pipeline()
+	terminate := make(chan os.Signal, 1)
+	signal.Notify(terminate, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case <-ctx.Done():
+		signal.Stop(terminate)
+	case sig := <-terminate:
+		l.Close()
+		signal.Stop(terminate)
+		if process, err := os.FindProcess(os.Getpid()); err != nil || process.Signal(sig) != nil {
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCloseFlushesQueuedEvents(t *testing.T) {
+	seq := newSeqRecorder(t)
+	logger := newTestLogger(t, seq.URL+EndpointRaw, 100, WithBatching(10, time.Hour))
+
+	for i := 0; i < 25; i++ {
+		logger.Log(LevelInformation, "Order {OrderId} placed", map[string]interface{}{"OrderId": i})
+	}
+	logger.Close()
+	logger.Close() // Close is idempotent
+
+	if n := strings.Count(seq.received(), "Order {OrderId} placed"); n != 25 {
+		t.Errorf("Expected 25 events delivered by Close, got %d", n)
+	}
+}
+
+func TestHandleSignalsReturnsWhenContextIsDone(t *testing.T) {
+	logger := newTestLogger(t, "http://localhost:0"+EndpointRaw, 1)
+	defer logger.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	returned := make(chan struct{})
+	go func() {
+		logger.HandleSignals(ctx)
+		close(returned)
+	}()
+
+	cancel()
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("HandleSignals did not return after the context was cancelled")
+	}
+}
+
+func TestLogAfterCloseIsDiscarded(t *testing.T) {
+	seq := newSeqRecorder(t)
+	logger := newTestLogger(t, seq.URL+EndpointRaw, 10)
+	logger.Close()
+
+	logger.Log(LevelInformation, "Too late", nil)
+	if err := logger.Flush(context.Background()); err != ErrClosed {
+		t.Errorf("Expected ErrClosed from Flush, got %v", err)
+	}
+	if err := logger.Audit().Log(context.Background(), LevelInformation, "Too late", nil); err != ErrClosed {
+		t.Errorf("Expected ErrClosed from Audit().Log, got %v", err)
+	}
+	if strings.Contains(seq.received(), "Too late") {
+		t.Error("Expected events logged after Close to be discarded")
+	}
+}
+
+func TestCloseConcurrentWithLog(t *testing.T) {
+	logger := newTestLogger(t, newSeqRecorder(t).URL+EndpointRaw, 4)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				logger.Log(LevelInformation, "Order placed", nil)
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	logger.Close()
+	wg.Wait()
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// DefaultSignatureHeader carries the signature of WithHMACSigning when it is given no header name
+const DefaultSignatureHeader = "X-Seq-Signature"
+
+// requestSigner signs ingestion request bodies with HMAC-SHA256, see WithHMACSigning
+type requestSigner struct {
+	secret []byte
+	header string
+}
+
+// WithHMACSigning signs the body of every ingestion request, as sent, compressed or
+// not, with HMAC-SHA256 and secret, for a verifying proxy in front of SEQ. The
+// signature is sent in hex, as "sha256=<hex>", in header, or DefaultSignatureHeader
+// when header is empty.
+func WithHMACSigning(secret []byte, header string) Option {
+	if header == "" {
+		header = DefaultSignatureHeader
+	}
+	return func(l *SEQLogger) {
+		l.signer = &requestSigner{secret: secret, header: header}
+	}
+}
+
+// sign sets the signature of body on req
+func (s *requestSigner) sign(req *http.Request, body []byte) {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(body)
+	req.Header.Set(s.header, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHMACSigning(t *testing.T) {
+	secret := []byte("shared secret")
+	verified := make(chan bool, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(body)
+		verified <- r.Header.Get(DefaultSignatureHeader) == "sha256="+hex.EncodeToString(mac.Sum(nil))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	logger := newSenderLogger(server.URL, WithHMACSigning(secret, ""), WithCompression("gzip", 1))
+	if err := logger.deliver(server.Client(), benchmarkBatch(3)); err != nil {
+		t.Fatalf("Failed to deliver: %v", err)
+	}
+	if !<-verified {
+		t.Error("Expected a signature of the body as sent")
+	}
+}
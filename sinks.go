@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Sink receives batches of events alongside the SEQ server, e.g. a file or a webhook.
+// Emit is called from the logger's processing goroutine and must not retain batch.
+type Sink interface {
+	Emit(batch []LogMessage) error
+}
+
+// Reopener is implemented by sinks writing to a file, which reopen its path so the
+// file logrotate moved away is let go of
+type Reopener interface {
+	Reopen() error
+}
+
+// routedSink is a Sink together with the minimum level rank of the events routed to it
+type routedSink struct {
+	sink     Sink
+	minLevel int
+}
+
+// filterByLevel appends the events of batch at or above minLevel to dst
+func filterByLevel(batch []LogMessage, minLevel int, dst []LogMessage) []LogMessage {
+	for _, logMessage := range batch {
+		if levelRank(logMessage.Level) >= minLevel {
+			dst = append(dst, logMessage)
+		}
+	}
+	return dst
+}
+
+// WriterSink writes encoded batches to an io.Writer such as os.Stderr
+type WriterSink struct {
+	mu      sync.Mutex
+	w       io.Writer
+	encoder Encoder
+}
+
+// NewWriterSink creates a sink writing to w; a nil encoder writes CLEF lines
+func NewWriterSink(w io.Writer, encoder Encoder) *WriterSink {
+	if encoder == nil {
+		encoder = CLEFEncoder{}
+	}
+	return &WriterSink{w: w, encoder: encoder}
+}
+
+// Emit encodes batch and writes it in a single call
+func (s *WriterSink) Emit(batch []LogMessage) error {
+	buf := getEncodeBuffer()
+	defer putEncodeBuffer(buf)
+
+	if err := s.encoder.Encode(buf, batch); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.w.Write(buf.Bytes())
+	return err
+}
+
+// FileSink appends encoded batches to a file
+type FileSink struct {
+	*WriterSink
+	file *os.File
+}
+
+// NewFileSink opens path for appending, creating it if needed; a nil encoder writes CLEF lines
+func NewFileSink(path string, encoder Encoder) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sink file: %w", err)
+	}
+	return &FileSink{WriterSink: NewWriterSink(file, encoder), file: file}, nil
+}
+
+// Reopen opens the path again and closes the previous file
+func (s *FileSink) Reopen() error {
+	file, err := os.OpenFile(s.file.Name(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open sink file: %w", err)
+	}
+	s.mu.Lock()
+	previous := s.file
+	s.w, s.file = file, file
+	s.mu.Unlock()
+	return previous.Close()
+}
+
+// Close syncs and closes the underlying file
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return errors.Join(s.file.Sync(), s.file.Close())
+}
+
+// WebhookSink posts encoded batches to an HTTP endpoint, e.g. an alerting webhook
+type WebhookSink struct {
+	url     string
+	client  *http.Client
+	encoder Encoder
+	headers http.Header
+}
+
+// webhookTimeout bounds a webhook request, as Emit holds up delivery to SEQ while it waits
+const webhookTimeout = 10 * time.Second
+
+// NewWebhookSink creates a sink posting to url; a nil encoder sends SEQ's raw events format.
+// A request taking longer than 10 seconds fails.
+func NewWebhookSink(url string, encoder Encoder, headers http.Header) *WebhookSink {
+	if encoder == nil {
+		encoder = RawEncoder{}
+	}
+	return &WebhookSink{url: url, client: &http.Client{Timeout: webhookTimeout}, encoder: encoder, headers: headers}
+}
+
+// Emit posts batch and reports any non-2xx response as an error
+func (s *WebhookSink) Emit(batch []LogMessage) error {
+	buf := getEncodeBuffer()
+	defer putEncodeBuffer(buf)
+
+	if err := s.encoder.Encode(buf, batch); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", s.url, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return err
+	}
+	for key, values := range s.headers {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", s.encoder.ContentType())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %v", resp.Status)
+	}
+	return nil
+}
+
+// RotatingFileSink appends encoded batches to a file, renaming it to path.1, path.2 and
+// so on when it would grow past maxSize and keeping at most maxFiles old files.
+// It suits a fallback for what could not be delivered during an outage.
+type RotatingFileSink struct {
+	mu       sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	encoder  Encoder
+	file     *os.File
+	size     int64
+}
+
+// NewRotatingFileSink opens path for appending, creating it if needed; a nil encoder
+// writes CLEF lines. maxSize <= 0 disables rotation.
+func NewRotatingFileSink(path string, maxSize int64, maxFiles int, encoder Encoder) (*RotatingFileSink, error) {
+	if encoder == nil {
+		encoder = CLEFEncoder{}
+	}
+	s := &RotatingFileSink{path: path, maxSize: maxSize, maxFiles: maxFiles, encoder: encoder}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// open opens the current file and records its size
+func (s *RotatingFileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open sink file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open sink file: %w", err)
+	}
+	s.file, s.size = file, info.Size()
+	return nil
+}
+
+// Emit writes batch, rotating first if it would take the file past maxSize
+func (s *RotatingFileSink) Emit(batch []LogMessage) error {
+	buf := getEncodeBuffer()
+	defer putEncodeBuffer(buf)
+
+	if err := s.encoder.Encode(buf, batch); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(buf.Len()) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(buf.Bytes())
+	s.size += int64(n)
+	return err
+}
+
+// rotate shifts the old files up by one, dropping the oldest, and starts a new file
+func (s *RotatingFileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	if s.maxFiles > 0 {
+		os.Remove(fmt.Sprintf("%s.%d", s.path, s.maxFiles))
+		for i := s.maxFiles - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", s.path, i), fmt.Sprintf("%s.%d", s.path, i+1))
+		}
+		if err := os.Rename(s.path, s.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(s.path); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+// Reopen closes the current file and opens the path again
+func (s *RotatingFileSink) Reopen() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+// Close syncs and closes the current file
+func (s *RotatingFileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return errors.Join(s.file.Sync(), s.file.Close())
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memorySink records the templates of the events it receives
+type memorySink struct {
+	mu        sync.Mutex
+	templates []string
+}
+
+func (s *memorySink) Emit(batch []LogMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, logMessage := range batch {
+		s.templates = append(s.templates, logMessage.MessageTemplate)
+	}
+	return nil
+}
+
+// seqRecorder is a test SEQ server that records the bodies it ingests
+type seqRecorder struct {
+	*httptest.Server
+	mu     sync.Mutex
+	bodies []string
+}
+
+func newSeqRecorder(t *testing.T) *seqRecorder {
+	recorder := &seqRecorder{}
+	recorder.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		recorder.mu.Lock()
+		recorder.bodies = append(recorder.bodies, string(body))
+		recorder.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(recorder.Close)
+	return recorder
+}
+
+// received returns everything ingested so far as a single string
+func (r *seqRecorder) received() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.bodies, "\n")
+}
+
+func TestDispatchRoutesByLevel(t *testing.T) {
+	seq := newSeqRecorder(t)
+	alerts := &memorySink{}
+	everything := &memorySink{}
+	logger := newQueueLogger(0,
+		WithSink(alerts, LevelError),
+		WithSink(everything, ""),
+		WithSeqMinLevel(LevelDebug),
+	)
+	logger.seqURL = seq.URL
+	logger.encoder = RawEncoder{}
+	logger.retry = defaultRetryPolicy
+
+	batch := []LogMessage{
+		{Timestamp: "2024-01-02T03:04:05Z", Level: LevelVerbose, MessageTemplate: "Verbose detail"},
+		{Timestamp: "2024-01-02T03:04:05Z", Level: LevelDebug, MessageTemplate: "Debug detail"},
+		{Timestamp: "2024-01-02T03:04:05Z", Level: LevelError, MessageTemplate: "Payment failed"},
+		{Timestamp: "2024-01-02T03:04:05Z", Level: LevelFatal, MessageTemplate: "Process crashed"},
+	}
+	logger.dispatch(seq.Client(), batch, nil)
+
+	if got := strings.Join(alerts.templates, ","); got != "Payment failed,Process crashed" {
+		t.Errorf("Error sink received %q", got)
+	}
+	if len(everything.templates) != 4 {
+		t.Errorf("Expected the unfiltered sink to receive every event, got %v", everything.templates)
+	}
+	received := seq.received()
+	if strings.Contains(received, "Verbose detail") || !strings.Contains(received, "Debug detail") || !strings.Contains(received, "Process crashed") {
+		t.Errorf("Unexpected events sent to SEQ: %s", received)
+	}
+}
+
+func TestFileSinkFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	errorsPath := filepath.Join(dir, "errors.clef")
+	path := writeConfigFile(t, "seqlogger.yaml", `
+serverUrl: http://localhost:5341/api/events/raw
+sinks:
+  - type: seq
+    minLevel: Debug
+  - type: file
+    path: `+errorsPath+`
+    minLevel: Error
+`)
+
+	config, err := LoadConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := config.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := newQueueLogger(0, opts...)
+	if logger.seqMinLevel != levelRank(LevelDebug) || len(logger.sinks) != 1 {
+		t.Fatalf("Unexpected routing: seq min level %d, %d sinks", logger.seqMinLevel, len(logger.sinks))
+	}
+
+	logger.sinks[0].sink.Emit([]LogMessage{{Timestamp: "2024-01-02T03:04:05Z", Level: LevelError, MessageTemplate: "Payment failed"}})
+	logger.sinks[0].sink.(*FileSink).Close()
+
+	data, err := os.ReadFile(errorsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"@mt":"Payment failed"`) {
+		t.Errorf("Expected a CLEF line in the file sink, got %s", data)
+	}
+}
+
+func TestWebhookSinkTimesOut(t *testing.T) {
+	sink := NewWebhookSink("http://localhost:9/hook", nil, nil)
+	if sink.client.Timeout != webhookTimeout {
+		t.Errorf("Expected a %v timeout, got %v", webhookTimeout, sink.client.Timeout)
+	}
+}
+
+func TestRotatingFileSinkRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fallback.clef")
+	sink, err := NewRotatingFileSink(path, 150, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	for i := 0; i < 6; i++ {
+		batch := []LogMessage{{Timestamp: "2024-01-02T03:04:05Z", Level: LevelError, MessageTemplate: "Payment failed"}}
+		if err := sink.Emit(batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("Expected %s to exist: %v", name, err)
+		}
+		if info.Size() > 150 {
+			t.Errorf("%s grew past the maximum size: %d bytes", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected at most 2 old files, found %s.3", path)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// spillReadSize is how much of the spill file is read at a time when draining it
+const spillReadSize = 64 << 10
+
+// spill is a bounded on-disk overflow for the queue: when logChan is full, Log appends
+// the event to the spill file instead of blocking, and a drainer goroutine moves
+// spilled events back into logChan as it empties. Spilled events may reach Seq after
+// events logged later that found room in the queue. Unlike the WAL the spill file
+// does not survive a restart.
+type spill struct {
+	mu          sync.Mutex
+	file        *os.File
+	maxBytes    int64
+	readOffset  int64
+	writeOffset int64
+	line        bytes.Buffer
+
+	pushed  uint64 // events spilled so far
+	drained uint64 // spilled events moved back into the queue
+
+	ready chan struct{} // signalled when an event is spilled
+	stop  chan struct{} // closed to stop the drainer
+	done  chan struct{} // closed when the drainer has stopped
+}
+
+// openSpill creates an empty spill file at path holding at most maxBytes
+func openSpill(path string, maxBytes int64) (*spill, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &spill{
+		file:     file,
+		maxBytes: maxBytes,
+		ready:    make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// push appends logMessage to the spill file and reports whether it fit
+func (s *spill) push(logMessage *LogMessage) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.line.Reset()
+	if err := json.NewEncoder(&s.line).Encode(walEntry{Seq: logMessage.walSeq, Event: *logMessage}); err != nil {
+		selfLogf("Failed to spill log message: %v", err)
+		return false
+	}
+	if s.writeOffset-s.readOffset+int64(s.line.Len()) > s.maxBytes {
+		return false
+	}
+	if _, err := s.file.WriteAt(s.line.Bytes(), s.writeOffset); err != nil {
+		selfLogf("Failed to spill log message: %v", err)
+		return false
+	}
+	s.writeOffset += int64(s.line.Len())
+	s.pushed++
+
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// pop reads the spilled events not drained yet, up to spillReadSize bytes' worth,
+// and truncates the file once everything has been read
+func (s *spill) pop() []LogMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.readOffset == s.writeOffset {
+		return nil
+	}
+
+	size := s.writeOffset - s.readOffset
+	if size > spillReadSize {
+		size = spillReadSize
+	}
+	chunk := make([]byte, size)
+	n, err := s.file.ReadAt(chunk, s.readOffset)
+	if err != nil && err != io.EOF {
+		selfLogf("Failed to read spilled log messages: %v", err)
+		return nil
+	}
+	chunk = chunk[:n]
+	// Only complete lines are decoded; an event larger than the chunk is read whole
+	if end := bytes.LastIndexByte(chunk, '\n'); end >= 0 {
+		chunk = chunk[:end+1]
+	} else {
+		chunk = make([]byte, s.writeOffset-s.readOffset)
+		s.file.ReadAt(chunk, s.readOffset)
+	}
+	s.readOffset += int64(len(chunk))
+
+	var events []LogMessage
+	decoder := json.NewDecoder(bytes.NewReader(chunk))
+	decoder.UseNumber()
+	for {
+		var entry walEntry
+		if err := decoder.Decode(&entry); err != nil {
+			break
+		}
+		entry.Event.walSeq = entry.Seq
+		events = append(events, entry.Event)
+	}
+
+	if s.readOffset == s.writeOffset {
+		s.file.Truncate(0)
+		s.readOffset, s.writeOffset = 0, 0
+	}
+	return events
+}
+
+// markDrained records that n popped events are back in the queue
+func (s *spill) markDrained(n int) {
+	s.mu.Lock()
+	s.drained += uint64(n)
+	s.mu.Unlock()
+}
+
+// waitDrained waits until every event spilled before the call is back in the queue
+func (s *spill) waitDrained(ctx context.Context) error {
+	s.mu.Lock()
+	target := s.pushed
+	s.mu.Unlock()
+
+	ticker := time.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		s.mu.Lock()
+		drained := s.drained
+		s.mu.Unlock()
+		if drained >= target {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// drainSpill moves spilled events back into logChan until the spill is stopped
+func (l *SEQLogger) drainSpill() {
+	defer close(l.spill.done)
+	for {
+		select {
+		case <-l.spill.ready:
+		case <-l.spill.stop:
+			return
+		}
+		for events := l.spill.pop(); len(events) > 0; events = l.spill.pop() {
+			l.requeue(events)
+			l.spill.markDrained(len(events))
+		}
+	}
+}
+
+// closeSpill stops the drainer and moves whatever is still spilled into logChan;
+// it is called by Close once no more events can be logged
+func (l *SEQLogger) closeSpill() {
+	close(l.spill.stop)
+	<-l.spill.done
+	for events := l.spill.pop(); len(events) > 0; events = l.spill.pop() {
+		l.requeue(events)
+		l.spill.markDrained(len(events))
+	}
+	l.spill.file.Close()
+	os.Remove(l.spill.file.Name())
+}
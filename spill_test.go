@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSpillWhenQueueIsFull(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var received strings.Builder
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received.Write(body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	logger := newTestLogger(t, server.URL+EndpointRaw, 2, WithSpill(filepath.Join(t.TempDir(), "spill"), 1<<20))
+
+	logged := make(chan struct{})
+	go func() {
+		for i := 0; i < 50; i++ {
+			logger.Log(LevelInformation, "Order {OrderId} placed", map[string]interface{}{"OrderId": i})
+		}
+		close(logged)
+	}()
+	select {
+	case <-logged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Log blocked although the spill file had room")
+	}
+
+	close(release)
+	if err := logger.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	logger.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if n := strings.Count(received.String(), "Order {OrderId} placed"); n != 50 {
+		t.Errorf("Expected all 50 events delivered, got %d", n)
+	}
+}
+
+func TestSpillIsBounded(t *testing.T) {
+	spill, err := openSpill(filepath.Join(t.TempDir(), "spill"), 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spill.file.Close()
+
+	logMessage := LogMessage{Timestamp: "2024-01-02T03:04:05Z", Level: LevelInformation, MessageTemplate: "Order placed"}
+	pushed := 0
+	for spill.push(&logMessage) {
+		pushed++
+	}
+	if pushed == 0 || pushed > 3 {
+		t.Fatalf("Expected the spill to hold a couple of events, held %d", pushed)
+	}
+	if events := spill.pop(); len(events) != pushed || events[0].MessageTemplate != "Order placed" {
+		t.Errorf("Expected %d spilled events back, got %+v", pushed, events)
+	}
+	if !spill.push(&logMessage) {
+		t.Error("Expected room in the spill once drained")
+	}
+}
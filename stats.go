@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// latencyBounds are the upper bounds of the delivery latency histogram buckets
+var latencyBounds = [...]time.Duration{
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+}
+
+// Stats is a snapshot of the logger's delivery to the SEQ server
+type Stats struct {
+	QueueDepth int    // events waiting to be sent, as QueueDepth returns
+	Delivered  uint64 // events the server accepted
+	Dropped    uint64 // events lost to validation or delivery failures
+	// LastSuccessfulDelivery is when the server last accepted a batch, zero before the first
+	LastSuccessfulDelivery time.Time
+	// Latency is the time from logging each delivered event to the server accepting it
+	Latency LatencyHistogram
+}
+
+// LatencyHistogram counts delivered events by latency. Events replayed from the WAL
+// or read back from the spill file are delivered without being counted, as the time
+// they were logged doesn't survive the disk.
+type LatencyHistogram struct {
+	Buckets []LatencyBucket
+	Count   uint64        // events counted, those of the implicit +Inf bucket included
+	Sum     time.Duration // of their latencies
+}
+
+// LatencyBucket counts the events delivered within UpperBound of being logged; as in
+// Prometheus, buckets are cumulative
+type LatencyBucket struct {
+	UpperBound time.Duration
+	Count      uint64
+}
+
+// deliveryStats records the events the SEQ server accepted, updated by the processing goroutine
+type deliveryStats struct {
+	mu        sync.Mutex
+	buckets   [len(latencyBounds)]uint64 // events by the first bucket they fall in, not cumulative
+	count     uint64                     // events with a known latency
+	sum       time.Duration
+	delivered uint64
+	last      time.Time
+}
+
+// recordDelivery records that the server accepted batch at now
+func (s *deliveryStats) recordDelivery(batch []LogMessage, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delivered += uint64(len(batch))
+	s.last = now
+	for i := range batch {
+		if batch[i].logged == 0 {
+			continue
+		}
+		latency := now.Sub(time.Unix(0, batch[i].logged))
+		for b, bound := range latencyBounds {
+			if latency <= bound {
+				s.buckets[b]++
+				break
+			}
+		}
+		s.count++
+		s.sum += latency
+	}
+}
+
+// Stats returns a snapshot of the queue and of delivery to the SEQ server, so a lag
+// between logging and ingestion can be charted and alerted on
+func (l *SEQLogger) Stats() Stats {
+	root := l.pipeline()
+	depth := root.QueueDepth()
+	s := &root.deliveryStats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := Stats{
+		QueueDepth:             depth,
+		Delivered:              s.delivered,
+		Dropped:                root.dropped.Load(),
+		LastSuccessfulDelivery: s.last,
+		Latency: LatencyHistogram{
+			Buckets: make([]LatencyBucket, len(latencyBounds)),
+			Count:   s.count,
+			Sum:     s.sum,
+		},
+	}
+	var cumulative uint64
+	for i, bound := range latencyBounds {
+		cumulative += s.buckets[i]
+		stats.Latency.Buckets[i] = LatencyBucket{UpperBound: bound, Count: cumulative}
+	}
+	return stats
+}
+
+// MetricsHandler serves Stats in the Prometheus text format, to be mounted on the
+// application's metrics endpoint, e.g. http.Handle("/metrics/seqlogger", logger.MetricsHandler())
+func (l *SEQLogger) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stats := l.Stats()
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		fmt.Fprint(w, "# HELP seqlogger_queue_depth Events waiting to be sent to SEQ.\n# TYPE seqlogger_queue_depth gauge\n")
+		fmt.Fprintf(w, "seqlogger_queue_depth %d\n", stats.QueueDepth)
+		fmt.Fprint(w, "# HELP seqlogger_events_delivered_total Events accepted by SEQ.\n# TYPE seqlogger_events_delivered_total counter\n")
+		fmt.Fprintf(w, "seqlogger_events_delivered_total %d\n", stats.Delivered)
+		fmt.Fprint(w, "# HELP seqlogger_events_dropped_total Events lost to validation or delivery failures.\n# TYPE seqlogger_events_dropped_total counter\n")
+		fmt.Fprintf(w, "seqlogger_events_dropped_total %d\n", stats.Dropped)
+
+		var last float64
+		if !stats.LastSuccessfulDelivery.IsZero() {
+			last = float64(stats.LastSuccessfulDelivery.UnixNano()) / float64(time.Second)
+		}
+		fmt.Fprint(w, "# HELP seqlogger_last_successful_delivery_timestamp_seconds When SEQ last accepted a batch.\n# TYPE seqlogger_last_successful_delivery_timestamp_seconds gauge\n")
+		fmt.Fprintf(w, "seqlogger_last_successful_delivery_timestamp_seconds %s\n", strconv.FormatFloat(last, 'f', -1, 64))
+
+		fmt.Fprint(w, "# HELP seqlogger_delivery_latency_seconds Time from logging an event to SEQ accepting it.\n# TYPE seqlogger_delivery_latency_seconds histogram\n")
+		for _, bucket := range stats.Latency.Buckets {
+			fmt.Fprintf(w, "seqlogger_delivery_latency_seconds_bucket{le=%q} %d\n", strconv.FormatFloat(bucket.UpperBound.Seconds(), 'f', -1, 64), bucket.Count)
+		}
+		fmt.Fprintf(w, "seqlogger_delivery_latency_seconds_bucket{le=\"+Inf\"} %d\n", stats.Latency.Count)
+		fmt.Fprintf(w, "seqlogger_delivery_latency_seconds_sum %s\n", strconv.FormatFloat(stats.Latency.Sum.Seconds(), 'f', -1, 64))
+		fmt.Fprintf(w, "seqlogger_delivery_latency_seconds_count %d\n", stats.Latency.Count)
+	})
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsRecordsDeliveryLatency(t *testing.T) {
+	server := newSeqRecorder(t)
+	logger := newTestLogger(t, server.URL+EndpointRaw, 10)
+	defer logger.Close()
+
+	before := time.Now()
+	logger.Log(LevelInformation, "Order placed", nil)
+	if err := logger.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	stats := logger.Named("Billing").Stats()
+	if stats.Delivered != 1 || stats.Latency.Count != 1 || stats.LastSuccessfulDelivery.Before(before) {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if last := stats.Latency.Buckets[len(stats.Latency.Buckets)-1]; last.UpperBound != time.Minute || last.Count != 1 {
+		t.Errorf("Expected the event in the last bucket, got %+v", last)
+	}
+}
+
+func TestRecordDeliveryBucketsByLatency(t *testing.T) {
+	logger := newQueueLogger(0)
+	now := time.Now()
+	batch := []LogMessage{
+		{logged: now.Add(-20 * time.Millisecond).UnixNano()},
+		{logged: now.Add(-40 * time.Second).UnixNano()},
+		{logged: now.Add(-2 * time.Minute).UnixNano()},
+		{}, // replayed from the WAL
+	}
+	logger.deliveryStats.recordDelivery(batch, now)
+
+	stats := logger.Stats()
+	if stats.Delivered != 4 || stats.Latency.Count != 3 {
+		t.Errorf("Expected 4 events delivered and 3 timed, got %+v", stats)
+	}
+	counts := make(map[time.Duration]uint64)
+	for _, bucket := range stats.Latency.Buckets {
+		counts[bucket.UpperBound] = bucket.Count
+	}
+	if counts[10*time.Millisecond] != 0 || counts[50*time.Millisecond] != 1 || counts[30*time.Second] != 1 || counts[time.Minute] != 2 {
+		t.Errorf("Unexpected cumulative buckets %v", counts)
+	}
+
+	recorder := httptest.NewRecorder()
+	logger.MetricsHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	for _, want := range []string{
+		`seqlogger_delivery_latency_seconds_bucket{le="0.05"} 1`,
+		`seqlogger_delivery_latency_seconds_bucket{le="+Inf"} 3`,
+		"seqlogger_delivery_latency_seconds_count 3",
+		"seqlogger_events_delivered_total 4",
+		"# TYPE seqlogger_delivery_latency_seconds histogram",
+	} {
+		if !strings.Contains(recorder.Body.String(), want) {
+			t.Errorf("Expected %s in the metrics, got %s", want, recorder.Body.String())
+		}
+	}
+}
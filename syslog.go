@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Properties of events received by the syslog receiver
+const (
+	SyslogFacilityProperty = "Facility"
+	SyslogHostnameProperty = "Hostname"
+	SyslogAppNameProperty  = "AppName"
+	SyslogProcIDProperty   = "ProcId"
+	SyslogMsgIDProperty    = "MsgId"
+)
+
+// maxSyslogMessage bounds a single syslog message received over TCP
+const maxSyslogMessage = 64 * 1024
+
+// syslogLevels maps syslog severities to levels: emergency, alert and critical are
+// Fatal, notice and informational Information
+var syslogLevels = [8]string{LevelFatal, LevelFatal, LevelFatal, LevelError, LevelWarning, LevelInformation, LevelInformation, LevelDebug}
+
+// syslogFacilities names the syslog facilities by code
+var syslogFacilities = [24]string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// syslogMessage is a parsed RFC 3164 or RFC 5424 message
+type syslogMessage struct {
+	facility  int
+	severity  int
+	timestamp time.Time // zero when the message has none
+	hostname  string
+	appName   string
+	procID    string
+	msgID     string
+	// structuredData maps SD-IDs to their parameters
+	structuredData map[string]map[string]interface{}
+	message        string
+}
+
+// ServeSyslog accepts syslog connections on ln, framed by octet counts or newlines as
+// in RFC 6587, and logs their RFC 3164 or RFC 5424 messages until ctx is done.
+// Severities map to levels and structured data elements become properties named by
+// their SD-ID.
+func (l *SEQLogger) ServeSyslog(ctx context.Context, ln net.Listener) error {
+	stop := context.AfterFunc(ctx, func() { ln.Close() })
+	defer stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+			closeConn := context.AfterFunc(ctx, func() { conn.Close() })
+			defer closeConn()
+
+			if err := l.handleSyslogStream(conn); err != nil && ctx.Err() == nil {
+				selfLogf("Failed to read syslog message from %v: %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// ServeSyslogPacket logs the syslog messages received as datagrams on conn, one per
+// datagram, until ctx is done
+func (l *SEQLogger) ServeSyslogPacket(ctx context.Context, conn net.PacketConn) error {
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	buf := make([]byte, maxSyslogMessage)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		l.logSyslog(buf[:n])
+	}
+}
+
+// handleSyslogStream logs the messages of a syslog connection until it is closed. A
+// frame starting with a digit is octet counted, anything else ends at a newline.
+func (l *SEQLogger) handleSyslogStream(conn io.Reader) error {
+	r := bufio.NewReaderSize(conn, maxSyslogMessage)
+	for {
+		first, err := r.Peek(1)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		var frame []byte
+		if first[0] >= '0' && first[0] <= '9' {
+			count, err := r.ReadString(' ')
+			if err != nil {
+				return unexpectedEOF(err)
+			}
+			n, err := strconv.Atoi(strings.TrimSuffix(count, " "))
+			if err != nil || n <= 0 || n > maxSyslogMessage {
+				return fmt.Errorf("invalid octet count %q", count)
+			}
+			frame = make([]byte, n)
+			if _, err := io.ReadFull(r, frame); err != nil {
+				return unexpectedEOF(err)
+			}
+		} else {
+			frame, err = r.ReadSlice('\n')
+			if err != nil && !(errors.Is(err, io.EOF) && len(frame) > 0) {
+				if errors.Is(err, bufio.ErrBufferFull) {
+					return fmt.Errorf("message longer than %d bytes", maxSyslogMessage)
+				}
+				return err
+			}
+		}
+		l.logSyslog(frame)
+	}
+}
+
+// logSyslog logs a syslog message; messages that don't parse are logged as they are
+func (l *SEQLogger) logSyslog(data []byte) {
+	data = bytes.TrimRight(data, "\r\n\x00")
+	if len(bytes.TrimSpace(data)) == 0 {
+		return
+	}
+
+	m, err := parseSyslog(data, time.Now())
+	if err != nil {
+		l.Log(LevelInformation, escapeTemplate(string(data)), nil)
+		return
+	}
+
+	fields := make(map[string]interface{}, 5+len(m.structuredData))
+	if m.facility < len(syslogFacilities) {
+		fields[SyslogFacilityProperty] = syslogFacilities[m.facility]
+	}
+	for key, value := range map[string]string{
+		SyslogHostnameProperty: m.hostname,
+		SyslogAppNameProperty:  m.appName,
+		SyslogProcIDProperty:   m.procID,
+		SyslogMsgIDProperty:    m.msgID,
+	} {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+	for id, params := range m.structuredData {
+		fields[id] = params
+	}
+	l.LogAt(m.timestamp, syslogLevels[m.severity], escapeTemplate(m.message), fields)
+}
+
+// parseSyslog parses an RFC 5424 message, or failing that an RFC 3164 one; now places
+// RFC 3164 timestamps, which have no year
+func parseSyslog(data []byte, now time.Time) (syslogMessage, error) {
+	s := string(data)
+	if !strings.HasPrefix(s, "<") {
+		return syslogMessage{}, errors.New("missing priority")
+	}
+	end := strings.IndexByte(s, '>')
+	if end < 2 || end > 4 {
+		return syslogMessage{}, errors.New("invalid priority")
+	}
+	pri, err := strconv.Atoi(s[1:end])
+	if err != nil || pri < 0 || pri > 191 {
+		return syslogMessage{}, fmt.Errorf("invalid priority %q", s[1:end])
+	}
+	m := syslogMessage{facility: pri / 8, severity: pri % 8}
+	s = s[end+1:]
+
+	if strings.HasPrefix(s, "1 ") {
+		rfc5424 := m
+		if err := rfc5424.parse5424(s[2:]); err == nil {
+			return rfc5424, nil
+		}
+	}
+	m.parse3164(s, now)
+	return m, nil
+}
+
+// parse5424 parses the header, structured data and message following VERSION
+func (m *syslogMessage) parse5424(s string) error {
+	var header [5]string
+	for i := range header {
+		field, rest, ok := strings.Cut(s, " ")
+		if !ok {
+			return errors.New("truncated header")
+		}
+		if field != "-" {
+			header[i] = field
+		}
+		s = rest
+	}
+	if header[0] != "" {
+		t, err := time.Parse(time.RFC3339Nano, header[0])
+		if err != nil {
+			return fmt.Errorf("invalid timestamp %q", header[0])
+		}
+		m.timestamp = t
+	}
+	m.hostname, m.appName, m.procID, m.msgID = header[1], header[2], header[3], header[4]
+
+	if strings.HasPrefix(s, "-") {
+		s = s[1:]
+	} else {
+		rest, err := m.parseStructuredData(s)
+		if err != nil {
+			return err
+		}
+		s = rest
+	}
+	s = strings.TrimPrefix(s, " ")
+	m.message = strings.TrimPrefix(s, "\ufeff")
+	return nil
+}
+
+// parseStructuredData parses the [SD-ID param="value" ...] elements at the start of s
+// and returns what follows them
+func (m *syslogMessage) parseStructuredData(s string) (string, error) {
+	m.structuredData = make(map[string]map[string]interface{})
+	for strings.HasPrefix(s, "[") {
+		s = s[1:]
+		end := strings.IndexAny(s, " ]")
+		if end <= 0 {
+			return "", errors.New("invalid structured data element")
+		}
+		params := make(map[string]interface{})
+		m.structuredData[s[:end]] = params
+		s = s[end:]
+
+		for strings.HasPrefix(s, " ") {
+			name, rest, ok := strings.Cut(s[1:], `="`)
+			if !ok || name == "" {
+				return "", errors.New("invalid structured data parameter")
+			}
+			value, rest, err := unescapeParamValue(rest)
+			if err != nil {
+				return "", err
+			}
+			params[name] = value
+			s = rest
+		}
+		if !strings.HasPrefix(s, "]") {
+			return "", errors.New("unterminated structured data element")
+		}
+		s = s[1:]
+	}
+	return s, nil
+}
+
+// unescapeParamValue reads a parameter value up to its closing quote, resolving the
+// \" \\ and \] escapes, and returns what follows the quote
+func unescapeParamValue(s string) (string, string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			return b.String(), s[i+1:], nil
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(`"\]`, s[i+1]) >= 0:
+			b.WriteByte(s[i+1])
+			i++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", errors.New("unterminated structured data parameter value")
+}
+
+// rfc3164TimestampLen is the length of an RFC 3164 timestamp such as "Jan  2 03:04:05"
+const rfc3164TimestampLen = len(time.Stamp)
+
+// parse3164 parses "TIMESTAMP HOSTNAME TAG[PID]: MESSAGE". The parts that are missing
+// or malformed, as is common with this loosely specified format, are left to the message.
+func (m *syslogMessage) parse3164(s string, now time.Time) {
+	if len(s) > rfc3164TimestampLen {
+		if t, err := time.ParseInLocation(time.Stamp, s[:rfc3164TimestampLen], now.Location()); err == nil {
+			t = t.AddDate(now.Year(), 0, 0)
+			// A December message read in January is from the previous year
+			if t.After(now.Add(24 * time.Hour)) {
+				t = t.AddDate(-1, 0, 0)
+			}
+			m.timestamp = t
+			s = strings.TrimPrefix(s[rfc3164TimestampLen:], " ")
+
+			if host, rest, ok := strings.Cut(s, " "); ok && !strings.HasSuffix(host, ":") {
+				m.hostname, s = host, rest
+			}
+		}
+	}
+
+	if tag, rest, ok := strings.Cut(s, ": "); ok && tag != "" && !strings.ContainsAny(tag, " ") {
+		if name, pid, ok := strings.Cut(tag, "["); ok && strings.HasSuffix(pid, "]") {
+			m.appName, m.procID = name, strings.TrimSuffix(pid, "]")
+		} else {
+			m.appName = tag
+		}
+		s = rest
+	}
+	m.message = s
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseSyslog5424(t *testing.T) {
+	data := `<165>1 2024-01-02T03:04:05.123Z web01 checkout 4211 ORDER [origin ip="10.0.0.1"][meta@32473 path="C:\\tmp\"x\]" n="1"] ` + "\ufeff" + `Order {7} placed`
+	m, err := parseSyslog([]byte(data), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.facility != 20 || m.severity != 5 || m.hostname != "web01" || m.appName != "checkout" || m.procID != "4211" || m.msgID != "ORDER" {
+		t.Errorf("Unexpected header %+v", m)
+	}
+	if !m.timestamp.Equal(time.Date(2024, 1, 2, 3, 4, 5, 123e6, time.UTC)) {
+		t.Errorf("Unexpected timestamp %v", m.timestamp)
+	}
+	if m.structuredData["origin"]["ip"] != "10.0.0.1" || m.structuredData["meta@32473"]["path"] != `C:\tmp"x]` || m.structuredData["meta@32473"]["n"] != "1" {
+		t.Errorf("Unexpected structured data %v", m.structuredData)
+	}
+	if m.message != "Order {7} placed" {
+		t.Errorf("Unexpected message %q", m.message)
+	}
+}
+
+func TestParseSyslog5424NilValues(t *testing.T) {
+	m, err := parseSyslog([]byte("<11>1 - - - - - -"), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.timestamp.IsZero() || m.hostname != "" || m.structuredData != nil || m.message != "" || m.severity != 3 {
+		t.Errorf("Unexpected message %+v", m)
+	}
+}
+
+func TestParseSyslog3164(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		data                    string
+		timestamp               time.Time
+		hostname, app, pid, msg string
+	}{
+		{"<34>Jan  1 10:00:00 mymachine su[230]: 'su root' failed", time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), "mymachine", "su", "230", "'su root' failed"},
+		{"<13>Dec 31 23:59:59 cron: nightly job done", time.Date(2023, 12, 31, 23, 59, 59, 0, time.UTC), "", "cron", "", "nightly job done"},
+		{"<13>just some text", time.Time{}, "", "", "", "just some text"},
+	}
+
+	for _, test := range tests {
+		m, err := parseSyslog([]byte(test.data), now)
+		if err != nil {
+			t.Errorf("Failed to parse %q: %v", test.data, err)
+			continue
+		}
+		if !m.timestamp.Equal(test.timestamp) || m.hostname != test.hostname || m.appName != test.app || m.procID != test.pid || m.message != test.msg {
+			t.Errorf("parseSyslog(%q) = %+v", test.data, m)
+		}
+	}
+}
+
+func TestParseSyslogRejectsMissingPriority(t *testing.T) {
+	for _, data := range []string{"no priority", "<abc>text", "<999>text"} {
+		if _, err := parseSyslog([]byte(data), time.Now()); err == nil {
+			t.Errorf("Expected %q to be rejected", data)
+		}
+	}
+}
+
+func TestSyslogReceivers(t *testing.T) {
+	logger := newQueueLogger(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go logger.ServeSyslogPacket(ctx, packetConn)
+	go logger.ServeSyslog(ctx, ln)
+
+	udp, err := net.Dial("udp", packetConn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udp.Close()
+	udp.Write([]byte("<11>1 - host app - - - over udp"))
+
+	tcp, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcp.Close()
+	framed := "<15>1 - host app - - - octet counted"
+	tcp.Write([]byte(strconv.Itoa(len(framed)) + " " + framed + "<12>newline framed\n"))
+
+	want := map[string]string{"over udp": LevelError, "octet counted": LevelDebug, "newline framed": LevelWarning}
+	for len(want) > 0 {
+		select {
+		case logMessage := <-logger.logChan:
+			level, ok := want[logMessage.MessageTemplate]
+			if !ok {
+				t.Fatalf("Unexpected event %+v", logMessage)
+			}
+			if logMessage.Level != level || logMessage.Fields[SyslogFacilityProperty] != "user" {
+				t.Errorf("Unexpected event %+v", logMessage)
+			}
+			delete(want, logMessage.MessageTemplate)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Events %v were not logged", want)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FilePathProperty carries the path of the file an event tailed by a FileTailer was read from
+const FilePathProperty = "FilePath"
+
+const (
+	// defaultTailInterval is how often a FileTailer looks for new lines and files
+	defaultTailInterval = time.Second
+	// maxTailLine bounds a line; longer lines are shipped in pieces of this size
+	maxTailLine = 1 << 20
+	// maxRotatedFiles bounds the rotated files remembered by a FileTailer
+	maxRotatedFiles = 64
+	// tailHeadSize is how much of the start of a file identifies it in the checkpoint
+	tailHeadSize = 256
+)
+
+// FileTailer ships the lines appended to the files matching its glob patterns, for
+// applications that can only log to files. JSON lines are mapped like the pipe
+// subcommand maps them and any other line becomes the message. Rotated files are read
+// to their end before the new file at the same path, and truncated files from their
+// start again. The offsets reached are kept in a checkpoint file, so a restarted
+// tailer resumes where it stopped. Offsets advance once lines are queued; use
+// WithWAL for them to survive a crash before delivery.
+type FileTailer struct {
+	logger     *SEQLogger
+	patterns   []string
+	checkpoint string // path of the checkpoint file, empty to keep offsets in memory only
+	interval   time.Duration
+	// parse turns a line of a file into an event, or reports false for a line that
+	// isn't one, e.g. the first part of a line split by a container runtime
+	parse func(path string, line []byte) (ingestEvent, bool)
+	buf   []byte
+
+	files map[string]*tailedFile
+	// rotated are the files rotated away from their path, so one that matches the
+	// patterns under its new name, e.g. app.log.1 for app*, isn't shipped again
+	rotated []*tailedFile
+	saved   map[string]tailCheckpoint // offsets read from the checkpoint file, by path
+	synced  bool                      // whether the checkpoint file holds the current offsets
+}
+
+// tailedFile is an open file being tailed
+type tailedFile struct {
+	path    string
+	file    *os.File
+	info    os.FileInfo
+	offset  int64  // offset of the first byte not yet read
+	partial []byte // the start of a line whose end hasn't been written yet
+	head    tailCheckpoint
+}
+
+// tailCheckpoint is a file's entry in the checkpoint file. Head is a hash of the
+// file's first HeadLen bytes, which tells a file replaced while the tailer was
+// stopped apart from the one the offset was recorded for.
+type tailCheckpoint struct {
+	Offset  int64  `json:"offset"`
+	Head    string `json:"head"`
+	HeadLen int    `json:"headLen"`
+}
+
+// NewFileTailer creates a FileTailer shipping the files matching patterns, see
+// filepath.Match, through logger and keeping its offsets in checkpoint
+func NewFileTailer(logger *SEQLogger, checkpoint string, patterns ...string) *FileTailer {
+	mapping := defaultPipeMapping()
+	return &FileTailer{
+		logger:     logger,
+		patterns:   patterns,
+		checkpoint: checkpoint,
+		interval:   defaultTailInterval,
+		parse:      func(_ string, line []byte) (ingestEvent, bool) { return mapping.event(line), true },
+		buf:        make([]byte, 64*1024),
+		files:      make(map[string]*tailedFile),
+	}
+}
+
+// Run tails the files until ctx is done, then saves the checkpoint
+func (t *FileTailer) Run(ctx context.Context) error {
+	if err := t.loadCheckpoint(); err != nil {
+		return err
+	}
+	defer t.closeFiles()
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	for {
+		t.poll()
+		select {
+		case <-ctx.Done():
+			t.saveCheckpoint()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll opens the files that newly match the patterns, reads what was appended to
+// every tailed file and saves the checkpoint if an offset moved
+func (t *FileTailer) poll() {
+	matches := make(map[string]bool)
+	for _, pattern := range t.patterns {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			selfLogf("Invalid file pattern %q: %v", pattern, err)
+			continue
+		}
+		for _, path := range paths {
+			matches[path] = true
+		}
+	}
+
+	for path := range t.files {
+		if !matches[path] {
+			t.remove(path)
+		}
+	}
+	paths := make([]string, 0, len(matches))
+	for path := range matches {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		t.follow(path)
+	}
+	t.saveCheckpoint()
+}
+
+// follow reads what was appended to path, handling rotation and truncation
+func (t *FileTailer) follow(path string) {
+	f, ok := t.files[path]
+	if !ok {
+		var err error
+		if f, err = t.open(path); err != nil {
+			selfLogf("Failed to open %s for tailing: %v", path, err)
+			return
+		}
+		t.files[path] = f
+	}
+
+	info, err := os.Stat(path)
+	switch {
+	case err != nil:
+		t.remove(path)
+		return
+	case !os.SameFile(f.info, info):
+		// Rotated: finish the old file, then start on the new one
+		t.read(f, true)
+		f.file.Close()
+		delete(t.files, path)
+		t.remember(f)
+		if f, err = t.openFile(path); err != nil {
+			selfLogf("Failed to open %s for tailing: %v", path, err)
+			return
+		}
+		t.files[path] = f
+	case info.Size() < f.offset:
+		// Truncated, e.g. by logrotate's copytruncate
+		f.offset, f.partial, f.head = 0, nil, tailCheckpoint{}
+		t.synced = false
+	}
+	t.read(f, false)
+}
+
+// open starts tailing path at its checkpointed offset, when the checkpoint is for this file
+func (t *FileTailer) open(path string) (*tailedFile, error) {
+	f, err := t.openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, rotated := range t.rotated {
+		if os.SameFile(rotated.info, f.info) {
+			f.offset = rotated.offset
+			return f, nil
+		}
+	}
+	if saved, ok := t.saved[path]; ok && saved.Offset <= f.info.Size() {
+		if head, err := fileHead(f.file, saved.HeadLen); err == nil && head.Head == saved.Head {
+			f.offset, f.head = saved.Offset, head
+		}
+	}
+	return f, nil
+}
+
+// openFile opens path for tailing from its start
+func (t *FileTailer) openFile(path string) (*tailedFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &tailedFile{path: path, file: file, info: info}, nil
+}
+
+// remove stops tailing path, which no longer matches, after reading what is left of it
+func (t *FileTailer) remove(path string) {
+	if f, ok := t.files[path]; ok {
+		t.read(f, true)
+		f.file.Close()
+		delete(t.files, path)
+		// The file may have been renamed rather than deleted
+		t.remember(f)
+	}
+	delete(t.saved, path)
+	t.synced = false
+}
+
+// remember keeps the offset of a file that was read to its end
+func (t *FileTailer) remember(f *tailedFile) {
+	t.synced = false
+	if t.rotated = append(t.rotated, f); len(t.rotated) > maxRotatedFiles {
+		t.rotated = t.rotated[1:]
+	}
+}
+
+// read logs the complete lines appended to f since the last read; final also logs a
+// last line without a newline, as nothing more will be appended to it
+func (t *FileTailer) read(f *tailedFile, final bool) {
+	for {
+		n, err := f.file.ReadAt(t.buf, f.offset)
+		if n > 0 {
+			f.offset += int64(n)
+			t.synced = false
+			t.lines(f, t.buf[:n])
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				selfLogf("Failed to read %s: %v", f.path, err)
+			}
+			break
+		}
+	}
+	if final && len(f.partial) > 0 {
+		t.logLine(f.path, f.partial)
+		f.partial = nil
+	}
+}
+
+// lines logs the complete lines in data, keeping a trailing partial line for the next read
+func (t *FileTailer) lines(f *tailedFile, data []byte) {
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			f.partial = append(f.partial, data...)
+			if len(f.partial) >= maxTailLine {
+				t.logLine(f.path, f.partial)
+				f.partial = nil
+			}
+			return
+		}
+		line := data[:i]
+		if len(f.partial) > 0 {
+			line = append(f.partial, line...)
+			f.partial = nil
+		}
+		t.logLine(f.path, line)
+		data = data[i+1:]
+	}
+}
+
+// logLine logs a line read from path
+func (t *FileTailer) logLine(path string, line []byte) {
+	line = bytes.TrimRight(line, "\r")
+	if len(bytes.TrimSpace(line)) == 0 {
+		return
+	}
+	event, ok := t.parse(path, line)
+	if !ok {
+		return
+	}
+	event.fields = withField(event.fields, FilePathProperty, path)
+	t.logger.LogAt(event.timestamp, event.level, event.template, event.fields)
+}
+
+// fileHead hashes the first n bytes of file
+func fileHead(file *os.File, n int) (tailCheckpoint, error) {
+	head := make([]byte, n)
+	if _, err := file.ReadAt(head, 0); err != nil && !(errors.Is(err, io.EOF) && n == 0) {
+		return tailCheckpoint{}, err
+	}
+	sum := sha256.Sum256(head)
+	return tailCheckpoint{Head: hex.EncodeToString(sum[:]), HeadLen: n}, nil
+}
+
+// loadCheckpoint reads the offsets saved by a previous run
+func (t *FileTailer) loadCheckpoint() error {
+	t.saved = make(map[string]tailCheckpoint)
+	if t.checkpoint == "" {
+		return nil
+	}
+	data, err := os.ReadFile(t.checkpoint)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &t.saved); err != nil {
+		selfLogf("Ignoring unreadable tail checkpoint %s: %v", t.checkpoint, err)
+		t.saved = make(map[string]tailCheckpoint)
+	}
+	return nil
+}
+
+// saveCheckpoint writes the current offsets, replacing the checkpoint file atomically
+func (t *FileTailer) saveCheckpoint() {
+	if t.synced {
+		return
+	}
+	for path, f := range t.files {
+		// The head grows with the file until it is tailHeadSize bytes long
+		if n := int(min(f.offset, tailHeadSize)); n != f.head.HeadLen || f.head.Head == "" {
+			if head, err := fileHead(f.file, n); err == nil {
+				f.head = head
+			}
+		}
+		// A partial line is read again after a restart
+		offset := f.offset - int64(len(f.partial))
+		t.saved[path] = tailCheckpoint{Offset: offset, Head: f.head.Head, HeadLen: f.head.HeadLen}
+	}
+	if t.checkpoint == "" {
+		t.synced = true
+		return
+	}
+
+	data, err := json.Marshal(t.saved)
+	if err != nil {
+		selfLogf("Failed to encode tail checkpoint: %v", err)
+		return
+	}
+	tmp := t.checkpoint + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		selfLogf("Failed to write tail checkpoint: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, t.checkpoint); err != nil {
+		selfLogf("Failed to write tail checkpoint: %v", err)
+		return
+	}
+	t.synced = true
+}
+
+// closeFiles closes every tailed file
+func (t *FileTailer) closeFiles() {
+	for path, f := range t.files {
+		f.file.Close()
+		delete(t.files, path)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// appendFile appends data to the file at path, creating it if needed
+func appendFile(t *testing.T, path, data string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestTailer creates a FileTailer with its checkpoint loaded, ready to poll
+func newTestTailer(t *testing.T, logger *SEQLogger, checkpoint string, patterns ...string) *FileTailer {
+	t.Helper()
+	tailer := NewFileTailer(logger, checkpoint, patterns...)
+	if err := tailer.loadCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(tailer.closeFiles)
+	return tailer
+}
+
+// drainTemplates returns the templates of the events queued on logger
+func drainTemplates(logger *SEQLogger) []string {
+	var templates []string
+	for len(logger.logChan) > 0 {
+		templates = append(templates, (<-logger.logChan).MessageTemplate)
+	}
+	return templates
+}
+
+func TestFileTailerFollowsAppendsAndPartialLines(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "first\n{\"msg\": \"second\", \"level\": \"error\"}\nthi")
+
+	logger := newQueueLogger(10)
+	tailer := newTestTailer(t, logger, "", filepath.Join(dir, "*.log"))
+	tailer.poll()
+
+	logMessage := <-logger.logChan
+	if logMessage.MessageTemplate != "first" || logMessage.Fields[FilePathProperty] != path {
+		t.Errorf("Unexpected event %+v", logMessage)
+	}
+	if logMessage := <-logger.logChan; logMessage.MessageTemplate != "second" || logMessage.Level != LevelError {
+		t.Errorf("Expected the JSON line to be mapped, got %+v", logMessage)
+	}
+	if got := drainTemplates(logger); len(got) != 0 {
+		t.Errorf("Expected the partial line to wait, got %v", got)
+	}
+
+	appendFile(t, path, "rd\n")
+	tailer.poll()
+	if got := drainTemplates(logger); len(got) != 1 || got[0] != "third" {
+		t.Errorf("Expected the completed line, got %v", got)
+	}
+}
+
+func TestFileTailerHandlesRotationAndTruncation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "one\n")
+
+	logger := newQueueLogger(10)
+	tailer := newTestTailer(t, logger, "", path)
+	tailer.poll()
+
+	// Rotated by rename, with a last line written to the old file just before
+	appendFile(t, path, "two")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path, "three\n")
+	tailer.poll()
+
+	// Truncated in place
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path, "four\n")
+	tailer.poll()
+
+	// The rotated file matching the patterns under its new name isn't shipped again
+	tailer.patterns = append(tailer.patterns, path+".*")
+	tailer.poll()
+
+	got := drainTemplates(logger)
+	want := []string{"one", "two", "three", "four"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestFileTailerResumesFromCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	checkpoint := filepath.Join(dir, "offsets")
+	appendFile(t, path, "shipped\npart")
+
+	logger := newQueueLogger(10)
+	first := newTestTailer(t, logger, checkpoint, path)
+	first.poll()
+	first.closeFiles()
+	drainTemplates(logger)
+
+	appendFile(t, path, "ial\nnew\n")
+	second := newTestTailer(t, logger, checkpoint, path)
+	second.poll()
+	if got := drainTemplates(logger); len(got) != 2 || got[0] != "partial" || got[1] != "new" {
+		t.Errorf("Expected to resume at the partial line, got %v", got)
+	}
+	second.closeFiles()
+
+	// A different file at the same path is read from its start
+	if err := os.WriteFile(path, []byte("replaced while stopped\nand longer than before\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	third := newTestTailer(t, logger, checkpoint, path)
+	third.poll()
+	if got := drainTemplates(logger); len(got) != 2 || got[0] != "replaced while stopped" {
+		t.Errorf("Expected the replaced file from its start, got %v", got)
+	}
+}
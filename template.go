@@ -0,0 +1,150 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// templateToken is either literal text or a property placeholder of a message template
+type templateToken struct {
+	// Text is the literal text of the token; for placeholders it holds the raw "{...}" source
+	Text string
+	// Property is the placeholder's property name, empty for literal text
+	Property string
+	// Alignment is the optional ",n" alignment of the placeholder
+	Alignment string
+	// Format is the optional ":format" specifier of the placeholder
+	Format string
+}
+
+// parseTemplate splits a message template into literal text and property tokens.
+// "{{" and "}}" escape literal braces; malformed placeholders are kept as text.
+func parseTemplate(template string) []templateToken {
+	var tokens []templateToken
+	var text strings.Builder
+
+	flushText := func() {
+		if text.Len() > 0 {
+			tokens = append(tokens, templateToken{Text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		switch {
+		case c == '{' && i+1 < len(template) && template[i+1] == '{':
+			text.WriteByte('{')
+			i++
+		case c == '}' && i+1 < len(template) && template[i+1] == '}':
+			text.WriteByte('}')
+			i++
+		case c == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				text.WriteString(template[i:])
+				i = len(template)
+				continue
+			}
+			raw := template[i : i+end+1]
+			token, ok := parsePlaceholder(raw)
+			if !ok {
+				text.WriteString(raw)
+			} else {
+				flushText()
+				tokens = append(tokens, token)
+			}
+			i += end
+		default:
+			text.WriteByte(c)
+		}
+	}
+	flushText()
+
+	return tokens
+}
+
+// parsePlaceholder parses a raw "{Name,alignment:format}" placeholder
+func parsePlaceholder(raw string) (templateToken, bool) {
+	content := raw[1 : len(raw)-1]
+	token := templateToken{Text: raw}
+
+	name, format, hasFormat := strings.Cut(content, ":")
+	if hasFormat {
+		token.Format = format
+	}
+	name, alignment, hasAlignment := strings.Cut(name, ",")
+	if hasAlignment {
+		if !validAlignment(alignment) {
+			return templateToken{}, false
+		}
+		token.Alignment = alignment
+	}
+
+	// '@' and '$' are destructuring/stringification hints and not part of the property name
+	if len(name) > 0 && (name[0] == '@' || name[0] == '$') {
+		name = name[1:]
+	}
+	if !validPlaceholderName(name) {
+		return templateToken{}, false
+	}
+	token.Property = name
+
+	return token, true
+}
+
+// validPlaceholderName reports whether name consists only of letters, digits and underscores
+func validPlaceholderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// validAlignment reports whether alignment is an optionally negative integer
+func validAlignment(alignment string) bool {
+	alignment = strings.TrimPrefix(alignment, "-")
+	if alignment == "" {
+		return false
+	}
+	for i := 0; i < len(alignment); i++ {
+		if alignment[i] < '0' || alignment[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Rendering is a property value formatted with the format specifier used in the message template
+type Rendering struct {
+	Property  string
+	Format    string
+	Rendering string
+}
+
+// renderings formats the values of every placeholder with a format specifier, in order of appearance.
+// It takes the fields as passed by the caller so numbers and times are formatted before normalization.
+func (p *parsedTemplate) renderings(fields map[string]interface{}) []Rendering {
+	if len(fields) == 0 || len(p.formatted) == 0 {
+		return nil
+	}
+
+	var renderings []Rendering
+	for _, token := range p.formatted {
+		rendering := token.Text
+		if value, ok := fields[token.Property]; ok {
+			rendering = formatValue(value, token.Format)
+		}
+		renderings = append(renderings, Rendering{
+			Property:  token.Property,
+			Format:    token.Format,
+			Rendering: rendering,
+		})
+	}
+	return renderings
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tokens := parseTemplate("User {@User} took {Elapsed,5:000} ms {{literal}} {bad name} {Open")
+
+	want := []templateToken{
+		{Text: "User "},
+		{Text: "{@User}", Property: "User"},
+		{Text: " took "},
+		{Text: "{Elapsed,5:000}", Property: "Elapsed", Alignment: "5", Format: "000"},
+		{Text: " ms {literal} {bad name} {Open"},
+	}
+	if !reflect.DeepEqual(tokens, want) {
+		t.Errorf("parseTemplate() = %#v, want %#v", tokens, want)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	at := time.Date(2024, 1, 2, 15, 4, 5, 123000000, time.UTC)
+	tests := []struct {
+		value  interface{}
+		format string
+		want   string
+	}{
+		{34, "000", "034"},
+		{-7, "000", "-007"},
+		{3.14159, "0.00", "3.14"},
+		{0.5, "#.##", ".5"},
+		{1234567.891, "#,##0.0", "1,234,567.9"},
+		{42, "D5", "00042"},
+		{255, "X4", "00FF"},
+		{255, "x", "ff"},
+		{1234.5, "N", "1,234.50"},
+		{2.5, "F1", "2.5"},
+		{0.125, "P1", "12.5%"},
+		{at, "yyyy-MM-dd HH:mm:ss.fff", "2024-01-02 15:04:05.123"},
+		{at, "dd MMM yyyy 'at' h:mm tt", "02 Jan 2024 at 3:04 PM"},
+		{at, "s", "2024-01-02T15:04:05"},
+		{"text", "000", "text"},
+		{1.5, "E", "1.5"},
+	}
+
+	for _, test := range tests {
+		if got := formatValue(test.value, test.format); got != test.want {
+			t.Errorf("formatValue(%v, %q) = %q, want %q", test.value, test.format, got, test.want)
+		}
+	}
+}
+
+func TestTemplateRenderings(t *testing.T) {
+	renderings := templates.get("Took {Elapsed:000} ms for {Count} items at {Rate:0.0} ({Missing:00})").renderings(map[string]interface{}{
+		"Elapsed": 34,
+		"Count":   3,
+		"Rate":    1.25,
+	})
+
+	want := []Rendering{
+		{Property: "Elapsed", Format: "000", Rendering: "034"},
+		{Property: "Rate", Format: "0.0", Rendering: "1.3"},
+		{Property: "Missing", Format: "00", Rendering: "{Missing:00}"},
+	}
+	if !reflect.DeepEqual(renderings, want) {
+		t.Errorf("renderings() = %#v, want %#v", renderings, want)
+	}
+	if templates.get("Took {Elapsed} ms").renderings(map[string]interface{}{"Elapsed": 1}) != nil {
+		t.Errorf("Expected no renderings without format specifiers")
+	}
+}
+
+func TestTemplateCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newTemplateCache(2)
+	first := cache.get("User {UserId} logged in")
+	cache.get("User {UserId} logged out")
+	if cache.get("User {UserId} logged in") != first {
+		t.Fatal("Expected a cached template to be reused")
+	}
+
+	cache.get("Order {OrderId} placed")
+	if _, ok := cache.entries["User {UserId} logged out"]; ok {
+		t.Error("Expected the least recently used template to be evicted")
+	}
+	if cache.get("User {UserId} logged in") != first {
+		t.Error("Expected the recently used template to stay cached")
+	}
+	if first.eventID != eventTypeID("User {UserId} logged in") {
+		t.Errorf("Unexpected cached event id %08x", first.eventID)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+)
+
+// templateCacheSize bounds how many parsed templates are kept; applications log
+// from a fixed set of templates, so a miss on a hot template should be rare
+const templateCacheSize = 1024
+
+// parsedTemplate is what Log needs from a message template, computed once per template
+type parsedTemplate struct {
+	template string
+	eventID  uint32
+	// tokens is the parsed template
+	tokens []templateToken
+	// properties holds the property names of all placeholders, in order of appearance
+	properties []string
+	// formatted holds the placeholders with a format specifier, which get renderings
+	formatted []templateToken
+}
+
+// templateCache is an LRU cache of parsed templates keyed by the template string
+type templateCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   list.List // most recently used first; values are *parsedTemplate
+}
+
+// templates is shared by all loggers, as templates are usually string constants
+var templates = newTemplateCache(templateCacheSize)
+
+// newTemplateCache creates a templateCache holding at most size templates
+func newTemplateCache(size int) *templateCache {
+	return &templateCache{size: size, entries: make(map[string]*list.Element, size)}
+}
+
+// get returns the parsed template, parsing and caching it on a miss
+func (c *templateCache) get(template string) *parsedTemplate {
+	c.mu.Lock()
+	if element, ok := c.entries[template]; ok {
+		c.order.MoveToFront(element)
+		c.mu.Unlock()
+		return element.Value.(*parsedTemplate)
+	}
+	c.mu.Unlock()
+
+	// Parse outside the lock; a concurrent miss on the same template parses it twice
+	parsed := parseTemplateOnce(template)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[template]; ok {
+		return element.Value.(*parsedTemplate)
+	}
+	c.entries[template] = c.order.PushFront(parsed)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*parsedTemplate).template)
+	}
+	return parsed
+}
+
+// parseTemplateOnce does the per-template work that the cache saves on later calls
+func parseTemplateOnce(template string) *parsedTemplate {
+	parsed := &parsedTemplate{template: template, eventID: eventTypeID(template)}
+	parsed.tokens = parseTemplate(template)
+	for _, token := range parsed.tokens {
+		if token.Property != "" {
+			parsed.properties = append(parsed.properties, token.Property)
+		}
+		if token.Property != "" && token.Format != "" {
+			parsed.formatted = append(parsed.formatted, token)
+		}
+	}
+	return parsed
+}
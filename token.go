@@ -0,0 +1,33 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// EventTokenProperty carries a client-generated token unique to each event. Retries,
+// WAL replay and audit resends deliver the same token, so Seq signals and queries can
+// tell a redelivery, which repeats the token, from a genuinely repeated event.
+const EventTokenProperty = "EventToken"
+
+// newEventToken returns a random 128-bit token in hex
+func newEventToken() string {
+	var token [16]byte
+	rand.Read(token[:])
+	return hex.EncodeToString(token[:])
+}
+
+// withEventToken returns a copy of fields carrying a fresh event token,
+// unless the caller already supplied one
+func withEventToken(fields map[string]interface{}) map[string]interface{} {
+	if _, ok := fields[EventTokenProperty]; ok {
+		return fields
+	}
+	return withField(fields, EventTokenProperty, newEventToken())
+}
+
+// needsEventTokens reports whether events may be delivered more than once: with a
+// WAL, with retries enabled or when explicitly requested with WithEventTokens
+func (l *SEQLogger) needsEventTokens() bool {
+	return l.eventTokens || l.wal != nil || l.retry.maxAttempts > 1
+}
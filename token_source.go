@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// tokenExpiryMargin is how long before its expiry a bearer token is refreshed, so it
+// doesn't expire on its way to the server
+const tokenExpiryMargin = 30 * time.Second
+
+// TokenFunc returns a bearer token and when it expires, a zero expiry for a token that
+// doesn't. An oauth2.TokenSource adapts as
+//
+//	func(ctx context.Context) (string, time.Time, error) {
+//		t, err := source.Token()
+//		if err != nil {
+//			return "", time.Time{}, err
+//		}
+//		return t.AccessToken, t.Expiry, nil
+//	}
+type TokenFunc func(ctx context.Context) (token string, expiry time.Time, err error)
+
+// bearerTokens caches the token of a TokenFunc until it is about to expire
+type bearerTokens struct {
+	fetch  TokenFunc
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// WithTokenSource sends "Authorization: Bearer <token>" on every request to the SEQ
+// server, for a server behind an identity-aware proxy. Tokens from fetch are reused
+// until shortly before they expire, or until the server answers 401, when the request
+// is made again at once with a new token. A failure to get one fails the request like
+// a network error, so it is retried.
+func WithTokenSource(fetch TokenFunc) Option {
+	return func(l *SEQLogger) {
+		l.tokens = &bearerTokens{fetch: fetch}
+	}
+}
+
+// get returns a token that is valid for at least tokenExpiryMargin, fetching one if needed
+func (b *bearerTokens) get(ctx context.Context) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.token != "" && (b.expiry.IsZero() || time.Until(b.expiry) > tokenExpiryMargin) {
+		return b.token, nil
+	}
+	token, expiry, err := b.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	b.token, b.expiry = token, expiry
+	return token, nil
+}
+
+// invalidate drops a token the server rejected, so the next request fetches a new one
+func (b *bearerTokens) invalidate() {
+	b.mu.Lock()
+	b.token = ""
+	b.mu.Unlock()
+}
+
+// authorize sets the bearer token on a request to the SEQ server, when WithTokenSource is used
+func (l *SEQLogger) authorize(ctx context.Context, req *http.Request) error {
+	if l.tokens == nil {
+		return nil
+	}
+	token, err := l.tokens.get(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to get a bearer token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenSourceRefreshesTokens(t *testing.T) {
+	authorizations := make(chan string, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		authorizations <- authorization
+		if authorization == "Bearer token-3" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	var fetches atomic.Int32
+	expiry := time.Now().Add(time.Hour)
+	logger := newSenderLogger(server.URL, WithFallback(&memorySink{}), WithTokenSource(func(ctx context.Context) (string, time.Time, error) {
+		n := fetches.Add(1)
+		if n == 2 {
+			// Expires within the margin, so it is refreshed before it is used again
+			return "token-2", time.Now().Add(time.Second), nil
+		}
+		return "token-" + strconv.Itoa(int(n)), expiry, nil
+	}))
+
+	deliver := func() { logger.deliver(server.Client(), benchmarkBatch(1)) }
+	deliver()
+	deliver()
+	for _, want := range []string{"Bearer token-1", "Bearer token-1"} {
+		if got := <-authorizations; got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	}
+
+	logger.tokens.invalidate()
+	deliver()
+	deliver()
+	deliver()
+	for _, want := range []string{"Bearer token-2", "Bearer token-3", "Bearer token-4"} {
+		if got := <-authorizations; got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	}
+}
+
+func TestTokenSourceRetriesRejectedToken(t *testing.T) {
+	var received atomic.Value
+	received.Store("")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received.Store(string(body))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	var fetches atomic.Int32
+	authErrors := 0
+	fallback := &memorySink{}
+	logger := newSenderLogger(server.URL, WithFallback(fallback), WithOnAuthError(func(*AuthError) { authErrors++ }),
+		WithTokenSource(func(ctx context.Context) (string, time.Time, error) {
+			return "token-" + strconv.Itoa(int(fetches.Add(1))), time.Time{}, nil
+		}))
+
+	batch := benchmarkBatch(1)
+	logger.deliver(server.Client(), batch)
+	if !strings.Contains(received.Load().(string), batch[0].MessageTemplate) || len(fallback.templates) != 0 {
+		t.Errorf("Expected the batch delivered with the refreshed token, got %q", received.Load())
+	}
+	if fetches.Load() != 2 || authErrors != 0 {
+		t.Errorf("Expected one refresh and no auth error, got %d fetches and %d auth errors", fetches.Load(), authErrors)
+	}
+}
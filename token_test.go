@@ -0,0 +1,77 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+var eventTokenPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+func TestEventTokensWhenRedeliveryIsPossible(t *testing.T) {
+	tests := map[string][]Option{
+		"explicit": {WithEventTokens()},
+		"retries":  {WithRetry(3, time.Millisecond, time.Second)},
+	}
+
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			logger := newQueueLogger(2, opts...)
+			logger.Log(LevelInformation, "Order placed", nil)
+			logger.Log(LevelInformation, "Order placed", map[string]interface{}{"OrderId": 1})
+
+			first, second := <-logger.logChan, <-logger.logChan
+			token, _ := first.Fields[EventTokenProperty].(string)
+			if !eventTokenPattern.MatchString(token) {
+				t.Fatalf("Expected a 128-bit hex token, got %q", token)
+			}
+			if second.Fields[EventTokenProperty] == token {
+				t.Errorf("Expected each event to get its own token")
+			}
+		})
+	}
+}
+
+func TestNoEventTokensByDefault(t *testing.T) {
+	logger := newQueueLogger(1)
+	logger.Log(LevelInformation, "Order placed", nil)
+	if logMessage := <-logger.logChan; logMessage.Fields != nil {
+		t.Errorf("Expected no properties, got %v", logMessage.Fields)
+	}
+}
+
+func TestEventTokenSurvivesWALReplay(t *testing.T) {
+	dir := t.TempDir()
+	logger, _ := newWALLogger(t, "http://localhost:0", dir)
+	logger.Log(LevelInformation, "Order placed", map[string]interface{}{"OrderId": 1})
+	token := (<-logger.logChan).Fields[EventTokenProperty]
+
+	_, replay := newWALLogger(t, "http://localhost:0", dir)
+	if len(replay) != 1 || replay[0].Fields[EventTokenProperty] != token {
+		t.Errorf("Expected the replayed event to keep token %v, got %+v", token, replay)
+	}
+}
+
+func TestCallerEventTokenIsKept(t *testing.T) {
+	logger := newQueueLogger(1, WithEventTokens())
+	logger.Log(LevelInformation, "Order placed", map[string]interface{}{EventTokenProperty: "order-42"})
+	if token := (<-logger.logChan).Fields[EventTokenProperty]; token != "order-42" {
+		t.Errorf("Expected the caller's token, got %v", token)
+	}
+}
+
+func TestSequenceNumbersIncrease(t *testing.T) {
+	logger := newQueueLogger(3, WithSequenceNumbers(), WithMinLevel(LevelInformation))
+	logger.Log(LevelInformation, "First", nil)
+	logger.Log(LevelDebug, "Filtered", nil)
+	logger.Log(LevelInformation, "Second", map[string]interface{}{"OrderId": 1})
+
+	first, second := <-logger.logChan, <-logger.logChan
+	if first.Fields[SequenceNumberProperty] != uint64(1) || second.Fields[SequenceNumberProperty] != uint64(2) {
+		t.Errorf("Expected sequence numbers 1 and 2, got %v and %v",
+			first.Fields[SequenceNumberProperty], second.Fields[SequenceNumberProperty])
+	}
+	if second.Fields["OrderId"] != 1 {
+		t.Errorf("Expected call-site fields to be kept, got %v", second.Fields)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import "net/http"
+
+// WithTracing records every ingestion request as an OpenTelemetry client span through
+// the global tracer provider, so slow SEQ ingestion shows up in the application's
+// traces. The instrumentation is only compiled into programs built with
+// -tags seqlog_otel, keeping the OpenTelemetry packages out of the others, see
+// TracingCompiled; without the tag WithTracing does nothing.
+func WithTracing() Option {
+	return func(l *SEQLogger) {
+		l.tracing = true
+	}
+}
+
+// tracedRoundTripper is the round-tripper of a traced client. It closes the idle
+// connections of the transport it wraps, which the instrumentation doesn't pass
+// on, so WithConnectionReset keeps working.
+type tracedRoundTripper struct {
+	http.RoundTripper
+	base http.RoundTripper
+}
+
+func (t *tracedRoundTripper) CloseIdleConnections() {
+	if closer, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// traced returns a copy of t whose requests are traced, see WithTracing
+func (t *Transport) traced() *Transport {
+	base := t.client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	client := *t.client
+	client.Transport = &tracedRoundTripper{RoundTripper: traceRoundTripper(base), base: base}
+	return &Transport{client: &client, slots: t.slots}
+}
@@ -0,0 +1,14 @@
+//go:build !seqlog_otel
+
+package main
+
+import "net/http"
+
+// TracingCompiled is true when the program is built with the seqlog_otel tag, which
+// lets WithTracing record ingestion requests as OpenTelemetry spans
+const TracingCompiled = false
+
+// traceRoundTripper returns base: the program was built without the seqlog_otel tag
+func traceRoundTripper(base http.RoundTripper) http.RoundTripper {
+	return base
+}
@@ -0,0 +1,22 @@
+//go:build seqlog_otel
+
+package main
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// TracingCompiled is true when the program is built with the seqlog_otel tag, which
+// lets WithTracing record ingestion requests as OpenTelemetry spans
+const TracingCompiled = true
+
+// traceRoundTripper wraps base with the OpenTelemetry HTTP instrumentation
+func traceRoundTripper(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base,
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return "SEQ " + r.Method + " " + r.URL.Path
+		}),
+	)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTracingDeliversThroughTracedTransport(t *testing.T) {
+	server := newSeqRecorder(t)
+	logger := newTestLogger(t, server.URL+EndpointRaw, 10, WithTracing())
+	defer logger.Close()
+
+	_, traced := logger.transport.client.Transport.(*tracedRoundTripper)
+	if traced != TracingCompiled {
+		t.Errorf("Expected the transport traced only with the seqlog_otel tag, got %T", logger.transport.client.Transport)
+	}
+	logger.Log(LevelInformation, "Order placed", nil)
+	if err := logger.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	if received := server.received(); !strings.Contains(received, "Order placed") {
+		t.Errorf("Expected the event delivered, got %s", received)
+	}
+}
+
+func TestTracedTransportKeepsConnectionReset(t *testing.T) {
+	roundTripper := &failingRoundTripper{}
+	transport := (&Transport{client: &http.Client{Transport: roundTripper}}).traced()
+	logger := newSenderLogger("http://seq.invalid", WithConnectionReset(1), WithRetry(1, time.Millisecond, time.Millisecond), WithFallback(&memorySink{}))
+
+	logger.deliver(transport.client, benchmarkBatch(1))
+	if n := roundTripper.closes.Load(); n != 1 {
+		t.Errorf("Expected the wrapped transport's connections closed, got %d resets", n)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+// Transport is an HTTP client, with its connection pool, and a bounded number of
+// concurrent ingestion requests that several loggers can share through WithTransport,
+// e.g. loggers with different minimum levels or properties sending to the same server
+type Transport struct {
+	client *http.Client
+	slots  chan struct{} // one token per request in flight, nil for no limit
+}
+
+// NewTransport creates a Transport sending at most maxConcurrent requests at a time
+// across all loggers using it; maxConcurrent <= 0 means no limit
+func NewTransport(maxConcurrent int) *Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	t := &Transport{client: &http.Client{Transport: transport}}
+	if maxConcurrent > 0 {
+		// Keep a pooled connection for every sender so they are reused, not redialled
+		transport.MaxIdleConnsPerHost = maxConcurrent
+		t.slots = make(chan struct{}, maxConcurrent)
+	}
+	return t
+}
+
+// newPrivateTransport creates the transport of a logger not given one with WithTransport.
+// It has its own connection pool, so resetting it leaves other clients alone.
+func newPrivateTransport() *Transport {
+	return &Transport{client: &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}}
+}
+
+// WithDialAddress connects to address, such as "10.0.4.2:443", instead of the address
+// the server URL's hostname resolves to, while requests keep that hostname as their
+// Host header and TLS server name, for split-horizon DNS or bypassing a service mesh.
+// A Transport shared through WithTransport keeps its request limit but not its
+// connection pool.
+func WithDialAddress(address string) Option {
+	return func(l *SEQLogger) {
+		l.dialAddress = address
+	}
+}
+
+// dialing returns a copy of t whose connections all go to address, see WithDialAddress
+func (t *Transport) dialing(address string) *Transport {
+	base, ok := t.client.Transport.(*http.Transport)
+	if t.client.Transport == nil {
+		base, ok = http.DefaultTransport.(*http.Transport), true
+	}
+	if !ok {
+		selfLogf("Failed to set the dial address: unsupported transport %T", t.client.Transport)
+		return t
+	}
+	transport := base.Clone()
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return dial(ctx, network, address)
+	}
+	client := *t.client
+	client.Transport = transport
+	return &Transport{client: &client, slots: t.slots}
+}
+
+// defaultConnectionResetAfter is the default number of consecutive failed requests
+// after which pooled connections are closed, see WithConnectionReset
+const defaultConnectionResetAfter = 3
+
+// WithConnectionReset closes the pooled keep-alive connections to the SEQ server after
+// failures consecutive requests fail without a response, so the next request dials
+// afresh and resolves the hostname again, following its IPs when they change in a
+// blue/green switch or Kubernetes service churn. It defaults to 3; failures <= 0
+// never resets connections.
+func WithConnectionReset(failures int) Option {
+	return func(l *SEQLogger) {
+		l.connectionResetAfter = failures
+	}
+}
+
+// requestFailed counts a request that got no response, closing the idle connections
+// of client once connectionResetAfter requests in a row failed
+func (l *SEQLogger) requestFailed(client *http.Client) {
+	if l.connectionResetAfter <= 0 {
+		return
+	}
+	if int(l.connectionFailures.Add(1)) < l.connectionResetAfter {
+		return
+	}
+	l.connectionFailures.Store(0)
+	selfLogf("Closing connections to the SEQ server after %d failed requests", l.connectionResetAfter)
+	client.CloseIdleConnections()
+}
+
+// acquire waits for a free request slot; the returned function releases it
+func (t *Transport) acquire(ctx context.Context) (func(), error) {
+	if t == nil || t.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case t.slots <- struct{}{}:
+		return func() { <-t.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
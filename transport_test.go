@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSharedTransportLimitsConcurrentRequests(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		inFlight.Add(-1)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	transport := NewTransport(1)
+	loggers := []*SEQLogger{
+		newTestLogger(t, server.URL+EndpointRaw, 10, WithTransport(transport)),
+		newTestLogger(t, server.URL+EndpointRaw, 10, WithTransport(transport), WithMinLevel(LevelWarning)),
+		newTestLogger(t, server.URL+EndpointRaw, 10, WithTransport(transport)),
+	}
+	for _, logger := range loggers {
+		logger.Log(LevelWarning, "Disk almost full", nil)
+	}
+	for _, logger := range loggers {
+		if err := logger.Flush(context.Background()); err != nil {
+			t.Errorf("Flush failed: %v", err)
+		}
+		logger.Close()
+	}
+
+	if p := peak.Load(); p != 1 {
+		t.Errorf("Expected at most 1 request in flight, saw %d", p)
+	}
+	if loggers[0].transport.client != loggers[1].transport.client {
+		t.Error("Expected the loggers to share one HTTP client")
+	}
+}
+
+// failingRoundTripper fails every request and counts the calls to CloseIdleConnections
+type failingRoundTripper struct {
+	closes atomic.Int32
+}
+
+func (f *failingRoundTripper) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (f *failingRoundTripper) CloseIdleConnections() {
+	f.closes.Add(1)
+}
+
+func TestConnectionsResetAfterConsecutiveFailures(t *testing.T) {
+	roundTripper := &failingRoundTripper{}
+	client := &http.Client{Transport: roundTripper}
+	logger := newSenderLogger("http://seq.invalid", WithConnectionReset(2), WithRetry(5, time.Millisecond, time.Millisecond), WithFallback(&memorySink{}))
+
+	logger.deliver(client, benchmarkBatch(1))
+	if n := roundTripper.closes.Load(); n != 2 {
+		t.Errorf("Expected connections closed after every 2 of 5 failed requests, got %d resets", n)
+	}
+}
+
+func TestDialAddressKeepsHostAndServerName(t *testing.T) {
+	type request struct{ host, serverName string }
+	requests := make(chan request, 1)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- request{r.Host, r.TLS.ServerName}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	logger := newTestLogger(t, "https://example.com:"+port+EndpointRaw, 10,
+		WithTransport(&Transport{client: server.Client()}),
+		WithDialAddress(server.Listener.Addr().String()),
+	)
+	logger.Log(LevelInformation, "Started", nil)
+	if err := logger.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	logger.Close()
+
+	if got := <-requests; got != (request{"example.com:" + port, "example.com"}) {
+		t.Errorf("Expected the logical hostname, got %+v", got)
+	}
+}
+
+func TestPrewarmConnectsBeforeFirstBatch(t *testing.T) {
+	var connections atomic.Int32
+	var methods []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	logger := newSenderLogger(server.URL+EndpointRaw, WithPrewarm())
+	logger.prewarmConnection()
+	if err := logger.deliver(logger.transport.client, benchmarkBatch(1)); err != nil {
+		t.Fatalf("Failed to deliver: %v", err)
+	}
+
+	if len(methods) != 2 || methods[0] != http.MethodHead {
+		t.Errorf("Expected a HEAD request before the batch, got %v", methods)
+	}
+	if n := connections.Load(); n != 1 {
+		t.Errorf("Expected the batch to reuse the prewarmed connection, got %d connections", n)
+	}
+}
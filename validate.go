@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ValidationErrorProperty carries why a validator rejected an event written to the quarantine sink
+const ValidationErrorProperty = "ValidationError"
+
+// Validator checks an event before it is queued; an error rejects the event
+type Validator func(logMessage *LogMessage) error
+
+// JSONValidator adapts a validator of JSON documents, e.g. the Validate method of a
+// compiled JSON Schema, to a Validator; it is given each event as a CLEF object
+func JSONValidator(validate func(event []byte) error) Validator {
+	return func(logMessage *LogMessage) error {
+		var buf bytes.Buffer
+		if err := (CLEFEncoder{}).Encode(&buf, []LogMessage{*logMessage}); err != nil {
+			return fmt.Errorf("Failed to encode event: %w", err)
+		}
+		return validate(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	}
+}
+
+// WithValidator checks the events logged with template against validator before they
+// are queued, or every event when template is empty. Rejected events never reach SEQ:
+// they are counted as dropped and reported through the self log, or written to the
+// quarantine sink, see WithQuarantine.
+func WithValidator(template string, validator Validator) Option {
+	return func(l *SEQLogger) {
+		if l.validators == nil {
+			l.validators = make(map[string][]Validator)
+		}
+		l.validators[template] = append(l.validators[template], validator)
+	}
+}
+
+// WithQuarantine writes the events rejected by a validator to sink, with the reason as
+// ValidationError, instead of only reporting them
+func WithQuarantine(sink Sink) Option {
+	return func(l *SEQLogger) {
+		l.quarantine = sink
+	}
+}
+
+// validate runs the validators of an event's template and of every template, and
+// quarantines the event if one rejects it. It reports whether the event may be queued.
+// The event is taken by value so that, without validators, log doesn't move it to the heap.
+func (l *SEQLogger) validate(event LogMessage) bool {
+	logMessage := &event
+	err := runValidators(l.validators[logMessage.MessageTemplate], logMessage)
+	if err == nil && logMessage.MessageTemplate != "" {
+		err = runValidators(l.validators[""], logMessage)
+	}
+	if err == nil {
+		return true
+	}
+
+	l.dropped.Add(1)
+	if l.quarantine == nil {
+		selfLogf("Event %q rejected by validator: %v", logMessage.MessageTemplate, err)
+		return false
+	}
+	rejected := *logMessage
+	rejected.Fields = withField(logMessage.Fields, ValidationErrorProperty, err.Error())
+	if err := l.quarantine.Emit([]LogMessage{rejected}); err != nil {
+		selfLogf("Failed to write rejected event to quarantine %T: %v", l.quarantine, err)
+	}
+	return false
+}
+
+// runValidators returns the first error of validators for logMessage
+func runValidators(validators []Validator, logMessage *LogMessage) error {
+	for _, validator := range validators {
+		if err := validator(logMessage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidatorRejectsEventsOfItsTemplate(t *testing.T) {
+	requireOrder := func(logMessage *LogMessage) error {
+		if _, ok := logMessage.Fields["OrderId"]; !ok {
+			return errors.New("OrderId is required")
+		}
+		return nil
+	}
+	logger := newQueueLogger(10, WithValidator("Order {OrderId} shipped", requireOrder))
+
+	logger.Log(LevelInformation, "Order {OrderId} shipped", nil)
+	logger.Log(LevelInformation, "Order {OrderId} shipped", map[string]interface{}{"OrderId": 7})
+	logger.Log(LevelInformation, "Cache warmed", nil)
+
+	if len(logger.logChan) != 2 || logger.dropped.Load() != 1 {
+		t.Fatalf("Expected one event rejected, got %d queued and %d dropped", len(logger.logChan), logger.dropped.Load())
+	}
+	if logMessage := <-logger.logChan; logMessage.Fields["OrderId"] != 7 {
+		t.Errorf("Expected the conforming event, got %+v", logMessage)
+	}
+}
+
+func TestRejectedEventsGoToQuarantine(t *testing.T) {
+	var quarantined bytes.Buffer
+	schema := JSONValidator(func(event []byte) error {
+		var decoded map[string]interface{}
+		if err := json.Unmarshal(event, &decoded); err != nil {
+			return err
+		}
+		if level, ok := decoded["@l"]; ok && level != LevelInformation {
+			return errors.New("only Information events are allowed")
+		}
+		return nil
+	})
+	logger := newQueueLogger(10, WithValidator("", schema), WithQuarantine(NewWriterSink(&quarantined, CLEFEncoder{})))
+
+	logger.Log(LevelInformation, "Started", nil)
+	logger.Log(LevelWarning, "Disk {Disk} almost full", map[string]interface{}{"Disk": "C"})
+
+	if len(logger.logChan) != 1 {
+		t.Errorf("Expected only the Information event queued, got %d", len(logger.logChan))
+	}
+	if line := quarantined.String(); !strings.Contains(line, `"Disk":"C"`) || !strings.Contains(line, `"ValidationError":"only Information events are allowed"`) {
+		t.Errorf("Unexpected quarantine output %q", line)
+	}
+}
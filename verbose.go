@@ -0,0 +1,21 @@
+//go:build !seqlog_noverbose
+
+package main
+
+import "time"
+
+// VerboseCompiled is false when the program is built with the seqlog_noverbose tag,
+// which turns Verbose into a no-op. Guarding a Verbose call whose fields are costly
+// to build, if VerboseCompiled { ... }, lets the compiler remove it entirely.
+const VerboseCompiled = true
+
+// Verbose logs a Verbose event, the most detailed tracing level. Build with
+// -tags seqlog_noverbose to compile it out, or raise the minimum level above Verbose
+// to skip it at run time for the cost of one atomic load.
+func (l *SEQLogger) Verbose(message string, fields map[string]interface{}) {
+	root := l.pipeline()
+	if !root.enabled(0, fields, l.contextFields) {
+		return
+	}
+	root.log(l.contextFields, time.Time{}, LevelVerbose, message, fields)
+}
@@ -0,0 +1,10 @@
+//go:build seqlog_noverbose
+
+package main
+
+// VerboseCompiled is false when the program is built with the seqlog_noverbose tag,
+// which turns Verbose into a no-op
+const VerboseCompiled = false
+
+// Verbose does nothing: the program was built with the seqlog_noverbose tag
+func (l *SEQLogger) Verbose(message string, fields map[string]interface{}) {}
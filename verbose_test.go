@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestVerboseFollowsMinimumLevel(t *testing.T) {
+	if !VerboseCompiled {
+		t.Skip("Verbose is compiled out")
+	}
+	logger := newQueueLogger(2, WithLevelOverride("trace", LevelVerbose), WithMinLevel(LevelDebug))
+	logger.Verbose("Dropped", nil)
+	logger.Named("trace").Verbose("Kept {Step}", map[string]interface{}{"Step": 1})
+
+	if len(logger.logChan) != 1 {
+		t.Fatalf("Expected only the overridden Verbose event, got %d events", len(logger.logChan))
+	}
+	if logMessage := <-logger.logChan; logMessage.Level != LevelVerbose || logMessage.Fields["Step"] != 1 {
+		t.Errorf("Unexpected event %+v", logMessage)
+	}
+}
+
+func BenchmarkVerboseFiltered(b *testing.B) {
+	logger := newQueueLogger(1, WithMinLevel(LevelInformation))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.Verbose("Step {Step}", nil)
+	}
+}
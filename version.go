@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// serverVersion is a SEQ version such as 5.1.3200 or 2023.4.10219, compared part by
+// part; the zero value stands for a server whose version is unknown
+type serverVersion [3]int
+
+// maxVersionBody bounds how much of the API root's response is read
+const maxVersionBody = 4 << 10
+
+// Minimum server versions of the optional behaviors gated by WithVersionDetection
+var (
+	// Seq 5.0 added the /ingest/clef endpoint; older servers only take raw events JSON
+	// on /api/events/raw
+	minVersionCLEF = serverVersion{5, 0, 0}
+	// Seq 2022.1 accepts gzip-encoded ingestion requests
+	minVersionCompression = serverVersion{2022, 1, 0}
+	// Seq 3.0 returns the API key's MinimumLevelAccepted in ingestion responses
+	minVersionLevelFeedback = serverVersion{3, 0, 0}
+)
+
+// detectedVersions caches the version found for each server base URL, as
+// negotiatedEndpoints does for the endpoint
+var detectedVersions sync.Map
+
+// parseServerVersion parses the leading numeric parts of version
+func parseServerVersion(version string) (serverVersion, bool) {
+	var v serverVersion
+	parts := strings.SplitN(version, ".", len(v)+1)
+	for i := 0; i < len(parts) && i < len(v); i++ {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return serverVersion{}, false
+		}
+		v[i] = n
+	}
+	return v, v != serverVersion{}
+}
+
+// less reports whether v is older than other
+func (v serverVersion) less(other serverVersion) bool {
+	for i := range v {
+		if v[i] != other[i] {
+			return v[i] < other[i]
+		}
+	}
+	return false
+}
+
+func (v serverVersion) String() string {
+	return strconv.Itoa(v[0]) + "." + strconv.Itoa(v[1]) + "." + strconv.Itoa(v[2])
+}
+
+// WithVersionDetection reads the server's version from its API root, /api, as the
+// logger is created, and turns off what the requested options rely on that the
+// server doesn't support, noting it in the self log: compression before SEQ 2022.1,
+// CLEF and its endpoint before SEQ 5, sending raw events JSON instead, and level
+// feedback before SEQ 3. A server whose version can't be read keeps every option.
+func WithVersionDetection() Option {
+	return func(l *SEQLogger) {
+		l.versionDetection = true
+	}
+}
+
+// ServerVersion returns the version WithVersionDetection read from the server, "" if
+// it wasn't read
+func (l *SEQLogger) ServerVersion() string {
+	root := l.pipeline()
+	if root.serverVersion == (serverVersion{}) {
+		return ""
+	}
+	return root.serverVersion.String()
+}
+
+// detectServerVersion asks the server at the base of seqURL for its version
+func (l *SEQLogger) detectServerVersion() serverVersion {
+	u, err := url.Parse(l.seqURL)
+	if err != nil {
+		return serverVersion{}
+	}
+	u.Path, _ = splitEndpoint(u.Path)
+	u.Path += "/api"
+	u.RawPath, u.RawQuery = "", ""
+	root := u.String()
+	if version, ok := detectedVersions.Load(root); ok {
+		return version.(serverVersion)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), negotiationTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", root, nil)
+	if err != nil {
+		return serverVersion{}
+	}
+	l.setHeaders(req)
+	if err := l.authorize(ctx, req); err != nil {
+		selfLogf("Failed to read the SEQ server version: %v", err)
+		return serverVersion{}
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := l.transport.client.Do(req)
+	if err != nil {
+		selfLogf("Failed to read the SEQ server version: %v", err)
+		return serverVersion{}
+	}
+	defer resp.Body.Close()
+	var info struct {
+		Version string
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(io.LimitReader(resp.Body, maxVersionBody)).Decode(&info) != nil {
+		selfLogf("Failed to read the SEQ server version: %s answered %s", root, resp.Status)
+		return serverVersion{}
+	}
+	version, ok := parseServerVersion(info.Version)
+	if !ok {
+		selfLogf("Failed to read the SEQ server version: unknown version %q", info.Version)
+		return serverVersion{}
+	}
+	detectedVersions.Store(root, version)
+	return version
+}
+
+// gateFeatures turns off the options the server at version doesn't support
+func (l *SEQLogger) gateFeatures(version serverVersion) {
+	l.serverVersion = version
+	if version == (serverVersion{}) {
+		return
+	}
+
+	if l.compression != nil && version.less(minVersionCompression) {
+		selfLogf("SEQ %s doesn't accept compressed requests, sending them uncompressed", version)
+		l.compression = nil
+	}
+	if version.less(minVersionCLEF) {
+		if u, err := url.Parse(l.seqURL); err == nil {
+			if prefix, endpoint := splitEndpoint(u.Path); endpoint == EndpointCLEF {
+				selfLogf("SEQ %s has no %s endpoint, sending to %s", version, EndpointCLEF, EndpointRaw)
+				u.Path, u.RawPath = prefix+EndpointRaw, ""
+				l.seqURL = u.String()
+			}
+		}
+		if l.encoder.ContentType() == (CLEFEncoder{}).ContentType() {
+			selfLogf("SEQ %s doesn't accept CLEF, sending raw events JSON", version)
+			l.encoder = RawEncoder{}
+		}
+	}
+	if l.levelFeedback && version.less(minVersionLevelFeedback) {
+		selfLogf("SEQ %s doesn't report a minimum level, leaving level feedback off", version)
+		l.levelFeedback = false
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseServerVersion(t *testing.T) {
+	for version, want := range map[string]serverVersion{
+		"5.1.3200":      {5, 1, 3200},
+		"2023.4.10219":  {2023, 4, 10219},
+		"2022.1":        {2022, 1, 0},
+		"4.2.1.0-pre":   {4, 2, 1},
+		"":              {},
+		"unknown":       {},
+		"2021.x.12.100": {},
+	} {
+		if got, _ := parseServerVersion(version); got != want {
+			t.Errorf("Expected %q parsed as %v, got %v", version, want, got)
+		}
+	}
+	if !(serverVersion{4, 9, 0}).less(minVersionCLEF) || (serverVersion{2022, 1, 0}).less(minVersionCompression) {
+		t.Error("Unexpected version order")
+	}
+}
+
+func TestVersionDetectionGatesFeatures(t *testing.T) {
+	messages := captureSelfLog(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/seq/api" {
+			w.Write([]byte(`{"Version":"4.2.1"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	logger := newTestLogger(t, server.URL+"/seq"+EndpointCLEF, 1, WithVersionDetection(),
+		WithEncoder(CLEFEncoder{}), WithCompression("gzip", 0), WithLevelFeedback())
+	defer logger.Close()
+
+	if version := logger.Named("Billing").ServerVersion(); version != "4.2.1" {
+		t.Errorf("Expected version 4.2.1, got %q", version)
+	}
+	if logger.seqURL != server.URL+"/seq"+EndpointRaw {
+		t.Errorf("Expected the raw endpoint, got %s", logger.seqURL)
+	}
+	if _, ok := logger.encoder.(RawEncoder); !ok || logger.compression != nil || !logger.levelFeedback {
+		t.Errorf("Expected raw events uncompressed with level feedback, got %T, %v, %v",
+			logger.encoder, logger.compression, logger.levelFeedback)
+	}
+	if got := strings.Join(messages(), "\n"); !strings.Contains(got, "doesn't accept compressed requests") ||
+		!strings.Contains(got, "doesn't accept CLEF") {
+		t.Errorf("Expected the gated features in the self log, got %s", got)
+	}
+}
+
+func TestUnknownServerVersionKeepsFeatures(t *testing.T) {
+	server := newSeqRecorder(t)
+	logger := newTestLogger(t, server.URL+EndpointCLEF, 1, WithVersionDetection(), WithEncoder(CLEFEncoder{}))
+	defer logger.Close()
+
+	if logger.ServerVersion() != "" || logger.seqURL != server.URL+EndpointCLEF {
+		t.Errorf("Expected an unknown version to keep CLEF, got %q on %s", logger.ServerVersion(), logger.seqURL)
+	}
+}
+
+func TestLevelFeedbackFollowsServer(t *testing.T) {
+	var level atomic.Value
+	level.Store(`{"MinimumLevelAccepted":"Warning"}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(level.Load().(string)))
+	}))
+	defer server.Close()
+
+	logger := newTestLogger(t, server.URL+EndpointRaw, 10, WithLevelFeedback())
+	defer logger.Close()
+
+	logger.Log(LevelInformation, "Order placed", nil)
+	if err := logger.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	if logger.IsEnabled(LevelInformation) || !logger.Named("Billing").IsEnabled(LevelError) {
+		t.Error("Expected only events from Warning up enabled")
+	}
+
+	level.Store(`{"MinimumLevelAccepted":null}`)
+	logger.Log(LevelWarning, "Stock low", nil)
+	if err := logger.Flush(context.Background()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	if !logger.IsEnabled(LevelInformation) {
+		t.Error("Expected Information enabled again once the server reports no level")
+	}
+}
+
+func TestVersionDetectionGatesLevelFeedback(t *testing.T) {
+	messages := captureSelfLog(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api" {
+			w.Write([]byte(`{"Version":"2.4.2"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	logger := newTestLogger(t, server.URL+EndpointRaw, 1, WithVersionDetection(), WithLevelFeedback())
+	defer logger.Close()
+	if logger.levelFeedback {
+		t.Error("Expected level feedback off before SEQ 3")
+	}
+	if got := strings.Join(messages(), "\n"); !strings.Contains(got, "doesn't report a minimum level") {
+		t.Errorf("Expected the gated level feedback in the self log, got %s", got)
+	}
+}
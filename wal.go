@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// WAL file names inside the directory passed to WithWAL
+const (
+	walEntriesFile = "events.wal"
+	walAcksFile    = "events.ack"
+)
+
+// walEntry is one line of the entries file
+type walEntry struct {
+	Seq   uint64     `json:"seq"`
+	Event LogMessage `json:"event"`
+}
+
+// walCompactEntries is how many entries the entries file holds before it is compacted
+// around the events still unacknowledged, once at least half of them are acknowledged
+const walCompactEntries = 10000
+
+// writeAheadLog persists every accepted event before Log returns and records which
+// events Seq has confirmed. The entries file holds one JSON walEntry per line and the
+// acks file holds "first last" sequence ranges. Writes are not fsynced per event, so
+// the log survives a process crash but not necessarily a power loss.
+type writeAheadLog struct {
+	mu           sync.Mutex
+	entriesPath  string
+	acksPath     string
+	entries      *os.File
+	acks         *os.File
+	nextSeq      uint64
+	pending      int // entries not acknowledged
+	written      int // entries in the entries file
+	compactAfter int
+	line         bytes.Buffer
+}
+
+// openWAL opens the write-ahead log in dir and returns the events that were never
+// acknowledged, in their original order. The files are compacted to hold only those events.
+func openWAL(dir string) (*writeAheadLog, []LogMessage, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, nil, err
+	}
+	entriesPath := filepath.Join(dir, walEntriesFile)
+	acksPath := filepath.Join(dir, walAcksFile)
+
+	acked, err := readWALAcks(acksPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	unacked, err := readWALEntries(entriesPath, acked)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w := &writeAheadLog{entriesPath: entriesPath, acksPath: acksPath, nextSeq: 1, compactAfter: walCompactEntries}
+	if err := w.rewrite(entriesPath, unacked); err != nil {
+		return nil, nil, err
+	}
+	if w.entries, err = os.OpenFile(entriesPath, os.O_WRONLY|os.O_APPEND, 0o600); err != nil {
+		return nil, nil, err
+	}
+	if w.acks, err = os.OpenFile(acksPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0o600); err != nil {
+		w.entries.Close()
+		return nil, nil, err
+	}
+
+	replay := make([]LogMessage, len(unacked))
+	for i, entry := range unacked {
+		replay[i] = entry.Event
+		replay[i].walSeq = entry.Seq
+	}
+	return w, replay, nil
+}
+
+// readWALAcks reads the acknowledged sequence ranges
+func readWALAcks(path string) (map[uint64]bool, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	acked := make(map[uint64]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var first, last uint64
+		// A torn last line from a crash mid-write is ignored; its events are replayed
+		if _, err := fmt.Sscan(scanner.Text(), &first, &last); err != nil {
+			continue
+		}
+		for seq := first; seq <= last; seq++ {
+			acked[seq] = true
+		}
+	}
+	return acked, scanner.Err()
+}
+
+// readWALEntries reads the entries that are not in acked
+func readWALEntries(path string, acked map[uint64]bool) ([]walEntry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var unacked []walEntry
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			var entry walEntry
+			decoder := json.NewDecoder(bytes.NewReader(line))
+			decoder.UseNumber() // keep integer properties exact
+			if decoder.Decode(&entry) == nil && !acked[entry.Seq] {
+				unacked = append(unacked, entry)
+			}
+		}
+		if err == io.EOF {
+			return unacked, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// rewrite atomically replaces the entries file with entries, renumbered from 1
+func (w *writeAheadLog) rewrite(path string, entries []walEntry) error {
+	for i := range entries {
+		entries[i].Seq = w.nextSeq
+		w.nextSeq++
+	}
+	if err := writeWALEntries(path, entries); err != nil {
+		return err
+	}
+	w.pending = len(entries)
+	w.written = len(entries)
+	return nil
+}
+
+// writeWALEntries atomically replaces the entries file at path with entries
+func writeWALEntries(path string, entries []walEntry) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), walEntriesFile+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	for i := range entries {
+		data, err := json.Marshal(entries[i])
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		writer.Write(data)
+		writer.WriteByte('\n')
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// append assigns logMessage the next sequence number and writes it to the log
+func (w *writeAheadLog) append(logMessage *LogMessage) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entry := walEntry{Seq: w.nextSeq, Event: *logMessage}
+	w.line.Reset()
+	if err := json.NewEncoder(&w.line).Encode(entry); err != nil {
+		return err
+	}
+	if _, err := w.entries.Write(w.line.Bytes()); err != nil {
+		return err
+	}
+
+	logMessage.walSeq = w.nextSeq
+	w.nextSeq++
+	w.pending++
+	w.written++
+	return nil
+}
+
+// ack records the events of batch as delivered. Once every written event is
+// acknowledged both files are truncated, and while events that failed to be delivered
+// stay unacknowledged, for a replay on the next start, the files are compacted around
+// them, so the log does not grow without bound.
+func (w *writeAheadLog) ack(batch []LogMessage) error {
+	seqs := make([]uint64, 0, len(batch))
+	for _, logMessage := range batch {
+		if logMessage.walSeq != 0 {
+			seqs = append(seqs, logMessage.walSeq)
+		}
+	}
+	if len(seqs) == 0 {
+		return nil
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending -= len(seqs)
+	if w.pending == 0 {
+		w.written = 0
+		if err := w.entries.Truncate(0); err != nil {
+			return err
+		}
+		return w.acks.Truncate(0)
+	}
+
+	var ranges []byte
+	for i := 0; i < len(seqs); {
+		j := i
+		for j+1 < len(seqs) && seqs[j+1] == seqs[j]+1 {
+			j++
+		}
+		ranges = strconv.AppendUint(ranges, seqs[i], 10)
+		ranges = append(ranges, ' ')
+		ranges = strconv.AppendUint(ranges, seqs[j], 10)
+		ranges = append(ranges, '\n')
+		i = j + 1
+	}
+	if _, err := w.acks.Write(ranges); err != nil {
+		return err
+	}
+	if w.written >= w.compactAfter && w.pending <= w.written/2 {
+		return w.compact()
+	}
+	return nil
+}
+
+// compact rewrites the entries file with the unacknowledged entries only, keeping
+// their sequence numbers as queued events still carry them, and empties the acks file
+func (w *writeAheadLog) compact() error {
+	acked, err := readWALAcks(w.acksPath)
+	if err != nil {
+		return err
+	}
+	unacked, err := readWALEntries(w.entriesPath, acked)
+	if err != nil {
+		return err
+	}
+	// The entries file is closed first, as Windows can't rename over an open file
+	if err := w.entries.Close(); err != nil {
+		return err
+	}
+	writeErr := writeWALEntries(w.entriesPath, unacked)
+	if w.entries, err = os.OpenFile(w.entriesPath, os.O_WRONLY|os.O_APPEND, 0o600); err != nil {
+		return err
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+	w.pending = len(unacked)
+	w.written = len(unacked)
+	return w.acks.Truncate(0)
+}
+
+// close closes the WAL files
+func (w *writeAheadLog) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.entries.Close()
+	w.acks.Close()
+}
+
+// acknowledge marks the events of a dispatched batch as delivered in the WAL: all of
+// them when Seq accepted the batch, otherwise only those never meant for Seq
+func (l *SEQLogger) acknowledge(batch, scratch []LogMessage, delivered bool) []LogMessage {
+	events := batch
+	if !delivered {
+		scratch = scratch[:0]
+		for _, logMessage := range batch {
+			if levelRank(logMessage.Level) < l.seqMinLevel {
+				scratch = append(scratch, logMessage)
+			}
+		}
+		events = scratch
+	}
+	if err := l.wal.ack(events); err != nil {
+		selfLogf("Failed to acknowledge log messages in WAL: %v", err)
+	}
+	return scratch
+}
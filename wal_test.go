@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newWALLogger creates a queue logger posting to seqURL with a write-ahead log in dir
+func newWALLogger(t *testing.T, seqURL, dir string) (*SEQLogger, []LogMessage) {
+	wal, replay, err := openWAL(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(wal.close)
+
+	logger := newQueueLogger(10, WithEncoder(RawEncoder{}))
+	logger.seqURL = seqURL
+	logger.retry = defaultRetryPolicy
+	logger.wal = wal
+	return logger, replay
+}
+
+// drainQueue returns the queued events of a logger created by newQueueLogger
+func drainQueue(logger *SEQLogger) []LogMessage {
+	var batch []LogMessage
+	for len(logger.logChan) > 0 {
+		batch = append(batch, <-logger.logChan)
+	}
+	return batch
+}
+
+func TestWALReplaysUnacknowledgedEvents(t *testing.T) {
+	dir := t.TempDir()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	logger, replay := newWALLogger(t, down.URL, dir)
+	if len(replay) != 0 {
+		t.Fatalf("Expected an empty WAL, got %d events", len(replay))
+	}
+	logger.Log(LevelInformation, "Order {OrderId} placed", map[string]interface{}{"OrderId": int64(1) << 60})
+	logger.Log(LevelError, "Payment failed", nil)
+	logger.dispatch(down.Client(), drainQueue(logger), nil)
+
+	// A new logger on the same directory, as after a restart, replays both events
+	seq := newSeqRecorder(t)
+	logger, replay = newWALLogger(t, seq.URL, dir)
+	if len(replay) != 2 {
+		t.Fatalf("Expected 2 replayed events, got %d", len(replay))
+	}
+	if replay[0].MessageTemplate != "Order {OrderId} placed" || replay[1].MessageTemplate != "Payment failed" {
+		t.Errorf("Unexpected replay order %+v", replay)
+	}
+	if id, _ := replay[0].Fields["OrderId"].(json.Number); id.String() != "1152921504606846976" {
+		t.Errorf("Expected exact integer property, got %v", replay[0].Fields["OrderId"])
+	}
+
+	logger.dispatch(seq.Client(), replay, nil)
+	if !strings.Contains(seq.received(), "1152921504606846976") {
+		t.Errorf("Replayed events not delivered: %s", seq.received())
+	}
+
+	info, err := os.Stat(filepath.Join(dir, walEntriesFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 0 {
+		t.Errorf("Expected the WAL to be compacted once everything is acknowledged, size %d", info.Size())
+	}
+	if _, replay = newWALLogger(t, seq.URL, dir); len(replay) != 0 {
+		t.Errorf("Expected no events to replay after delivery, got %d", len(replay))
+	}
+}
+
+func TestWALAcknowledgesPartialDelivery(t *testing.T) {
+	dir := t.TempDir()
+	seq := newSeqRecorder(t)
+	logger, _ := newWALLogger(t, seq.URL, dir)
+
+	for _, template := range []string{"First", "Second", "Third"} {
+		logger.Log(LevelInformation, template, nil)
+	}
+	events := drainQueue(logger)
+	logger.dispatch(seq.Client(), events[:2], nil)
+
+	_, replay := newWALLogger(t, seq.URL, dir)
+	if len(replay) != 1 || replay[0].MessageTemplate != "Third" {
+		t.Errorf("Expected only the unsent event to replay, got %+v", replay)
+	}
+}
+
+func TestWALCompactsAroundFailedEvents(t *testing.T) {
+	dir := t.TempDir()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	seq := newSeqRecorder(t)
+
+	logger, _ := newWALLogger(t, down.URL, dir)
+	logger.wal.compactAfter = 4
+	logger.Log(LevelError, "Payment failed", nil)
+	logger.dispatch(down.Client(), drainQueue(logger), nil)
+
+	logger.seqURL = seq.URL
+	for i := 0; i < 20; i++ {
+		logger.Log(LevelInformation, "Order placed", nil)
+		logger.dispatch(seq.Client(), drainQueue(logger), nil)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, walEntriesFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines > 4 {
+		t.Errorf("Expected the WAL compacted around the failed event, got %d entries", lines)
+	}
+	if _, replay := newWALLogger(t, seq.URL, dir); len(replay) != 1 || replay[0].MessageTemplate != "Payment failed" {
+		t.Errorf("Expected only the failed event to replay, got %+v", replay)
+	}
+}